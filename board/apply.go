@@ -0,0 +1,115 @@
+package board
+
+import (
+	"errors"
+
+	"shogi/piece"
+)
+
+// 指せない手の理由
+var (
+	ErrNoPiece            = errors.New("board: 移動元に自分の駒がありません")
+	ErrNoPieceInHand      = errors.New("board: その駒を持っていません")
+	ErrNifu               = errors.New("board: 二歩です")
+	ErrIllegalDestination = errors.New("board: そのマスには指せません")
+	ErrCannotPromote      = errors.New("board: 成れません")
+	ErrMustPromote        = errors.New("board: 成らなければなりません")
+	ErrLeavesKingInCheck  = errors.New("board: 自玉が取られる手です")
+	ErrDropPawnMate       = errors.New("board: 打ち歩詰めです")
+)
+
+// 指し手が合法か調べ、指せない手ならその理由を返す
+func (b *Board) CheckMove(move Move) error {
+	return b.checkMove(move, true)
+}
+
+// 指し手を調べる（checkDropPawnMate が false なら打ち歩詰めは調べない）
+func (b *Board) checkMove(move Move, checkDropPawnMate bool) error {
+	if move.FromX == -1 && move.FromY == -1 {
+		if err := b.checkDrop(move); err != nil {
+			return err
+		}
+	} else if err := b.checkNormalMove(move); err != nil {
+		return err
+	}
+
+	next := b.Clone()
+	next.MakeMove(move)
+
+	// 指した後に自玉が取られる状態なら反則
+	if next.InCheck(b.CurrentPlayer) {
+		return ErrLeavesKingInCheck
+	}
+
+	// 打ち歩詰め
+	if checkDropPawnMate && move.FromX == -1 && move.Piece == piece.Pawn &&
+		next.IsCheck() && !next.hasLegalMove() {
+		return ErrDropPawnMate
+	}
+	return nil
+}
+
+// 駒打ちを調べる
+func (b *Board) checkDrop(move Move) error {
+	if !b.InBounds(move.ToX, move.ToY) {
+		return ErrIllegalDestination
+	}
+
+	// 持ち駒を打てない種類では持ち駒がないものとする
+	captures := b.SenteCaptures
+	if b.CurrentPlayer == piece.Gote {
+		captures = b.GoteCaptures
+	}
+	if b.variant().NoDrops || captures[move.Piece] <= 0 {
+		return ErrNoPieceInHand
+	}
+
+	if b.Grid[move.ToY][move.ToX].Type != piece.Empty {
+		return ErrIllegalDestination
+	}
+	if move.Piece == piece.Pawn && b.hasOwnPawnInColumn(move.ToX) {
+		return ErrNifu
+	}
+	if !b.canDropToPosition(move) {
+		return ErrIllegalDestination
+	}
+	return nil
+}
+
+// 盤上の駒の移動を調べる
+func (b *Board) checkNormalMove(move Move) error {
+	if !b.InBounds(move.FromX, move.FromY) {
+		return ErrNoPiece
+	}
+	if !b.InBounds(move.ToX, move.ToY) {
+		return ErrIllegalDestination
+	}
+
+	p := b.Grid[move.FromY][move.FromX]
+	if p.Type == piece.Empty || p.Player != b.CurrentPlayer {
+		return ErrNoPiece
+	}
+	if dest := b.Grid[move.ToY][move.ToX]; dest.Type != piece.Empty && dest.Player == b.CurrentPlayer {
+		return ErrIllegalDestination
+	}
+	if !b.isValidPieceMove(move, p) {
+		return ErrIllegalDestination
+	}
+
+	if move.Promote && !b.isValidPromotion(move, p) {
+		return ErrCannotPromote
+	}
+	if !move.Promote && b.mustPromote(move, p) {
+		return ErrMustPromote
+	}
+	return nil
+}
+
+// 指し手を調べてから指す（指せない手なら盤面を変えずに理由を返す）
+func (b *Board) ApplyMove(move Move) error {
+	if err := b.CheckMove(move); err != nil {
+		return err
+	}
+	b.MakeMove(move)
+	return nil
+}
@@ -0,0 +1,82 @@
+package board
+
+import (
+	"errors"
+	"testing"
+
+	"shogi/piece"
+)
+
+func TestApplyMoveErrors(t *testing.T) {
+	tests := []struct {
+		sfen string
+		move Move
+		want error
+	}{
+		// ▲７六歩
+		{StartSFEN, Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, nil},
+		// 後手の駒・空いたマスは動かせない
+		{StartSFEN, Move{FromX: 2, FromY: 2, ToX: 2, ToY: 3}, ErrNoPiece},
+		{StartSFEN, Move{FromX: 4, FromY: 4, ToX: 4, ToY: 3}, ErrNoPiece},
+		// 盤の外
+		{StartSFEN, Move{FromX: 0, FromY: 8, ToX: 0, ToY: 9}, ErrIllegalDestination},
+		// 自分の駒があるマス、駒の動けないマス
+		{StartSFEN, Move{FromX: 7, FromY: 7, ToX: 6, ToY: 6}, ErrIllegalDestination},
+		{StartSFEN, Move{FromX: 2, FromY: 6, ToX: 2, ToY: 4}, ErrIllegalDestination},
+		// 敵陣の外では成れない
+		{StartSFEN, Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5, Promote: true}, ErrCannotPromote},
+		// 金は成れない
+		{"4k4/9/4G4/9/9/9/9/9/4K4 b - 1", Move{FromX: 4, FromY: 2, ToX: 4, ToY: 1, Promote: true}, ErrCannotPromote},
+		// 行き所のない段には成らなければ行けない
+		{"4k4/P8/9/9/9/9/9/9/4K4 b - 1", Move{FromX: 0, FromY: 1, ToX: 0, ToY: 0}, ErrMustPromote},
+		{"4k4/9/9/6N2/9/9/9/9/4K4 b - 1", Move{FromX: 6, FromY: 3, ToX: 7, ToY: 1}, ErrMustPromote},
+		{"4k4/9/9/6N2/9/9/9/9/4K4 b - 1", Move{FromX: 6, FromY: 3, ToX: 7, ToY: 1, Promote: true}, nil},
+		// 持ち駒
+		{"4k4/9/9/9/9/9/9/9/4K4 b - 1", Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn}, ErrNoPieceInHand},
+		{"4k4/9/9/9/9/9/9/9/4K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 4, ToY: 8, Piece: piece.Pawn}, ErrIllegalDestination},
+		{"4k4/9/9/9/9/9/9/9/4K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 4, ToY: 0, Piece: piece.Pawn}, ErrIllegalDestination},
+		{"4k4/9/9/9/9/9/4P4/9/4K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn}, ErrNifu},
+		// 後手の持ち駒は先手の手番では打てない
+		{"4k4/9/9/9/9/9/9/9/4K4 b p 1", Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn}, ErrNoPieceInHand},
+		// 王手を放置する手、ピンされた駒を動かす手
+		{"4k4/9/9/9/9/9/9/4r4/3GK4 b - 1", Move{FromX: 3, FromY: 8, ToX: 2, ToY: 7}, ErrLeavesKingInCheck},
+		{"4k4/9/9/9/4r4/9/9/4B4/4K4 b - 1", Move{FromX: 4, FromY: 7, ToX: 3, ToY: 6}, ErrLeavesKingInCheck},
+		// 打ち歩詰め
+		{"8k/6S2/7G1/9/9/9/9/9/4K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 8, ToY: 1, Piece: piece.Pawn}, ErrDropPawnMate},
+	}
+	for _, tt := range tests {
+		b, _, err := ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := b.SFEN(1)
+		err = b.ApplyMove(tt.move)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s %+v: ApplyMove() = %v, want %v", tt.sfen, tt.move, err, tt.want)
+			continue
+		}
+		if err != nil && b.SFEN(1) != before {
+			t.Errorf("%s %+v: 指せない手で盤面が変わりました: %s", tt.sfen, tt.move, b.SFEN(1))
+		}
+		if err == nil && b.SFEN(1) == before {
+			t.Errorf("%s %+v: 指した手が盤面に反映されていません", tt.sfen, tt.move)
+		}
+	}
+}
+
+func TestDropPiece(t *testing.T) {
+	b, _, err := ParseSFEN("4k4/9/9/9/9/9/9/9/4K4 b G 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DropPiece(4, 4, piece.Pawn); !errors.Is(err, ErrNoPieceInHand) {
+		t.Fatalf("DropPiece(歩) = %v, want ErrNoPieceInHand", err)
+	}
+	if err := b.DropPiece(4, 4, piece.Gold); err != nil {
+		t.Fatal(err)
+	}
+	if b.SenteCaptures[piece.Gold] != 0 || b.CurrentPlayer != piece.Gote ||
+		b.GetPiece(4, 4) != (piece.Piece{Type: piece.Gold, Player: piece.Sente}) {
+		t.Errorf("DropPiece(金) の後の局面が違います: %s", b.SFEN(1))
+	}
+}
@@ -0,0 +1,50 @@
+package board
+
+import (
+	"shogi/piece"
+)
+
+// 盤上のマス (x, y) に利いている player の駒の位置
+// 盤面を書き換えないので、他のゴルーチンが同じ盤面を読んでいても呼べる
+func (b *Board) AttackersOf(x, y int, player piece.Player) [][2]int {
+	var attackers [][2]int
+	for fy := 0; fy < b.Height(); fy++ {
+		for fx := 0; fx < b.Width(); fx++ {
+			if b.attacks(fx, fy, x, y, player) {
+				attackers = append(attackers, [2]int{fx, fy})
+			}
+		}
+	}
+	return attackers
+}
+
+// 盤上のマス (x, y) に player の駒が利いているか
+func (b *Board) IsAttacked(x, y int, player piece.Player) bool {
+	for fy := 0; fy < b.Height(); fy++ {
+		for fx := 0; fx < b.Width(); fx++ {
+			if b.attacks(fx, fy, x, y, player) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// player の玉に王手がかかっているか（玉がなければ false）
+func (b *Board) InCheck(player piece.Player) bool {
+	x, y := b.findKing(player)
+	if x == -1 {
+		return false
+	}
+	return b.IsAttacked(x, y, player.Opposite())
+}
+
+// (fx, fy) にある player の駒が (x, y) に利いているか
+// 成らなければ行けない段への利きも利きとして数える
+func (b *Board) attacks(fx, fy, x, y int, player piece.Player) bool {
+	p := b.Grid[fy][fx]
+	if p.Type == piece.Empty || p.Player != player || (fx == x && fy == y) {
+		return false
+	}
+	return b.isValidPieceMove(Move{FromX: fx, FromY: fy, ToX: x, ToY: y}, p)
+}
@@ -0,0 +1,90 @@
+package board
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"shogi/piece"
+)
+
+func TestAttackersOf(t *testing.T) {
+	tests := []struct {
+		sfen   string
+		x, y   int
+		player piece.Player
+		want   [][2]int
+	}{
+		// ５八には先手の飛車と金２枚と玉が利いている
+		{StartSFEN, 4, 7, piece.Sente, [][2]int{{7, 7}, {3, 8}, {4, 8}, {5, 8}}},
+		// 初期局面の７六には先手の歩だけが利いている（角は歩が邪魔）
+		{StartSFEN, 2, 5, piece.Sente, [][2]int{{2, 6}}},
+		{StartSFEN, 2, 5, piece.Gote, nil},
+		// 飛び駒は間の駒で止まり、桂は跳び越える
+		{"4k4/9/9/9/4r4/4P4/9/9/4K4 b - 1", 4, 8, piece.Gote, nil},
+		{"4k4/9/9/9/9/9/5n3/9/4K4 b - 1", 4, 8, piece.Gote, [][2]int{{5, 6}}},
+		// 行き所のない段への利き（成らなければ行けない）
+		{"4k4/P8/9/9/9/9/9/9/4K4 b - 1", 0, 0, piece.Sente, [][2]int{{0, 1}}},
+	}
+	for _, tt := range tests {
+		b, _, err := ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.AttackersOf(tt.x, tt.y, tt.player); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (%d, %d): AttackersOf() = %v, want %v", tt.sfen, tt.x, tt.y, got, tt.want)
+		}
+		if got := b.IsAttacked(tt.x, tt.y, tt.player); got != (len(tt.want) > 0) {
+			t.Errorf("%s (%d, %d): IsAttacked() = %v", tt.sfen, tt.x, tt.y, got)
+		}
+	}
+}
+
+func TestInCheck(t *testing.T) {
+	b, _, err := ParseSFEN("4k4/9/9/9/9/9/9/4r4/4K4 w - 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.InCheck(piece.Sente) || b.InCheck(piece.Gote) {
+		t.Errorf("InCheck(先手) = %v, InCheck(後手) = %v", b.InCheck(piece.Sente), b.InCheck(piece.Gote))
+	}
+	if b.IsCheck() {
+		t.Error("手番の後手に王手がかかっています")
+	}
+	if b.CurrentPlayer != piece.Gote {
+		t.Error("王手の判定で手番が変わりました")
+	}
+}
+
+// 同じ盤面を複数のゴルーチンから同時に調べても競合しない（go test -race で確かめる）
+func TestConcurrentQueries(t *testing.T) {
+	b, _, err := ParseSFEN("lnsgk2nl/1r4gs1/p1pppp1pp/6p2/1p7/2P6/PPSPPPPPP/7R1/LN1GKGSNL w Bb 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := b.SFEN(1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				b.IsCheck()
+				b.InCheck(piece.Sente)
+				b.AttackersOf(4, 8, piece.Gote)
+				b.LegalMoves()
+				b.IsCheckmate()
+				b.Winner()
+				b.Validate()
+				b.Position()
+				b.SFEN(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := b.SFEN(1); got != want {
+		t.Errorf("調べた後の盤面 = %s, want %s", got, want)
+	}
+}
@@ -4,14 +4,23 @@ import (
 	"shogi/piece"
 )
 
+// 盤の最大の大きさ（本将棋の盤）
 const BoardSize = 9
 
 // 将棋盤の状態を管理する構造体
 type Board struct {
-	Grid          [BoardSize][BoardSize]piece.Piece
+	Grid          [BoardSize][BoardSize]piece.Piece // 盤より小さい種類では左上だけを使う
 	SenteCaptures map[piece.Type]int
 	GoteCaptures  map[piece.Type]int
 	CurrentPlayer piece.Player
+	Variant       *Variant // 将棋の種類（nilなら本将棋）
+}
+
+// 持ち駒になる駒の種類（表示・列挙の順序）
+var handPieceTypes = []piece.Type{
+	piece.Pawn, piece.Lance, piece.Knight,
+	piece.Silver, piece.Gold, piece.Bishop, piece.Rook,
+	piece.Giraffe, piece.Elephant,
 }
 
 // 移動を表す構造体
@@ -22,61 +31,18 @@ type Move struct {
 	Promote      bool       // 成るかどうか
 }
 
-// 新しい将棋盤を初期化
+// 新しい将棋盤を初期化（本将棋の初期配置）
 func New() *Board {
-	b := &Board{
+	return Standard.New()
+}
+
+// 駒が1枚もない将棋盤を作成（任意の局面を組み立てる場合に使用）
+func NewEmpty() *Board {
+	return &Board{
 		SenteCaptures: make(map[piece.Type]int),
 		GoteCaptures:  make(map[piece.Type]int),
 		CurrentPlayer: piece.Sente,
 	}
-
-	// 駒の初期配置
-	b.initializePieces()
-	return b
-}
-
-// 駒の初期配置を設定
-func (b *Board) initializePieces() {
-	// 先手の駒（下側）
-	b.placePiece(8, 0, piece.Lance, piece.Sente)
-	b.placePiece(8, 1, piece.Knight, piece.Sente)
-	b.placePiece(8, 2, piece.Silver, piece.Sente)
-	b.placePiece(8, 3, piece.Gold, piece.Sente)
-	b.placePiece(8, 4, piece.King, piece.Sente)
-	b.placePiece(8, 5, piece.Gold, piece.Sente)
-	b.placePiece(8, 6, piece.Silver, piece.Sente)
-	b.placePiece(8, 7, piece.Knight, piece.Sente)
-	b.placePiece(8, 8, piece.Lance, piece.Sente)
-
-	b.placePiece(7, 1, piece.Bishop, piece.Sente)
-	b.placePiece(7, 7, piece.Rook, piece.Sente)
-
-	for i := 0; i < BoardSize; i++ {
-		b.placePiece(6, i, piece.Pawn, piece.Sente)
-	}
-
-	// 後手の駒（上側）
-	b.placePiece(0, 0, piece.Lance, piece.Gote)
-	b.placePiece(0, 1, piece.Knight, piece.Gote)
-	b.placePiece(0, 2, piece.Silver, piece.Gote)
-	b.placePiece(0, 3, piece.Gold, piece.Gote)
-	b.placePiece(0, 4, piece.King, piece.Gote)
-	b.placePiece(0, 5, piece.Gold, piece.Gote)
-	b.placePiece(0, 6, piece.Silver, piece.Gote)
-	b.placePiece(0, 7, piece.Knight, piece.Gote)
-	b.placePiece(0, 8, piece.Lance, piece.Gote)
-
-	b.placePiece(1, 1, piece.Rook, piece.Gote)
-	b.placePiece(1, 7, piece.Bishop, piece.Gote)
-
-	for i := 0; i < BoardSize; i++ {
-		b.placePiece(2, i, piece.Pawn, piece.Gote)
-	}
-}
-
-// 駒を配置
-func (b *Board) placePiece(y, x int, pieceType piece.Type, player piece.Player) {
-	b.Grid[y][x] = piece.Piece{Type: pieceType, Player: player}
 }
 
 // 指定位置の駒を取得
@@ -89,7 +55,7 @@ func (b *Board) IsValidMove(move Move) bool {
 	// 駒打ちの場合
 	if move.FromX == -1 && move.FromY == -1 {
 		// 移動先の座標が盤の範囲内かチェック
-		if move.ToX < 0 || move.ToX >= BoardSize || move.ToY < 0 || move.ToY >= BoardSize {
+		if !b.InBounds(move.ToX, move.ToY) {
 			return false
 		}
 		return b.isValidDrop(move)
@@ -97,8 +63,7 @@ func (b *Board) IsValidMove(move Move) bool {
 
 	// 通常の移動の場合
 	// 移動元と移動先の座標が盤の範囲内かチェック
-	if move.FromX < 0 || move.FromX >= BoardSize || move.FromY < 0 || move.FromY >= BoardSize ||
-		move.ToX < 0 || move.ToX >= BoardSize || move.ToY < 0 || move.ToY >= BoardSize {
+	if !b.InBounds(move.FromX, move.FromY) || !b.InBounds(move.ToX, move.ToY) {
 		return false
 	}
 
@@ -107,6 +72,11 @@ func (b *Board) IsValidMove(move Move) bool {
 
 // 駒打ちの有効性をチェック
 func (b *Board) isValidDrop(move Move) bool {
+	// 持ち駒を打てない種類
+	if b.variant().NoDrops {
+		return false
+	}
+
 	// 持ち駒があるかチェック
 	if b.CurrentPlayer == piece.Sente {
 		if b.SenteCaptures[move.Piece] <= 0 {
@@ -123,38 +93,18 @@ func (b *Board) isValidDrop(move Move) bool {
 		return false
 	}
 
-	// 歩、香車、桂馬の特殊ルール
-	switch move.Piece {
-	case piece.Pawn:
-		// 二歩のチェック
-		if b.hasOwnPawnInColumn(move.ToX) {
-			return false
-		}
-		// 最奥の段には打てない
-		if (b.CurrentPlayer == piece.Sente && move.ToY == 0) ||
-			(b.CurrentPlayer == piece.Gote && move.ToY == BoardSize-1) {
-			return false
-		}
-	case piece.Lance:
-		// 最奥の段には打てない
-		if (b.CurrentPlayer == piece.Sente && move.ToY == 0) ||
-			(b.CurrentPlayer == piece.Gote && move.ToY == BoardSize-1) {
-			return false
-		}
-	case piece.Knight:
-		// 最奥の2段には打てない
-		if (b.CurrentPlayer == piece.Sente && move.ToY <= 1) ||
-			(b.CurrentPlayer == piece.Gote && move.ToY >= BoardSize-2) {
-			return false
-		}
+	// 二歩のチェック
+	if move.Piece == piece.Pawn && b.hasOwnPawnInColumn(move.ToX) {
+		return false
 	}
 
-	return true
+	// 歩、香車、桂馬は行き所のない段には打てない
+	return b.canDropToPosition(move)
 }
 
 // 同じ段に自分の歩があるかチェック
 func (b *Board) hasOwnPawnInColumn(x int) bool {
-	for y := 0; y < BoardSize; y++ {
+	for y := 0; y < b.Height(); y++ {
 		p := b.Grid[y][x]
 		if p.Type == piece.Pawn && p.Player == b.CurrentPlayer {
 			return true
@@ -174,14 +124,8 @@ func (b *Board) GetCaptures(player piece.Player) []piece.Type {
 		captureMap = b.GoteCaptures
 	}
 
-	// 駒の種類の順序を固定
-	pieceTypes := []piece.Type{
-		piece.Pawn, piece.Lance, piece.Knight,
-		piece.Silver, piece.Gold, piece.Bishop, piece.Rook,
-	}
-
 	// 固定順序で持ち駒を追加
-	for _, pt := range pieceTypes {
+	for _, pt := range b.variant().handTypes() {
 		count := captureMap[pt]
 		for i := 0; i < count; i++ {
 			captures = append(captures, pt)
@@ -191,28 +135,17 @@ func (b *Board) GetCaptures(player piece.Player) []piece.Type {
 	return captures
 }
 
-// 持ち駒を配置
-func (b *Board) DropPiece(x, y int, pieceType piece.Type) {
-	// 持ち駒を減らす
-	if b.CurrentPlayer == piece.Sente {
-		b.SenteCaptures[pieceType]--
-	} else {
-		b.GoteCaptures[pieceType]--
-	}
-
-	// 駒を配置
-	b.Grid[y][x] = piece.Piece{Type: pieceType, Player: b.CurrentPlayer}
-
-	// 手番を交代
-	b.CurrentPlayer = b.CurrentPlayer.Opposite()
+// 持ち駒を打つ（打てない場合は盤面を変えずに理由を返す）
+func (b *Board) DropPiece(x, y int, pieceType piece.Type) error {
+	return b.ApplyMove(Move{FromX: -1, FromY: -1, ToX: x, ToY: y, Piece: pieceType})
 }
 
 // 持ち駒の配置可能な位置を取得
 func (b *Board) GetValidDropPositions(pieceType piece.Type) [][2]int {
 	var positions [][2]int
 
-	for y := 0; y < BoardSize; y++ {
-		for x := 0; x < BoardSize; x++ {
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
 			move := Move{
 				FromX: -1,
 				FromY: -1,
@@ -262,18 +195,8 @@ func (b *Board) isValidPromotion(move Move, p piece.Piece) bool {
 		}
 
 		// 敵陣または敵陣から出る場合のみ成れる
-		canPromote := false
-		if b.CurrentPlayer == piece.Sente {
-			if move.FromY <= 2 || move.ToY <= 2 {
-				canPromote = true
-			}
-		} else {
-			if move.FromY >= 6 || move.ToY >= 6 {
-				canPromote = true
-			}
-		}
-
-		if !canPromote {
+		if !b.inPromotionZone(b.CurrentPlayer, move.FromY) &&
+			!b.inPromotionZone(b.CurrentPlayer, move.ToY) {
 			return false
 		}
 	}
@@ -288,51 +211,33 @@ func (b *Board) isValidPromotion(move Move, p piece.Piece) bool {
 
 // 駒打ちの位置が有効かチェック
 func (b *Board) canDropToPosition(move Move) bool {
-	// 歩、香車、桂馬の特殊ルール
-	if b.CurrentPlayer == piece.Sente {
-		switch move.Piece {
-		case piece.Pawn, piece.Lance:
-			if move.ToY == 0 {
-				return false
-			}
-		case piece.Knight:
-			if move.ToY <= 1 {
-				return false
-			}
-		}
-	} else {
-		switch move.Piece {
-		case piece.Pawn, piece.Lance:
-			if move.ToY == BoardSize-1 {
-				return false
-			}
-		case piece.Knight:
-			if move.ToY >= BoardSize-2 {
-				return false
-			}
-		}
-	}
-	return true
+	return !b.isDeadEnd(move.Piece, b.rankFromFar(b.CurrentPlayer, move.ToY))
 }
 
 // 必ず成らなければならない状況かチェック
 func (b *Board) mustPromote(move Move, p piece.Piece) bool {
-	if b.CurrentPlayer == piece.Sente {
-		switch p.Type {
-		case piece.Pawn, piece.Lance:
-			return move.ToY == 0
-		case piece.Knight:
-			return move.ToY <= 1
+	return b.isDeadEnd(p.Type, b.rankFromFar(b.CurrentPlayer, move.ToY))
+}
+
+// 奥から rank 段目では動けなくなる駒か（歩・香車は最奥の段、桂馬は奥の2段など）
+// 前に進む動きしかない駒だけが対象で、最も小さい前進の幅までの段が行き所のない段になる
+func (b *Board) isDeadEnd(t piece.Type, rank int) bool {
+	ranks := 0
+	for _, dir := range (piece.Piece{Type: t, Player: piece.Sente}).GetMovements() {
+		if dir.DY >= 0 {
+			return false
 		}
-	} else {
-		switch p.Type {
-		case piece.Pawn, piece.Lance:
-			return move.ToY == BoardSize-1
-		case piece.Knight:
-			return move.ToY >= BoardSize-2
+		if ranks == 0 || -dir.DY < ranks {
+			ranks = -dir.DY
 		}
 	}
-	return false
+	return rank <= ranks
+}
+
+// 盤上の駒が行き所のない段にあるか（局面の編集などで確認する）
+func (b *Board) IsDeadPiece(x, y int) bool {
+	p := b.Grid[y][x]
+	return p.Type != piece.Empty && b.isDeadEnd(p.Type, b.rankFromFar(p.Player, y))
 }
 
 // 各駒の移動可能範囲をチェック
@@ -342,24 +247,17 @@ func (b *Board) isValidPieceMove(move Move, p piece.Piece) bool {
 
 	// 駒の移動可能な方向を取得
 	for _, dir := range p.GetMovements() {
-		// 桂馬の場合は途中のマスを無視して、移動先が正しいかだけを確認
-		if p.Type == piece.Knight {
+		// 1マスだけ動く駒や桂馬のように跳ぶ駒は、移動先が正しいかだけを確認
+		if !dir.Repeat {
 			if dx == dir.DX && dy == dir.DY {
 				return true
 			}
 			continue
 		}
 
-		// その他の駒の場合
-		if dir.DX == sign(dx) && dir.DY == sign(dy) {
-			if !dir.Repeat {
-				// 1マスだけ動く駒の場合
-				return abs(dx) == abs(dir.DX) && abs(dy) == abs(dir.DY)
-			}
-			// 複数マス動ける駒の場合は経路チェック
-			if b.isPathClear(move) {
-				return true
-			}
+		// 複数マス動ける駒の場合は向きと経路をチェック
+		if dir.DX == sign(dx) && dir.DY == sign(dy) && b.isPathClear(move) {
+			return true
 		}
 	}
 	return false
@@ -373,7 +271,7 @@ func (b *Board) isPathClear(move Move) bool {
 
 	for x != move.ToX || y != move.ToY {
 		// 盤の範囲外のチェック
-		if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize {
+		if !b.InBounds(x, y) {
 			return false
 		}
 		if b.Grid[y][x].Type != piece.Empty {
@@ -401,7 +299,7 @@ func (b *Board) MakeMove(move Move) {
 		// 移動先に相手の駒があれば取る
 		if dest := b.Grid[move.ToY][move.ToX]; dest.Type != piece.Empty {
 			// 成り駒は元の駒に戻して持ち駒に加える
-			capturedType := getOriginalPiece(dest.Type)
+			capturedType := dest.Type.Unpromote()
 			if b.CurrentPlayer == piece.Sente {
 				b.SenteCaptures[capturedType]++
 			} else {
@@ -414,7 +312,7 @@ func (b *Board) MakeMove(move Move) {
 
 		// 移動先に駒を配置（必要に応じて成り）
 		if move.Promote {
-			p.Type = getPromotedPiece(p.Type)
+			p.Type = p.Type.Promote()
 		}
 		b.Grid[move.ToY][move.ToX] = p
 	}
@@ -423,48 +321,36 @@ func (b *Board) MakeMove(move Move) {
 	b.CurrentPlayer = getNextPlayer(b.CurrentPlayer)
 }
 
-// 王手判定
+// 王手判定（手番の側の玉に王手がかかっているか）
 func (b *Board) IsCheck() bool {
-	// 王の位置を探す
-	kingX, kingY := b.findKing(b.CurrentPlayer)
-	if kingX == -1 {
-		return false // 王がない（通常はありえない）
-	}
-
-	// 一時的に手番を入れ替えて、相手が王を取れるかチェック
-	opponent := getNextPlayer(b.CurrentPlayer)
-	tempPlayer := b.CurrentPlayer
-	b.CurrentPlayer = opponent
+	return b.InCheck(b.CurrentPlayer)
+}
 
-	// 盤上の全ての相手の駒について、王を取れるかチェック
-	for y := 0; y < BoardSize; y++ {
-		for x := 0; x < BoardSize; x++ {
+// 王の位置を探す
+func (b *Board) findKing(player piece.Player) (int, int) {
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
 			p := b.Grid[y][x]
-			if p.Type != piece.Empty && p.Player == opponent {
-				move := Move{FromX: x, FromY: y, ToX: kingX, ToY: kingY}
-				if b.isValidNormalMove(move) {
-					b.CurrentPlayer = tempPlayer
-					return true
-				}
+			if p.Type == piece.King && p.Player == player {
+				return x, y
 			}
 		}
 	}
-
-	b.CurrentPlayer = tempPlayer
-	return false
+	return -1, -1
 }
 
-// 王の位置を探す
-func (b *Board) findKing(player piece.Player) (int, int) {
-	for y := 0; y < BoardSize; y++ {
-		for x := 0; x < BoardSize; x++ {
+// 盤上の玉の数
+func (b *Board) countKings(player piece.Player) int {
+	n := 0
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
 			p := b.Grid[y][x]
 			if p.Type == piece.King && p.Player == player {
-				return x, y
+				n++
 			}
 		}
 	}
-	return -1, -1
+	return n
 }
 
 // ユーティリティ関数
@@ -491,41 +377,3 @@ func getNextPlayer(current piece.Player) piece.Player {
 	}
 	return piece.Sente
 }
-
-func getPromotedPiece(t piece.Type) piece.Type {
-	switch t {
-	case piece.Pawn:
-		return piece.PromPawn
-	case piece.Lance:
-		return piece.PromLance
-	case piece.Knight:
-		return piece.PromKnight
-	case piece.Silver:
-		return piece.PromSilver
-	case piece.Bishop:
-		return piece.PromBishop
-	case piece.Rook:
-		return piece.PromRook
-	default:
-		return t
-	}
-}
-
-func getOriginalPiece(t piece.Type) piece.Type {
-	switch t {
-	case piece.PromPawn:
-		return piece.Pawn
-	case piece.PromLance:
-		return piece.Lance
-	case piece.PromKnight:
-		return piece.Knight
-	case piece.PromSilver:
-		return piece.Silver
-	case piece.PromBishop:
-		return piece.Bishop
-	case piece.PromRook:
-		return piece.Rook
-	default:
-		return t
-	}
-}
@@ -0,0 +1,90 @@
+package board
+
+import (
+	"shogi/piece"
+)
+
+// 指し手が合法かチェック
+// IsValidMove に加えて、自玉を王手にさらす手と打ち歩詰めを除く（CheckMove が nil を返す手）
+func (b *Board) IsLegalMove(move Move) bool {
+	return b.CheckMove(move) == nil
+}
+
+// 合法手の一覧を取得
+func (b *Board) LegalMoves() []Move {
+	var moves []Move
+	for _, m := range b.candidateMoves() {
+		if b.CheckMove(m) == nil {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// 合法手が1つでもあるか（打ち歩詰めの判定では調べない）
+func (b *Board) hasLegalMove() bool {
+	for _, m := range b.candidateMoves() {
+		if b.checkMove(m, false) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// 詰みかどうか（王手がかかっていて合法手がない）
+func (b *Board) IsCheckmate() bool {
+	return b.IsCheck() && len(b.LegalMoves()) == 0
+}
+
+// 駒の動きから指し手の候補を列挙（王手放置などは未チェック）
+func (b *Board) candidateMoves() []Move {
+	var moves []Move
+
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			p := b.Grid[y][x]
+			if p.Type == piece.Empty || p.Player != b.CurrentPlayer {
+				continue
+			}
+			for _, dir := range p.GetMovements() {
+				tx, ty := x+dir.DX, y+dir.DY
+				for b.InBounds(tx, ty) {
+					dest := b.Grid[ty][tx]
+					if dest.Type != piece.Empty && dest.Player == b.CurrentPlayer {
+						break
+					}
+
+					move := Move{FromX: x, FromY: y, ToX: tx, ToY: ty}
+					if b.isValidPromotion(move, p) {
+						moves = append(moves, move)
+					}
+					move.Promote = true
+					if b.isValidPromotion(move, p) {
+						moves = append(moves, move)
+					}
+
+					if dest.Type != piece.Empty || !dir.Repeat {
+						break
+					}
+					tx, ty = tx+dir.DX, ty+dir.DY
+				}
+			}
+		}
+	}
+
+	// 持ち駒を打つ手
+	captures := b.SenteCaptures
+	if b.CurrentPlayer == piece.Gote {
+		captures = b.GoteCaptures
+	}
+	for _, pt := range b.variant().handTypes() {
+		if captures[pt] <= 0 {
+			continue
+		}
+		for _, pos := range b.GetValidDropPositions(pt) {
+			moves = append(moves, Move{FromX: -1, FromY: -1, ToX: pos[0], ToY: pos[1], Piece: pt})
+		}
+	}
+
+	return moves
+}
@@ -0,0 +1,109 @@
+package board
+
+import (
+	"shogi/piece"
+)
+
+// 持ち駒の種類の数の上限（組み込みの持ち駒と、定義ファイルの独自の駒 A〜Z の26種類）
+const maxHandTypes = 9 + 26
+
+// 持ち駒の枚数（将棋の種類の持ち駒の順序で並べる）
+type Hand [maxHandTypes]int8
+
+// 変更できない局面の値
+// マップを持たないので、そのままコピーしてゴルーチンに渡したり、
+// == で比べたり、マップのキーにしたりできる
+type Position struct {
+	Grid    [BoardSize][BoardSize]piece.Piece
+	Hands   [2]Hand // 先手・後手の持ち駒
+	Turn    piece.Player
+	Variant *Variant // 将棋の種類（nilなら本将棋）
+}
+
+// 盤面の複製（持ち駒のマップも別に作る）
+func (b *Board) Clone() *Board {
+	c := &Board{
+		Grid:          b.Grid,
+		SenteCaptures: make(map[piece.Type]int, len(b.SenteCaptures)),
+		GoteCaptures:  make(map[piece.Type]int, len(b.GoteCaptures)),
+		CurrentPlayer: b.CurrentPlayer,
+		Variant:       b.Variant,
+	}
+	for t, n := range b.SenteCaptures {
+		c.SenteCaptures[t] = n
+	}
+	for t, n := range b.GoteCaptures {
+		c.GoteCaptures[t] = n
+	}
+	return c
+}
+
+// 盤面の今の局面
+func (b *Board) Position() Position {
+	p := Position{Grid: b.Grid, Turn: b.CurrentPlayer}
+	if b.Variant != Standard {
+		p.Variant = b.Variant
+	}
+	v := b.variant()
+	for i, captures := range []map[piece.Type]int{b.SenteCaptures, b.GoteCaptures} {
+		for t, n := range captures {
+			if j := v.handIndex(t); j >= 0 && n != 0 {
+				p.Hands[i][j] = int8(n)
+			}
+		}
+	}
+	return p
+}
+
+// 局面から盤面を作る（返した盤面を変えても局面は変わらない）
+func (p Position) Board() *Board {
+	b := NewEmpty()
+	b.Grid = p.Grid
+	b.CurrentPlayer = p.Turn
+	b.Variant = p.Variant
+	for i, t := range b.variant().handTypes() {
+		if n := p.Hands[0][i]; n != 0 {
+			b.SenteCaptures[t] = int(n)
+		}
+		if n := p.Hands[1][i]; n != 0 {
+			b.GoteCaptures[t] = int(n)
+		}
+	}
+	return b
+}
+
+// 指定位置の駒を取得
+func (p Position) GetPiece(x, y int) piece.Piece {
+	return p.Grid[y][x]
+}
+
+// 持ち駒の枚数
+func (p Position) HandCount(player piece.Player, t piece.Type) int {
+	v := p.Variant
+	if v == nil {
+		v = Standard
+	}
+	i := v.handIndex(t)
+	if i < 0 {
+		return 0
+	}
+	if player == piece.Gote {
+		return int(p.Hands[1][i])
+	}
+	return int(p.Hands[0][i])
+}
+
+// 持ち駒の種類の並び（handTypes）での位置（持ち駒にならない駒なら -1）
+func (v *Variant) handIndex(t piece.Type) int {
+	for i, ht := range handPieceTypes {
+		if ht == t {
+			return i
+		}
+	}
+	for i, ht := range v.Pieces {
+		if ht == t {
+			return len(handPieceTypes) + i
+		}
+	}
+	return -1
+}
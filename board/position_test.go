@@ -0,0 +1,116 @@
+package board
+
+import (
+	"strings"
+	"testing"
+
+	"shogi/piece"
+)
+
+func TestClone(t *testing.T) {
+	b := New()
+	c := b.Clone()
+	c.MakeMove(Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5})
+	c.SenteCaptures[piece.Gold] = 1
+	if b.SFEN(1) != StartSFEN || b.SenteCaptures[piece.Gold] != 0 {
+		t.Errorf("複製を変えると元の盤面も変わります: %s", b.SFEN(1))
+	}
+}
+
+// 手順が違っても同じ局面なら == で等しく、マップのキーとして使える
+func TestPositionEqual(t *testing.T) {
+	play := func(moves ...Move) Position {
+		b := New()
+		for _, m := range moves {
+			if err := b.ApplyMove(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return b.Position()
+	}
+	p76 := Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5} // ▲７六歩
+	p26 := Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5} // ▲２六歩
+	p34 := Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3} // △３四歩
+	p84 := Move{FromX: 1, FromY: 2, ToX: 1, ToY: 3} // △８四歩
+
+	a := play(p76, p34, p26, p84)
+	b := play(p26, p84, p76, p34)
+	if a != b {
+		t.Error("同じ局面が等しくなりません")
+	}
+	if a == play(p76, p34) {
+		t.Error("違う局面が等しくなります")
+	}
+
+	seen := map[Position]int{a: 1}
+	if seen[b] != 1 {
+		t.Error("同じ局面をマップのキーとして引けません")
+	}
+}
+
+func TestPositionBoard(t *testing.T) {
+	sfens := []string{
+		StartSFEN,
+		"lnsgk2nl/1r4gs1/p1pppp1pp/6p2/1p7/2P6/PPSPPPPPP/7R1/LN1GKGSNL w Bb 12",
+		"4k4/9/9/9/9/9/9/9/4K4 b 2R2B4G4S4N4L9Pp 1",
+	}
+	for _, sfen := range sfens {
+		b, _, err := ParseSFEN(sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := b.Position()
+		if got := p.Board().SFEN(1); got != b.SFEN(1) {
+			t.Errorf("Board() = %s, want %s", got, b.SFEN(1))
+		}
+		if p.Board().Position() != p {
+			t.Errorf("%s: 局面と盤面を往復すると局面が変わります", sfen)
+		}
+	}
+
+	// 局面から作った盤面を変えても局面は変わらない
+	b := New()
+	p := b.Position()
+	c := p.Board()
+	c.MakeMove(Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5})
+	if p != b.Position() {
+		t.Error("盤面を変えると局面も変わります")
+	}
+}
+
+func TestPositionHandCount(t *testing.T) {
+	b, _, err := ParseSFEN("4k4/9/9/9/9/9/9/9/4K4 b 2Gp 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := b.Position()
+	if n := p.HandCount(piece.Sente, piece.Gold); n != 2 {
+		t.Errorf("先手の金 = %d, want 2", n)
+	}
+	if n := p.HandCount(piece.Gote, piece.Pawn); n != 1 {
+		t.Errorf("後手の歩 = %d, want 1", n)
+	}
+	if n := p.HandCount(piece.Sente, piece.King); n != 0 {
+		t.Errorf("先手の玉 = %d, want 0", n)
+	}
+}
+
+// 独自の駒も持ち駒として局面に入る
+func TestPositionCustomPiece(t *testing.T) {
+	v, err := LoadVariant(strings.NewReader(`{
+		"name": "custom", "width": 5, "height": 5, "promotionZone": 1,
+		"start": "k4/5/5/5/4K b Xx 1",
+		"pieces": [{"letter": "X", "name": "X", "moves": [{"dx": 0, "dy": -1}]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := v.New()
+	p := b.Position()
+	if n := p.HandCount(piece.Gote, v.Pieces[0]); n != 1 {
+		t.Errorf("後手の独自の駒 = %d, want 1", n)
+	}
+	if got := p.Board().SFEN(1); got != v.StartSFEN {
+		t.Errorf("Board() = %s, want %s", got, v.StartSFEN)
+	}
+}
@@ -0,0 +1,59 @@
+package board
+
+import (
+	"shogi/piece"
+)
+
+// 千日手の判定結果
+type Repetition int
+
+const (
+	RepetitionNone           Repetition = iota // 千日手ではない
+	RepetitionDraw                             // 千日手（引き分け）
+	RepetitionPerpetualCheck                   // 連続王手の千日手（王手をかけ続けた側の負け）
+)
+
+// 千日手を判定するための局面の履歴
+type History struct {
+	positions map[Position][]int // 局面ごとの出現した手数
+	movers    []piece.Player     // 各手を指した側
+	checks    []bool             // 各手が王手だったか
+}
+
+// 開始局面から履歴を作成
+func NewHistory(start *Board) *History {
+	return &History{
+		positions: map[Position][]int{start.Position(): {0}},
+	}
+}
+
+// 指した後の局面を記録
+func (h *History) Push(b *Board) {
+	h.movers = append(h.movers, b.CurrentPlayer.Opposite())
+	h.checks = append(h.checks, b.IsCheck())
+	key := b.Position()
+	h.positions[key] = append(h.positions[key], len(h.movers))
+}
+
+// 最後に記録した局面で千日手が成立したか（同一局面4回）
+// 連続王手の千日手なら王手をかけ続けた側も返す
+func (h *History) Repetition(b *Board) (Repetition, piece.Player) {
+	seen := h.positions[b.Position()]
+	if len(seen) < 4 {
+		return RepetitionNone, piece.None
+	}
+
+	// 最初の出現から今までの間、ずっと王手をかけ続けた側がいるか
+	allChecks := map[piece.Player]bool{piece.Sente: true, piece.Gote: true}
+	for i := seen[0]; i < len(h.movers); i++ {
+		if !h.checks[i] {
+			allChecks[h.movers[i]] = false
+		}
+	}
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		if allChecks[player] {
+			return RepetitionPerpetualCheck, player
+		}
+	}
+	return RepetitionDraw, piece.None
+}
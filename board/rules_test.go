@@ -0,0 +1,260 @@
+package board_test
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+// 合法手の判定を確かめる局面（testdata/positions.json）
+type position struct {
+	Name    string    `json:"name"`
+	SFEN    string    `json:"sfen"`
+	Moves   *[]string `json:"moves"`   // 合法手のすべて（USI）
+	Count   *int      `json:"count"`   // 合法手の数
+	Include []string  `json:"include"` // 合法手に含まれる手
+	Exclude []string  `json:"exclude"` // 合法手に含まれない手
+	Check   bool      `json:"check"`
+	Mate    bool      `json:"mate"`
+}
+
+func loadPositions(t *testing.T) []position {
+	t.Helper()
+	data, err := os.ReadFile("testdata/positions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var positions []position
+	if err := json.Unmarshal(data, &positions); err != nil {
+		t.Fatal(err)
+	}
+	return positions
+}
+
+func TestGoldenPositions(t *testing.T) {
+	for _, tt := range loadPositions(t) {
+		t.Run(tt.Name, func(t *testing.T) {
+			b, _, err := board.ParseSFEN(tt.SFEN)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			legal := map[string]bool{}
+			got := []string{}
+			for _, m := range b.LegalMoves() {
+				s := usi.FormatMove(m)
+				if legal[s] {
+					t.Errorf("合法手 %s が重複しています", s)
+				}
+				legal[s] = true
+				got = append(got, s)
+			}
+			sort.Strings(got)
+
+			if tt.Moves != nil {
+				want := append([]string{}, *tt.Moves...)
+				sort.Strings(want)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("LegalMoves() = %v, want %v", got, want)
+				}
+			}
+			if tt.Count != nil && len(got) != *tt.Count {
+				t.Errorf("合法手の数 = %d, want %d: %v", len(got), *tt.Count, got)
+			}
+			for _, s := range tt.Include {
+				if !legal[s] || !b.IsLegalMove(mustParseMove(t, s)) {
+					t.Errorf("%s が合法手になっていません", s)
+				}
+			}
+			for _, s := range tt.Exclude {
+				if legal[s] || b.IsLegalMove(mustParseMove(t, s)) {
+					t.Errorf("%s が合法手になっています", s)
+				}
+			}
+
+			if got := b.IsCheck(); got != tt.Check {
+				t.Errorf("IsCheck() = %v, want %v", got, tt.Check)
+			}
+			if got := b.IsCheckmate(); got != tt.Mate {
+				t.Errorf("IsCheckmate() = %v, want %v", got, tt.Mate)
+			}
+		})
+	}
+}
+
+func mustParseMove(t *testing.T, s string) board.Move {
+	t.Helper()
+	m, err := usi.ParseMove(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// 入力のバイト列で合法手を選んで対局を進め、1手ごとに局面の不変条件を確かめる
+func FuzzRandomGame(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	f.Add([]byte("shogi random game with captures and drops"))
+	seed := make([]byte, 200)
+	for i := range seed {
+		seed[i] = byte(i * 37)
+	}
+	f.Add(seed)
+
+	// 1回の実行が重くならないよう、手数を区切り、重い検査は一部の局面だけで行う
+	const maxPlies = 60
+	const sampleEvery = 8
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > maxPlies {
+			data = data[:maxPlies]
+		}
+		b := board.New()
+		checkInvariants(t, b)
+		for i, c := range data {
+			moves := b.LegalMoves()
+			if len(moves) == 0 {
+				if !b.IsCheckmate() {
+					t.Fatalf("%d手目: 王手でないのに合法手がありません: %s", i+1, b.SFEN(1))
+				}
+				checkInvariants(t, b)
+				return
+			}
+			// どの局面を調べるかも入力で変わるようにする
+			sampled := (i+int(c))%sampleEvery == 0
+			if sampled {
+				checkMoveErrors(t, b, rand.New(rand.NewSource(int64(i)<<8|int64(c))))
+			}
+			m := moves[int(c)%len(moves)]
+			mover := b.CurrentPlayer
+			b.MakeMove(m)
+			if b.CurrentPlayer != mover.Opposite() {
+				t.Fatalf("%d手目 %s: 手番が交代していません", i+1, usi.FormatMove(m))
+			}
+			if sampled || i == len(data)-1 {
+				checkInvariants(t, b)
+			}
+		}
+	})
+}
+
+// でたらめな指し手で CheckMove と IsLegalMove の判定が一致し、
+// ApplyMove が指せない手で盤面を変えないことを確かめる
+func checkMoveErrors(t *testing.T, b *board.Board, r *rand.Rand) {
+	t.Helper()
+	hand := []piece.Type{piece.Pawn, piece.Lance, piece.Knight, piece.Silver, piece.Gold, piece.Bishop, piece.Rook}
+	for i := 0; i < 20; i++ {
+		m := board.Move{
+			FromX: r.Intn(board.BoardSize), FromY: r.Intn(board.BoardSize),
+			ToX: r.Intn(board.BoardSize), ToY: r.Intn(board.BoardSize),
+			Promote: r.Intn(2) == 0,
+		}
+		if r.Intn(4) == 0 {
+			m.FromX, m.FromY, m.Piece, m.Promote = -1, -1, hand[r.Intn(len(hand))], false
+		}
+		err := b.CheckMove(m)
+		if (err == nil) != b.IsLegalMove(m) {
+			t.Fatalf("%s %+v: CheckMove() = %v, IsLegalMove() = %v", b.SFEN(1), m, err, b.IsLegalMove(m))
+		}
+		if err != nil {
+			before := b.SFEN(1)
+			if b.ApplyMove(m) == nil || b.SFEN(1) != before {
+				t.Fatalf("%s %+v: 指せない手で盤面が変わりました", before, m)
+			}
+		}
+	}
+	for _, m := range b.LegalMoves() {
+		if err := b.CheckMove(m); err != nil {
+			t.Fatalf("%s %+v: 合法手で CheckMove() = %v", b.SFEN(1), m, err)
+		}
+	}
+}
+
+// 本将棋の局面の不変条件
+//   - 盤上と持ち駒を合わせて駒が40枚（種類ごとの枚数も初期局面と同じ）
+//   - 玉はそれぞれ1枚
+//   - 二歩・行き所のない駒がない
+//   - 手番でない側の玉に王手がかかっていない
+//   - SFENに書き出して読み直しても同じ局面になる
+//   - Validate で問題が見つからない
+func checkInvariants(t *testing.T, b *board.Board) {
+	t.Helper()
+	sfen := b.SFEN(1)
+
+	counts := map[piece.Type]int{}
+	kings := map[piece.Player]int{}
+	for x := 0; x < b.Width(); x++ {
+		pawns := map[piece.Player]int{}
+		for y := 0; y < b.Height(); y++ {
+			p := b.GetPiece(x, y)
+			if p.Type == piece.Empty {
+				continue
+			}
+			counts[p.Type.Unpromote()]++
+			switch p.Type {
+			case piece.King:
+				kings[p.Player]++
+			case piece.Pawn:
+				pawns[p.Player]++
+				if pawns[p.Player] > 1 {
+					t.Fatalf("二歩です: %s", sfen)
+				}
+			}
+			if b.IsDeadPiece(x, y) {
+				t.Fatalf("行き所のない駒があります: %s", sfen)
+			}
+		}
+	}
+	for _, hand := range []map[piece.Type]int{b.SenteCaptures, b.GoteCaptures} {
+		for pt, n := range hand {
+			if n < 0 {
+				t.Fatalf("持ち駒の数が負です: %s", sfen)
+			}
+			counts[pt] += n
+		}
+	}
+
+	want := map[piece.Type]int{
+		piece.Pawn: 18, piece.Lance: 4, piece.Knight: 4, piece.Silver: 4,
+		piece.Gold: 4, piece.Bishop: 2, piece.Rook: 2, piece.King: 2,
+	}
+	total := 0
+	for pt, n := range counts {
+		if n != want[pt] {
+			t.Fatalf("駒 %v の枚数 = %d, want %d: %s", pt, n, want[pt], sfen)
+		}
+		total += n
+	}
+	if total != 40 {
+		t.Fatalf("駒の数 = %d, want 40: %s", total, sfen)
+	}
+	if kings[piece.Sente] != 1 || kings[piece.Gote] != 1 {
+		t.Fatalf("玉の数 = %v: %s", kings, sfen)
+	}
+
+	// 手番でない側に王手がかかっていれば、直前の手が王手放置だった
+	opp := *b
+	opp.CurrentPlayer = b.CurrentPlayer.Opposite()
+	if opp.IsCheck() {
+		t.Fatalf("手番でない側に王手がかかっています: %s", sfen)
+	}
+
+	if problems := b.Validate(); len(problems) > 0 {
+		t.Fatalf("Validate() = %v: %s", problems, sfen)
+	}
+
+	again, _, err := board.ParseSFEN(sfen)
+	if err != nil {
+		t.Fatalf("SFENを読み直せません: %v: %s", err, sfen)
+	}
+	if got := again.SFEN(1); got != sfen {
+		t.Fatalf("SFENの読み直しで局面が変わりました: %s -> %s", sfen, got)
+	}
+}
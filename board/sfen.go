@@ -0,0 +1,256 @@
+package board
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"shogi/piece"
+)
+
+// 平手の初期局面
+const StartSFEN = "lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1"
+
+var ErrInvalidSFEN = errors.New("board: 不正なSFENです")
+
+// SFENの駒文字（先手は大文字、後手は小文字）
+var sfenLetters = map[piece.Type]byte{
+	piece.Pawn:   'P',
+	piece.Lance:  'L',
+	piece.Knight: 'N',
+	piece.Silver: 'S',
+	piece.Gold:   'G',
+	piece.Bishop: 'B',
+	piece.Rook:   'R',
+	piece.King:   'K',
+
+	// どうぶつしょうぎ風の駒
+	piece.Giraffe:  'J',
+	piece.Elephant: 'E',
+}
+
+// SFENでの持ち駒の順序
+var sfenHandOrder = []piece.Type{
+	piece.Rook, piece.Bishop, piece.Gold, piece.Silver,
+	piece.Knight, piece.Lance, piece.Pawn,
+	piece.Giraffe, piece.Elephant,
+}
+
+// 局面をSFEN形式で表す（moveNumber は手数欄に入れる値）
+func (b *Board) SFEN(moveNumber int) string {
+	var sb strings.Builder
+
+	// 盤面（一段目から、左端の筋から1筋の順）
+	for y := 0; y < b.Height(); y++ {
+		if y > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for x := 0; x < b.Width(); x++ {
+			p := b.Grid[y][x]
+			if p.Type == piece.Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			if p.Type.IsPromoted() {
+				sb.WriteByte('+')
+			}
+			c := b.variant().letter(p.Type.Unpromote())
+			if p.Player == piece.Gote {
+				c += 'a' - 'A'
+			}
+			sb.WriteByte(c)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+	}
+
+	// 手番
+	if b.CurrentPlayer == piece.Gote {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	// 持ち駒
+	hand := ""
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		captures := b.SenteCaptures
+		if player == piece.Gote {
+			captures = b.GoteCaptures
+		}
+		for _, pt := range b.variant().sfenHandTypes() {
+			n := captures[pt]
+			if n <= 0 {
+				continue
+			}
+			if n > 1 {
+				hand += strconv.Itoa(n)
+			}
+			c := b.variant().letter(pt)
+			if player == piece.Gote {
+				c += 'a' - 'A'
+			}
+			hand += string(c)
+		}
+	}
+	if hand == "" {
+		hand = "-"
+	}
+	sb.WriteString(hand)
+
+	sb.WriteString(" " + strconv.Itoa(moveNumber))
+	return sb.String()
+}
+
+// 同一局面の判定に使うキー（SFENから手数を除いたもの）
+func (b *Board) PositionKey() string {
+	s := b.SFEN(1)
+	return s[:strings.LastIndexByte(s, ' ')]
+}
+
+// SFEN形式の局面を読み込む。手数欄がなければ1を返す
+// 将棋の種類は盤の大きさから判断する
+func ParseSFEN(s string) (*Board, int, error) {
+	return parseSFEN(s, nil)
+}
+
+// SFEN形式の局面を将棋の種類 v の盤として読み込む（nilなら盤の大きさから判断する）
+func parseSFEN(s string, v *Variant) (*Board, int, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, 0, ErrInvalidSFEN
+	}
+	b := NewEmpty()
+
+	// 盤面
+	ranks := strings.Split(fields[0], "/")
+	if v == nil {
+		v = variantBySize(sfenRankWidth(ranks[0]), len(ranks))
+		if v == nil {
+			return nil, 0, ErrInvalidSFEN
+		}
+	}
+	if len(ranks) != v.Height {
+		return nil, 0, ErrInvalidSFEN
+	}
+	if v != Standard {
+		b.Variant = v
+	}
+	for y, rank := range ranks {
+		x := 0
+		promoted := false
+		for i := 0; i < len(rank); i++ {
+			c := rank[i]
+			switch {
+			case c >= '1' && c <= '9':
+				if promoted {
+					return nil, 0, ErrInvalidSFEN
+				}
+				x += int(c - '0')
+			case c == '+':
+				promoted = true
+			default:
+				pt, player, ok := v.parseLetter(c)
+				if !ok || x >= v.Width {
+					return nil, 0, ErrInvalidSFEN
+				}
+				if promoted {
+					if !v.canPromote(pt) {
+						return nil, 0, ErrInvalidSFEN
+					}
+					pt = pt.Promote()
+					promoted = false
+				}
+				b.Grid[y][x] = piece.Piece{Type: pt, Player: player}
+				x++
+			}
+		}
+		if x != v.Width || promoted {
+			return nil, 0, ErrInvalidSFEN
+		}
+	}
+
+	// 手番
+	switch fields[1] {
+	case "b":
+		b.CurrentPlayer = piece.Sente
+	case "w":
+		b.CurrentPlayer = piece.Gote
+	default:
+		return nil, 0, ErrInvalidSFEN
+	}
+
+	// 持ち駒
+	if fields[2] != "-" {
+		n := 0
+		for i := 0; i < len(fields[2]); i++ {
+			c := fields[2][i]
+			if c >= '0' && c <= '9' {
+				n = n*10 + int(c-'0')
+				continue
+			}
+			pt, player, ok := v.parseLetter(c)
+			if !ok || pt == piece.King {
+				return nil, 0, ErrInvalidSFEN
+			}
+			if n == 0 {
+				n = 1
+			}
+			if player == piece.Sente {
+				b.SenteCaptures[pt] += n
+			} else {
+				b.GoteCaptures[pt] += n
+			}
+			n = 0
+		}
+		if n != 0 {
+			return nil, 0, ErrInvalidSFEN
+		}
+	}
+
+	// 手数
+	moveNumber := 1
+	if len(fields) == 4 {
+		n, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, 0, ErrInvalidSFEN
+		}
+		moveNumber = n
+	}
+	return b, moveNumber, nil
+}
+
+// SFENの段の表記が表すマスの数
+func sfenRankWidth(rank string) int {
+	n := 0
+	for i := 0; i < len(rank); i++ {
+		switch c := rank[i]; {
+		case c >= '1' && c <= '9':
+			n += int(c - '0')
+		case c != '+':
+			n++
+		}
+	}
+	return n
+}
+
+// SFENの駒文字を駒の種類とプレイヤーに変換
+func parseSFENLetter(c byte) (piece.Type, piece.Player, bool) {
+	player := piece.Sente
+	if c >= 'a' && c <= 'z' {
+		player = piece.Gote
+		c -= 'a' - 'A'
+	}
+	for pt, l := range sfenLetters {
+		if l == c {
+			return pt, player, true
+		}
+	}
+	return piece.Empty, piece.None, false
+}
@@ -0,0 +1,238 @@
+package board
+
+import (
+	"errors"
+
+	"shogi/piece"
+)
+
+var ErrInvalidSquare = errors.New("board: 不正なマスです")
+
+// 盤上のマス（Grid の y*BoardSize+x）
+// File・USI・Japanese・SquareAt・ParseSquare の筋・段は本将棋の盤（9×9）の表記で、x=0 が９筋、y=0 が一段目。
+// 幅の違う盤（どうぶつしょうぎなど）では筋の番号が変わるので、Board の File・SquareUSI・ParseSquareUSI を使う
+type Square int8
+
+// 盤上のマスでないこと（駒打ちの移動元）を表す
+const NoSquare Square = -1
+
+// 全角の筋の数字（添字は筋の番号）
+var fileZenkaku = []string{"", "１", "２", "３", "４", "５", "６", "７", "８", "９"}
+
+// 段の漢数字
+var rankKanji = []string{"一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// 座標からマスを作る（盤の外なら NoSquare）
+func NewSquare(x, y int) Square {
+	if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize {
+		return NoSquare
+	}
+	return Square(y*BoardSize + x)
+}
+
+// 本将棋の盤の筋・段の番号（1始まり）からマスを作る（範囲外なら NoSquare）
+func SquareAt(file, rank int) Square {
+	return squareAt(BoardSize, BoardSize, file, rank)
+}
+
+// 幅 width・高さ height の盤の筋・段の番号からマスを作る（範囲外なら NoSquare）
+func squareAt(width, height, file, rank int) Square {
+	if file < 1 || file > width || rank < 1 || rank > height {
+		return NoSquare
+	}
+	return NewSquare(width-file, rank-1)
+}
+
+// Grid の列
+func (s Square) X() int {
+	return int(s) % BoardSize
+}
+
+// Grid の行
+func (s Square) Y() int {
+	return int(s) / BoardSize
+}
+
+// 本将棋の盤の筋の番号（1〜9）
+func (s Square) File() int {
+	return BoardSize - s.X()
+}
+
+// 段の番号（1〜9）
+func (s Square) Rank() int {
+	return s.Y() + 1
+}
+
+// 盤上のマスか
+func (s Square) IsValid() bool {
+	return s >= 0 && int(s) < BoardSize*BoardSize
+}
+
+// 本将棋の盤でのUSIのマス表記（例: 7g）
+func (s Square) USI() string {
+	return s.usi(BoardSize)
+}
+
+// 幅 width の盤でのUSIのマス表記
+func (s Square) usi(width int) string {
+	if !s.IsValid() {
+		return "-"
+	}
+	return string([]byte{byte('0' + width - s.X()), byte('a' + s.Y())})
+}
+
+// 本将棋の盤での日本式のマス表記（例: ７七）
+func (s Square) Japanese() string {
+	if !s.IsValid() {
+		return "－"
+	}
+	return fileZenkaku[s.File()] + rankKanji[s.Y()]
+}
+
+// USIのマス表記
+func (s Square) String() string {
+	return s.USI()
+}
+
+// 本将棋の盤でのUSIのマス表記（例: 7g）を読み込む
+func ParseSquare(s string) (Square, error) {
+	return parseSquare(BoardSize, BoardSize, s)
+}
+
+// 幅 width・高さ height の盤でのUSIのマス表記を読み込む
+func parseSquare(width, height int, s string) (Square, error) {
+	if len(s) != 2 || s[0] < '1' || s[0] > '9' || s[1] < 'a' || s[1] > 'i' {
+		return NoSquare, ErrInvalidSquare
+	}
+	sq := squareAt(width, height, int(s[0]-'0'), int(s[1]-'a')+1)
+	if sq == NoSquare {
+		return NoSquare, ErrInvalidSquare
+	}
+	return sq, nil
+}
+
+// 盤の幅に合わせた筋の番号（1始まり）
+func (b *Board) File(s Square) int {
+	return b.Width() - s.X()
+}
+
+// 盤の幅に合わせたUSIのマス表記（例: どうぶつしょうぎの盤の左上は 3a）
+func (b *Board) SquareUSI(s Square) string {
+	if !b.InBounds(s.X(), s.Y()) {
+		return "-"
+	}
+	return s.usi(b.Width())
+}
+
+// 盤の幅に合わせたUSIのマス表記を読み込む（盤の外のマスはエラー）
+func (b *Board) ParseSquareUSI(s string) (Square, error) {
+	return parseSquare(b.Width(), b.Height(), s)
+}
+
+// 日本式のマス表記（例: ７七、7七）を読み込む
+func ParseJapaneseSquare(s string) (Square, error) {
+	r := []rune(s)
+	if len(r) != 2 {
+		return NoSquare, ErrInvalidSquare
+	}
+	file := 0
+	switch {
+	case r[0] >= '1' && r[0] <= '9':
+		file = int(r[0] - '0')
+	case r[0] >= '１' && r[0] <= '９':
+		file = int(r[0] - '１' + 1)
+	}
+	for i, k := range rankKanji {
+		if string(r[1]) == k && file > 0 {
+			return SquareAt(file, i+1), nil
+		}
+	}
+	return NoSquare, ErrInvalidSquare
+}
+
+// 移動元のマス（駒打ちなら NoSquare）
+func (m Move) From() Square {
+	if m.IsDrop() {
+		return NoSquare
+	}
+	return NewSquare(m.FromX, m.FromY)
+}
+
+// 移動先のマス
+func (m Move) To() Square {
+	return NewSquare(m.ToX, m.ToY)
+}
+
+// 駒打ちか
+func (m Move) IsDrop() bool {
+	return m.FromX == -1 && m.FromY == -1
+}
+
+// 32ビットに詰めた指し手（マップのキーやエンジンとのやり取りに使う）
+//
+//	0〜6ビット:   移動先のマス
+//	7〜13ビット:  移動元のマス（駒打ちなら127）
+//	14〜21ビット: 打つ駒の種類
+//	22ビット:     成るか
+//	23〜30ビット: 取った駒の種類（取らなければ Empty）
+type MoveCode uint32
+
+const (
+	moveCodeDrop    = 0x7f
+	moveCodePromote = 1 << 22
+)
+
+// 盤面で指す手を32ビットに詰める（取る駒は盤面から求める）
+func (b *Board) EncodeMove(m Move) MoveCode {
+	c := MoveCode(m.To()) & 0x7f
+	if m.IsDrop() {
+		c |= moveCodeDrop<<7 | MoveCode(m.Piece&0xff)<<14
+	} else {
+		c |= MoveCode(m.From()&0x7f) << 7
+		if b.InBounds(m.ToX, m.ToY) {
+			c |= MoveCode(b.Grid[m.ToY][m.ToX].Type&0xff) << 23
+		}
+	}
+	if m.Promote {
+		c |= moveCodePromote
+	}
+	return c
+}
+
+// 移動元のマス（駒打ちなら NoSquare）
+func (c MoveCode) From() Square {
+	if c>>7&0x7f == moveCodeDrop {
+		return NoSquare
+	}
+	return Square(c >> 7 & 0x7f)
+}
+
+// 移動先のマス
+func (c MoveCode) To() Square {
+	return Square(c & 0x7f)
+}
+
+// 打つ駒の種類（駒打ちでなければ Empty）
+func (c MoveCode) Drop() piece.Type {
+	return piece.Type(c >> 14 & 0xff)
+}
+
+// 成るか
+func (c MoveCode) Promote() bool {
+	return c&moveCodePromote != 0
+}
+
+// 取った駒の種類（取らなければ Empty）
+func (c MoveCode) Captured() piece.Type {
+	return piece.Type(c >> 23 & 0xff)
+}
+
+// 詰めた指し手を元に戻す
+func (c MoveCode) Move() Move {
+	to := c.To()
+	m := Move{FromX: -1, FromY: -1, ToX: to.X(), ToY: to.Y(), Piece: c.Drop(), Promote: c.Promote()}
+	if from := c.From(); from != NoSquare {
+		m.FromX, m.FromY = from.X(), from.Y()
+	}
+	return m
+}
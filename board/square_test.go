@@ -0,0 +1,138 @@
+package board
+
+import (
+	"errors"
+	"testing"
+
+	"shogi/piece"
+)
+
+func TestSquare(t *testing.T) {
+	tests := []struct {
+		x, y     int
+		file     int
+		rank     int
+		usi      string
+		japanese string
+	}{
+		{2, 6, 7, 7, "7g", "７七"},
+		{0, 0, 9, 1, "9a", "９一"},
+		{8, 8, 1, 9, "1i", "１九"},
+		{4, 4, 5, 5, "5e", "５五"},
+	}
+	for _, tt := range tests {
+		s := NewSquare(tt.x, tt.y)
+		if s.X() != tt.x || s.Y() != tt.y || s.File() != tt.file || s.Rank() != tt.rank {
+			t.Errorf("NewSquare(%d, %d) = (%d, %d) %d筋%d段", tt.x, tt.y, s.X(), s.Y(), s.File(), s.Rank())
+		}
+		if s != SquareAt(tt.file, tt.rank) {
+			t.Errorf("SquareAt(%d, %d) = %v, want %v", tt.file, tt.rank, SquareAt(tt.file, tt.rank), s)
+		}
+		if s.USI() != tt.usi || s.Japanese() != tt.japanese {
+			t.Errorf("(%d, %d): USI() = %s, Japanese() = %s", tt.x, tt.y, s.USI(), s.Japanese())
+		}
+		if got, err := ParseSquare(tt.usi); err != nil || got != s {
+			t.Errorf("ParseSquare(%s) = %v, %v", tt.usi, got, err)
+		}
+		if got, err := ParseJapaneseSquare(tt.japanese); err != nil || got != s {
+			t.Errorf("ParseJapaneseSquare(%s) = %v, %v", tt.japanese, got, err)
+		}
+	}
+
+	if got, err := ParseJapaneseSquare("7七"); err != nil || got != SquareAt(7, 7) {
+		t.Errorf("ParseJapaneseSquare(7七) = %v, %v", got, err)
+	}
+	for _, s := range []string{"", "0a", "7j", "77", "g7", "7g+"} {
+		if _, err := ParseSquare(s); !errors.Is(err, ErrInvalidSquare) {
+			t.Errorf("ParseSquare(%q) = %v, want ErrInvalidSquare", s, err)
+		}
+	}
+	for _, s := range []string{"", "７", "７十", "〇七", "７七歩"} {
+		if _, err := ParseJapaneseSquare(s); !errors.Is(err, ErrInvalidSquare) {
+			t.Errorf("ParseJapaneseSquare(%q) = %v, want ErrInvalidSquare", s, err)
+		}
+	}
+	if NewSquare(9, 0) != NoSquare || NewSquare(0, -1) != NoSquare || SquareAt(0, 1) != NoSquare {
+		t.Error("盤の外のマスが NoSquare になりません")
+	}
+}
+
+// 幅の違う盤では筋の番号が盤の幅に合わせて変わる
+func TestBoardSquareUSI(t *testing.T) {
+	tests := []struct {
+		variant *Variant
+		x, y    int
+		file    int
+		usi     string
+	}{
+		{Standard, 2, 6, 7, "7g"},
+		{Dobutsu, 0, 0, 3, "3a"},
+		{Dobutsu, 2, 3, 1, "1d"},
+		{Dobutsu, 1, 2, 2, "2c"},
+	}
+	for _, tt := range tests {
+		b := tt.variant.New()
+		s := NewSquare(tt.x, tt.y)
+		if got := b.File(s); got != tt.file {
+			t.Errorf("%s (%d, %d): File() = %d, want %d", tt.variant.Name, tt.x, tt.y, got, tt.file)
+		}
+		if got := b.SquareUSI(s); got != tt.usi {
+			t.Errorf("%s (%d, %d): SquareUSI() = %s, want %s", tt.variant.Name, tt.x, tt.y, got, tt.usi)
+		}
+		if got, err := b.ParseSquareUSI(tt.usi); err != nil || got != s {
+			t.Errorf("%s: ParseSquareUSI(%s) = %v, %v, want %v", tt.variant.Name, tt.usi, got, err, s)
+		}
+	}
+
+	b := Dobutsu.New()
+	for _, s := range []string{"4a", "1e", "9i", "0a"} {
+		if _, err := b.ParseSquareUSI(s); !errors.Is(err, ErrInvalidSquare) {
+			t.Errorf("どうぶつしょうぎの ParseSquareUSI(%s) = %v, want ErrInvalidSquare", s, err)
+		}
+	}
+	if got := b.SquareUSI(NewSquare(3, 0)); got != "-" {
+		t.Errorf("盤の外のマスの SquareUSI() = %s, want -", got)
+	}
+}
+
+func TestMoveSquares(t *testing.T) {
+	m := Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}
+	if m.IsDrop() || m.From().USI() != "7g" || m.To().USI() != "7f" {
+		t.Errorf("%+v: From() = %v, To() = %v", m, m.From(), m.To())
+	}
+	d := Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn}
+	if !d.IsDrop() || d.From() != NoSquare || d.To().USI() != "5e" {
+		t.Errorf("%+v: From() = %v, To() = %v", d, d.From(), d.To())
+	}
+}
+
+func TestMoveCode(t *testing.T) {
+	b, _, err := ParseSFEN("lnsgkgsnl/1r5+B1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/7R1/LNSGKGSNL w B 4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[MoveCode]Move{}
+	for _, m := range b.LegalMoves() {
+		c := b.EncodeMove(m)
+		if got := c.Move(); got != m {
+			t.Errorf("%+v: Move() = %+v", m, got)
+		}
+		if c.From() != m.From() || c.To() != m.To() || c.Promote() != m.Promote {
+			t.Errorf("%+v: From() = %v, To() = %v, Promote() = %v", m, c.From(), c.To(), c.Promote())
+		}
+		if prev, ok := seen[c]; ok {
+			t.Errorf("%+v と %+v が同じ %#x になります", prev, m, uint32(c))
+		}
+		seen[c] = m
+	}
+
+	// △同銀（２二の馬を取る）
+	m := Move{FromX: 6, FromY: 0, ToX: 7, ToY: 1}
+	if got := b.EncodeMove(m).Captured(); got != piece.PromBishop {
+		t.Errorf("Captured() = %v, want 馬", got)
+	}
+	// 駒打ち
+	if b.EncodeMove(Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Bishop}).Drop() != piece.Bishop {
+		t.Error("打つ駒の種類が戻りません")
+	}
+}
@@ -0,0 +1,160 @@
+package board
+
+import (
+	"fmt"
+
+	"shogi/piece"
+)
+
+// 局面の問題の種類
+type ProblemKind int
+
+const (
+	ProblemKingCount       ProblemKind = iota // 玉がない、または2枚以上ある
+	ProblemPieceCount                         // 駒の数が初期局面の駒の数より多い
+	ProblemNegativeHand                       // 持ち駒の数が負
+	ProblemNifu                               // 同じ筋に自分の歩が2枚以上ある
+	ProblemDeadPiece                          // 行き所のない段に駒がある
+	ProblemOpponentInCheck                    // 手番でない側の玉に王手がかかっている
+)
+
+// 局面の問題点
+type Problem struct {
+	Kind   ProblemKind
+	Player piece.Player // 問題のある側（駒の数の問題では None）
+	File   int          // 問題のマスの筋（1始まり、なければ0）
+	Rank   int          // 問題のマスの段（1始まり、なければ0。二歩では0）
+	Type   piece.Type   // 問題の駒の種類
+	Count  int          // 枚数（玉・駒・持ち駒の数の問題）
+}
+
+// 先手・後手の表記
+var problemPlayerNames = map[piece.Player]string{
+	piece.Sente: "先手",
+	piece.Gote:  "後手",
+}
+
+// 問題点の説明（例: 先手の二歩です（5筋））
+func (p Problem) String() string {
+	name := problemPlayerNames[p.Player]
+	switch p.Kind {
+	case ProblemKingCount:
+		if p.Count == 0 {
+			return name + "の玉がありません"
+		}
+		return fmt.Sprintf("%sの玉が%d枚あります", name, p.Count)
+	case ProblemPieceCount:
+		return fmt.Sprintf("%sが%d枚あります（多すぎます）", piece.Piece{Type: p.Type}.String(), p.Count)
+	case ProblemNegativeHand:
+		return fmt.Sprintf("%sの持駒の%sが%d枚です", name, piece.Piece{Type: p.Type}.String(), p.Count)
+	case ProblemNifu:
+		return fmt.Sprintf("%sの二歩です（%d筋）", name, p.File)
+	case ProblemDeadPiece:
+		mark := "▲"
+		if p.Player == piece.Gote {
+			mark = "△"
+		}
+		return fmt.Sprintf("行き所のない駒：%s%d%s%s", mark, p.File, rankKanji[p.Rank-1],
+			piece.Piece{Type: p.Type, Player: p.Player}.String())
+	case ProblemOpponentInCheck:
+		return "手番でない" + name + "の玉に王手がかかっています"
+	}
+	return "不明な問題"
+}
+
+// 局面のルール違反をすべて調べる（問題がなければ空）
+// 駒の数は将棋の種類の初期局面の駒（本将棋なら40枚）を上限とする
+func (b *Board) Validate() []Problem {
+	var problems []Problem
+	players := []piece.Player{piece.Sente, piece.Gote}
+
+	// 玉の数
+	for _, player := range players {
+		if n := b.countKings(player); n != 1 {
+			problems = append(problems, Problem{Kind: ProblemKingCount, Player: player, Type: piece.King, Count: n})
+		}
+	}
+
+	// 駒の数（成駒は元の駒として数える。玉は玉の数で調べる）
+	limits := b.variant().pieceCounts()
+	counts := b.pieceCounts()
+	for _, t := range b.variant().handTypes() {
+		if counts[t] > limits[t] {
+			problems = append(problems, Problem{Kind: ProblemPieceCount, Type: t, Count: counts[t]})
+		}
+	}
+
+	// 持ち駒の数
+	for _, player := range players {
+		hand := b.SenteCaptures
+		if player == piece.Gote {
+			hand = b.GoteCaptures
+		}
+		for _, t := range b.variant().handTypes() {
+			if n := hand[t]; n < 0 {
+				problems = append(problems, Problem{Kind: ProblemNegativeHand, Player: player, Type: t, Count: n})
+			}
+		}
+	}
+
+	// 二歩
+	for _, player := range players {
+		for x := 0; x < b.Width(); x++ {
+			n := 0
+			for y := 0; y < b.Height(); y++ {
+				if p := b.Grid[y][x]; p.Type == piece.Pawn && p.Player == player {
+					n++
+				}
+			}
+			if n > 1 {
+				problems = append(problems, Problem{Kind: ProblemNifu, Player: player, File: b.Width() - x, Type: piece.Pawn, Count: n})
+			}
+		}
+	}
+
+	// 行き所のない駒
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			if b.IsDeadPiece(x, y) {
+				p := b.Grid[y][x]
+				problems = append(problems, Problem{Kind: ProblemDeadPiece, Player: p.Player, File: b.Width() - x, Rank: y + 1, Type: p.Type, Count: 1})
+			}
+		}
+	}
+
+	// 手番でない側への王手（直前の手が王手放置になる）
+	opponent := b.CurrentPlayer.Opposite()
+	if b.InCheck(opponent) {
+		problems = append(problems, Problem{Kind: ProblemOpponentInCheck, Player: opponent, Type: piece.King})
+	}
+	return problems
+}
+
+// 盤上と持ち駒の駒の数（成駒は元の駒として数える）
+func (b *Board) pieceCounts() map[piece.Type]int {
+	counts := make(map[piece.Type]int)
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			if p := b.Grid[y][x]; p.Type != piece.Empty {
+				counts[p.Type.Unpromote()]++
+			}
+		}
+	}
+	for _, hand := range []map[piece.Type]int{b.SenteCaptures, b.GoteCaptures} {
+		for t, n := range hand {
+			if n > 0 {
+				counts[t] += n
+			}
+		}
+	}
+	return counts
+}
+
+// 初期局面の駒の数（局面で使える駒の数の上限）
+func (v *Variant) pieceCounts() map[piece.Type]int {
+	b, _, err := parseSFEN(v.StartSFEN, v)
+	if err != nil {
+		return nil
+	}
+	return b.pieceCounts()
+}
@@ -0,0 +1,87 @@
+package board
+
+import (
+	"reflect"
+	"testing"
+
+	"shogi/piece"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		sfen string
+		want []Problem
+	}{
+		{StartSFEN, nil},
+		// 詰将棋の局面（駒が40枚より少ない）は問題ない
+		{"4k4/9/4P4/9/9/9/9/9/9 b G2r2b3g4s4n4l17p 1", []Problem{
+			{Kind: ProblemKingCount, Player: piece.Sente, Type: piece.King},
+		}},
+		{"4k4/9/9/9/9/9/9/9/3KK4 b - 1", []Problem{
+			{Kind: ProblemKingCount, Player: piece.Sente, Type: piece.King, Count: 2},
+		}},
+		// 金が5枚
+		{"4k4/9/9/9/9/9/9/9/4K4 b 5G 1", []Problem{
+			{Kind: ProblemPieceCount, Type: piece.Gold, Count: 5},
+		}},
+		// 成駒も元の駒として数える
+		{"4k4/9/9/9/9/9/9/+P+P+P+P+P+P+P+P+P/4K4 b 9P1p 1", []Problem{
+			{Kind: ProblemPieceCount, Type: piece.Pawn, Count: 19},
+		}},
+		{"4k4/9/9/9/4P4/9/9/4P4/4K4 b - 1", []Problem{
+			{Kind: ProblemNifu, Player: piece.Sente, File: 5, Type: piece.Pawn, Count: 2},
+		}},
+		// と金は二歩にならない
+		{"4k4/9/9/9/4+P4/9/9/4P4/4K4 b - 1", nil},
+		{"P3k4/9/9/9/9/9/9/8n/4K3l b - 1", []Problem{
+			{Kind: ProblemDeadPiece, Player: piece.Sente, File: 9, Rank: 1, Type: piece.Pawn, Count: 1},
+			{Kind: ProblemDeadPiece, Player: piece.Gote, File: 1, Rank: 8, Type: piece.Knight, Count: 1},
+			{Kind: ProblemDeadPiece, Player: piece.Gote, File: 1, Rank: 9, Type: piece.Lance, Count: 1},
+		}},
+		// 後手の手番で先手の玉に王手がかかっていることはない
+		{"4k4/9/9/9/9/9/9/4r4/4K4 w - 1", []Problem{
+			{Kind: ProblemOpponentInCheck, Player: piece.Sente, Type: piece.King},
+		}},
+		{"4k4/9/9/9/9/9/9/4r4/4K4 b - 1", nil},
+	}
+	for _, tt := range tests {
+		b, _, err := ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.Validate(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Validate() = %v, want %v", tt.sfen, got, tt.want)
+		}
+	}
+}
+
+func TestValidateNegativeHand(t *testing.T) {
+	// 持ち駒がないのに打つと、歩が1枚増えて持ち駒が負になる
+	b := New()
+	b.MakeMove(Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn})
+	want := []Problem{
+		{Kind: ProblemPieceCount, Type: piece.Pawn, Count: 19},
+		{Kind: ProblemNegativeHand, Player: piece.Sente, Type: piece.Pawn, Count: -1},
+		{Kind: ProblemNifu, Player: piece.Sente, File: 5, Type: piece.Pawn, Count: 2},
+	}
+	if got := b.Validate(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+}
+
+func TestProblemString(t *testing.T) {
+	tests := []struct {
+		problem Problem
+		want    string
+	}{
+		{Problem{Kind: ProblemKingCount, Player: piece.Gote, Type: piece.King}, "後手の玉がありません"},
+		{Problem{Kind: ProblemNifu, Player: piece.Sente, File: 5, Type: piece.Pawn, Count: 2}, "先手の二歩です（5筋）"},
+		{Problem{Kind: ProblemDeadPiece, Player: piece.Sente, File: 1, Rank: 1, Type: piece.Pawn, Count: 1}, "行き所のない駒：▲1一歩"},
+		{Problem{Kind: ProblemPieceCount, Type: piece.Gold, Count: 5}, "金が5枚あります（多すぎます）"},
+	}
+	for _, tt := range tests {
+		if got := tt.problem.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package board
+
+import "shogi/piece"
+
+// 盤の大きさ・敵陣の段数・初期配置・ルールが異なる将棋の種類
+// 盤面は Grid の左上（Width×Height）の範囲だけを使う
+type Variant struct {
+	Name          string         // 識別名（コマンドラインでの指定などに使う）
+	Title         string         // 表示名
+	Width         int            // 筋の数
+	Height        int            // 段の数
+	PromotionZone int            // 敵陣の段数
+	StartSFEN     string         // 初期局面
+	NoDrops       bool           // 持ち駒を打てない
+	Wins          []WinCondition // 勝ちの条件（空なら詰みのみ）
+	Pieces        []piece.Type   // 独自に定義した駒（成る前の駒）
+
+	letters map[piece.Type]byte // 独自の駒のSFENの文字（組み込みの駒の文字より優先する）
+
+	// 定義ファイルの初期局面を確かめるときだけ使う、まだ登録していない独自の駒が成れるか
+	provisional map[piece.Type]bool
+}
+
+// 勝ちの条件
+type WinCondition string
+
+const (
+	WinCheckmate WinCondition = "checkmate" // 相手の玉を詰ませる
+	WinTry       WinCondition = "try"       // 自分の玉が最奥の段に入り、取られない
+)
+
+var (
+	// 本将棋
+	Standard = &Variant{
+		Name:          "standard",
+		Title:         "本将棋",
+		Width:         9,
+		Height:        9,
+		PromotionZone: 3,
+		StartSFEN:     StartSFEN,
+	}
+
+	// 5五将棋
+	Minishogi = &Variant{
+		Name:          "minishogi",
+		Title:         "5五将棋",
+		Width:         5,
+		Height:        5,
+		PromotionZone: 1,
+		StartSFEN:     "rbsgk/4p/5/P4/KGSBR b - 1",
+	}
+
+	// ジャドケンス将棋（6×6）
+	Judkins = &Variant{
+		Name:          "judkins",
+		Title:         "ジャドケンス将棋",
+		Width:         6,
+		Height:        6,
+		PromotionZone: 2,
+		StartSFEN:     "rbnsgk/5p/6/6/P5/KGSNBR b - 1",
+	}
+
+	// どうぶつしょうぎ風（3×4、キリン・ゾウ・ヒヨコ・ライオン）
+	// ライオンは玉、ヒヨコは歩として扱う
+	Dobutsu = &Variant{
+		Name:          "dobutsu",
+		Title:         "どうぶつしょうぎ",
+		Width:         3,
+		Height:        4,
+		PromotionZone: 1,
+		StartSFEN:     "jke/1p1/1P1/EKJ b - 1",
+		Wins:          []WinCondition{WinCheckmate, WinTry},
+	}
+)
+
+// 選べる将棋の種類
+var Variants = []*Variant{Standard, Minishogi, Judkins, Dobutsu}
+
+// 識別名から将棋の種類を探す（なければnil）
+func VariantByName(name string) *Variant {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// 初期局面の将棋盤を作成
+func (v *Variant) New() *Board {
+	b, _, err := parseSFEN(v.StartSFEN, v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// この種類の盤としてSFEN形式の局面を読み込む
+func (v *Variant) ParseSFEN(s string) (*Board, int, error) {
+	return parseSFEN(s, v)
+}
+
+// 盤の大きさが一致する種類を探す（なければnil）
+func variantBySize(width, height int) *Variant {
+	for _, v := range Variants {
+		if v.Width == width && v.Height == height {
+			return v
+		}
+	}
+	return nil
+}
+
+// 将棋の種類（指定がなければ本将棋）
+func (b *Board) variant() *Variant {
+	if b.Variant == nil {
+		return Standard
+	}
+	return b.Variant
+}
+
+// 筋の数
+func (b *Board) Width() int {
+	return b.variant().Width
+}
+
+// 段の数
+func (b *Board) Height() int {
+	return b.variant().Height
+}
+
+// 盤の範囲内か
+func (b *Board) InBounds(x, y int) bool {
+	return x >= 0 && x < b.Width() && y >= 0 && y < b.Height()
+}
+
+// 指定のプレイヤーから見て敵陣の段か
+func (b *Board) inPromotionZone(player piece.Player, y int) bool {
+	zone := b.variant().PromotionZone
+	if player == piece.Sente {
+		return y < zone
+	}
+	return y >= b.Height()-zone
+}
+
+// 指定のプレイヤーから見て奥から何段目か（最奥の段は1）
+func (b *Board) rankFromFar(player piece.Player, y int) int {
+	if player == piece.Sente {
+		return y + 1
+	}
+	return b.Height() - y
+}
+
+// SFENの駒文字（先手の文字）
+func (v *Variant) letter(t piece.Type) byte {
+	if c, ok := v.letters[t]; ok {
+		return c
+	}
+	return sfenLetters[t]
+}
+
+// 成ることができる駒か（まだ登録していない独自の駒も含む）
+func (v *Variant) canPromote(t piece.Type) bool {
+	if promotable, ok := v.provisional[t]; ok {
+		return promotable
+	}
+	return t.CanPromote()
+}
+
+// SFENの駒文字を駒の種類とプレイヤーに変換
+func (v *Variant) parseLetter(c byte) (piece.Type, piece.Player, bool) {
+	player, upper := piece.Sente, c
+	if c >= 'a' && c <= 'z' {
+		player = piece.Gote
+		upper -= 'a' - 'A'
+	}
+	for pt, l := range v.letters {
+		if l == upper {
+			return pt, player, true
+		}
+	}
+	return parseSFENLetter(c)
+}
+
+// 持ち駒になる駒の種類（表示・列挙の順序）
+func (v *Variant) handTypes() []piece.Type {
+	if len(v.Pieces) == 0 {
+		return handPieceTypes
+	}
+	return append(append([]piece.Type(nil), handPieceTypes...), v.Pieces...)
+}
+
+// SFENでの持ち駒の順序
+func (v *Variant) sfenHandTypes() []piece.Type {
+	if len(v.Pieces) == 0 {
+		return sfenHandOrder
+	}
+	return append(append([]piece.Type(nil), sfenHandOrder...), v.Pieces...)
+}
+
+// 勝ちの条件
+func (v *Variant) winConditions() []WinCondition {
+	if len(v.Wins) == 0 {
+		return []WinCondition{WinCheckmate}
+	}
+	return v.Wins
+}
+
+// 勝敗が決まっていれば勝ったプレイヤーを返す（決まっていなければ None）
+// 直前に指したプレイヤーが勝ちの条件を満たしたかを調べる
+func (b *Board) Winner() piece.Player {
+	mover := b.CurrentPlayer.Opposite()
+	for _, w := range b.variant().winConditions() {
+		switch w {
+		case WinCheckmate:
+			if b.IsCheckmate() {
+				return mover
+			}
+		case WinTry:
+			x, y := b.findKing(mover)
+			if x >= 0 && b.rankFromFar(mover, y) == 1 && !b.InCheck(mover) {
+				return mover
+			}
+		}
+	}
+	return piece.None
+}
@@ -0,0 +1,246 @@
+package board
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shogi/piece"
+)
+
+// 同梱の定義ファイルが読み込め、初期局面で指せる
+func TestLoadVariantFiles(t *testing.T) {
+	files, err := filepath.Glob("../variants/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatal("定義ファイルがありません", err)
+	}
+	for _, name := range files {
+		v, err := LoadVariantFile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		b := v.New()
+		if got := b.SFEN(1); got != v.StartSFEN {
+			t.Errorf("%s: SFEN = %q, want %q", name, got, v.StartSFEN)
+		}
+		if len(b.LegalMoves()) == 0 {
+			t.Errorf("%s: 初期局面に合法手がありません", name)
+		}
+	}
+}
+
+func TestLoadVariantErrors(t *testing.T) {
+	tests := []string{
+		`{"width": 5, "height": 5, "start": "4k/5/5/5/K4 b - 1"}`,
+		`{"name": "x", "width": 10, "height": 5, "start": "4k/5/5/5/K4 b - 1"}`,
+		`{"name": "x", "width": 5, "height": 5, "start": "4k/5/5/5/5 b - 1"}`,
+		`{"name": "x", "width": 5, "height": 5, "start": "4k/5/5/5/K4 b - 1", "win": ["stalemate"]}`,
+		`{"name": "x", "width": 5, "height": 5, "start": "4k/5/5/5/K4 b - 1",
+		  "pieces": [{"letter": "X", "name": "X", "moves": [{"dx": 2, "dy": 0, "slide": true}]}]}`,
+		`{"name": "x", "width": 5, "height": 5, "start": "4k/5/5/5/K4 b - 1", "unknown": 1}`,
+	}
+	for _, tt := range tests {
+		if _, err := LoadVariant(strings.NewReader(tt)); !errors.Is(err, ErrInvalidVariant) {
+			t.Errorf("LoadVariant(%s) = %v, want ErrInvalidVariant", tt, err)
+		}
+	}
+}
+
+// 左右が非対称な駒は後手では180度回った動きになる
+// 前にしか進めない駒は最奥の段に行けない（成れないので）
+func TestCustomPieceMovement(t *testing.T) {
+	v, err := LoadVariant(strings.NewReader(`{
+		"name": "test", "width": 5, "height": 5, "promotionZone": 1,
+		"start": "k3x/5/2X2/5/4K b - 1",
+		"pieces": [{"letter": "X", "name": "右", "moves": [{"dx": 1, "dy": -1, "slide": true}]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := v.New()
+	if !b.IsLegalMove(Move{FromX: 2, FromY: 2, ToX: 3, ToY: 1}) {
+		t.Error("先手の駒が右上に動けません")
+	}
+	if b.IsLegalMove(Move{FromX: 2, FromY: 2, ToX: 1, ToY: 1}) {
+		t.Error("先手の駒が左上に動けます")
+	}
+	if b.IsLegalMove(Move{FromX: 2, FromY: 2, ToX: 4, ToY: 0}) {
+		t.Error("先手の駒が行き所のない段に動けます")
+	}
+
+	b.CurrentPlayer = piece.Gote
+	if !b.IsLegalMove(Move{FromX: 4, FromY: 0, ToX: 3, ToY: 1}) {
+		t.Error("後手の駒が後手から見て右前に動けません")
+	}
+}
+
+func TestNoDrops(t *testing.T) {
+	v, err := LoadVariant(strings.NewReader(`{
+		"name": "nodrops", "width": 9, "height": 9, "promotionZone": 3,
+		"start": "4k4/9/9/9/9/9/9/9/4K4 b G 1", "drops": false
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := v.New()
+	for _, m := range b.LegalMoves() {
+		if m.FromX == -1 {
+			t.Fatalf("持ち駒を打つ手が合法手に含まれています: %+v", m)
+		}
+	}
+}
+
+func TestWinner(t *testing.T) {
+	tests := []struct {
+		variant *Variant
+		sfen    string
+		want    piece.Player
+	}{
+		// トライ（取られない）
+		{Dobutsu, "1K1/3/1k1/3 w - 1", piece.Sente},
+		// 最奥の段に入っても取られるならトライではない
+		{Dobutsu, "1Kj/3/1k1/3 w - 1", piece.None},
+		// 本将棋にトライはない
+		{Standard, "4K4/9/9/9/4k4/9/9/9/9 w - 1", piece.None},
+		// 詰み
+		{Standard, "4k4/4G4/4P4/9/9/9/9/9/4K4 w - 1", piece.Sente},
+	}
+	for _, tt := range tests {
+		b, _, err := tt.variant.ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.Winner(); got != tt.want {
+			t.Errorf("%s: Winner() = %v, want %v", tt.sfen, got, tt.want)
+		}
+	}
+}
+
+// 同じ定義ファイルを何度読み込んでも駒の種類は増えず、読み込めなかったファイルの駒は登録しない
+func TestLoadVariantRegistersOnce(t *testing.T) {
+	load := func(start, name string) (*Variant, error) {
+		return LoadVariant(strings.NewReader(`{
+			"name": "once", "width": 5, "height": 5, "promotionZone": 1,
+			"start": "` + start + `",
+			"pieces": [{"letter": "X", "name": "` + name + `", "moves": [{"dx": 0, "dy": -1}],
+			            "promoted": {"name": "成` + name + `", "moves": [{"dx": 0, "dy": 1}]}}]
+		}`))
+	}
+	a, err := load("k4/5/2X2/5/4K b - 1", "一")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := load("k4/5/2X2/5/4K b - 1", "一")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Pieces[0] != a.Pieces[0] {
+		t.Errorf("読み込み直した駒の種類 = %d, want %d", again.Pieces[0], a.Pieces[0])
+	}
+
+	// 玉のない初期局面（成駒の '+' は登録前でも読める）
+	if _, err := load("5/5/2+X2/5/4K b - 1", "二"); !errors.Is(err, ErrInvalidVariant) {
+		t.Fatalf("玉のない定義ファイル: err = %v, want ErrInvalidVariant", err)
+	}
+	b, err := load("k4/5/2X2/5/4K b - 1", "三")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := a.Pieces[0] + 2; b.Pieces[0] != want {
+		t.Errorf("次に登録した駒の種類 = %d, want %d（読み込めなかった駒が登録されています）", b.Pieces[0], want)
+	}
+}
+
+// 探索などで駒の動きを引きながら定義ファイルを読み込める
+func TestLoadVariantConcurrent(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			for pt := piece.Type(1); pt <= piece.Elephant+4; pt++ {
+				p := piece.Piece{Type: pt, Player: piece.Sente}
+				p.GetMovements()
+				_ = p.String()
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if _, err := LoadVariantFile("../variants/tori.json"); err != nil {
+			t.Error(err)
+		}
+	}
+	<-done
+}
+
+// 初期局面と、そこからの合法手の数
+func TestVariantStart(t *testing.T) {
+	tests := []struct {
+		variant *Variant
+		sfen    string
+		moves   int
+	}{
+		{Standard, StartSFEN, 30},
+		{Minishogi, "rbsgk/4p/5/P4/KGSBR b - 1", 14},
+		{Judkins, "rbnsgk/5p/6/6/P5/KGSNBR b - 1", 20},
+		{Dobutsu, "jke/1p1/1P1/EKJ b - 1", 4},
+	}
+	for _, tt := range tests {
+		b := tt.variant.New()
+		if got := b.SFEN(1); got != tt.sfen {
+			t.Errorf("%s: SFEN = %q, want %q", tt.variant.Name, got, tt.sfen)
+		}
+		if b.Width() != tt.variant.Width || b.Height() != tt.variant.Height {
+			t.Errorf("%s: 盤の大きさ %d×%d", tt.variant.Name, b.Width(), b.Height())
+		}
+		if got := len(b.LegalMoves()); got != tt.moves {
+			t.Errorf("%s: 初期局面の合法手 %d, want %d", tt.variant.Name, got, tt.moves)
+		}
+		for _, m := range b.LegalMoves() {
+			if !b.InBounds(m.ToX, m.ToY) {
+				t.Errorf("%s: 盤の外への合法手 %+v", tt.variant.Name, m)
+			}
+		}
+	}
+}
+
+// 小さい盤での成り・打つ手の境目（敵陣の段数、行き所のない駒、二歩）
+func TestVariantMoveRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant *Variant
+		sfen    string
+		move    Move
+		want    error
+	}{
+		// 5五将棋の敵陣は1段
+		{"5五将棋 歩が二段目に成る", Minishogi, "4k/5/P4/5/K4 b - 1", Move{FromX: 0, FromY: 2, ToX: 0, ToY: 1, Promote: true}, ErrCannotPromote},
+		{"5五将棋 歩が一段目に成らない", Minishogi, "4k/P4/5/5/K4 b - 1", Move{FromX: 0, FromY: 1, ToX: 0, ToY: 0}, ErrMustPromote},
+		{"5五将棋 歩が一段目に成る", Minishogi, "4k/P4/5/5/K4 b - 1", Move{FromX: 0, FromY: 1, ToX: 0, ToY: 0, Promote: true}, nil},
+		{"5五将棋 銀が敵陣から出て成る", Minishogi, "S3k/5/5/5/K4 b - 1", Move{FromX: 0, FromY: 0, ToX: 1, ToY: 1, Promote: true}, nil},
+		{"5五将棋 後手の歩が五段目に成らない", Minishogi, "4k/5/5/p4/4K w - 1", Move{FromX: 0, FromY: 3, ToX: 0, ToY: 4}, ErrMustPromote},
+		{"5五将棋 歩を一段目に打つ", Minishogi, "4k/5/5/5/K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 1, ToY: 0, Piece: piece.Pawn}, ErrIllegalDestination},
+		{"5五将棋 歩を二段目に打つ", Minishogi, "4k/5/5/5/K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 1, ToY: 1, Piece: piece.Pawn}, nil},
+		{"5五将棋 二歩", Minishogi, "4k/5/5/1P3/K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 1, ToY: 1, Piece: piece.Pawn}, ErrNifu},
+		{"5五将棋 盤の外に打つ", Minishogi, "4k/5/5/5/K4 b P 1", Move{FromX: -1, FromY: -1, ToX: 5, ToY: 1, Piece: piece.Pawn}, ErrIllegalDestination},
+
+		// ジャドケンス将棋の敵陣は2段
+		{"ジャドケンス 歩が二段目に成る", Judkins, "5k/6/P5/6/6/K5 b - 1", Move{FromX: 0, FromY: 2, ToX: 0, ToY: 1, Promote: true}, nil},
+		{"ジャドケンス 歩が三段目に成る", Judkins, "5k/6/6/P5/6/K5 b - 1", Move{FromX: 0, FromY: 3, ToX: 0, ToY: 2, Promote: true}, ErrCannotPromote},
+		{"ジャドケンス 桂が二段目に成らない", Judkins, "5k/6/6/1N4/6/K5 b - 1", Move{FromX: 1, FromY: 3, ToX: 0, ToY: 1}, ErrMustPromote},
+		{"ジャドケンス 桂が三段目に跳ぶ", Judkins, "5k/6/6/6/1N4/K5 b - 1", Move{FromX: 1, FromY: 4, ToX: 2, ToY: 2}, nil},
+		{"ジャドケンス 桂を二段目に打つ", Judkins, "5k/6/6/6/6/K5 b N 1", Move{FromX: -1, FromY: -1, ToX: 2, ToY: 1, Piece: piece.Knight}, ErrIllegalDestination},
+		{"ジャドケンス 桂を三段目に打つ", Judkins, "5k/6/6/6/6/K5 b N 1", Move{FromX: -1, FromY: -1, ToX: 2, ToY: 2, Piece: piece.Knight}, nil},
+		{"ジャドケンス 後手の桂を五段目に打つ", Judkins, "5k/6/6/6/6/K5 w n 1", Move{FromX: -1, FromY: -1, ToX: 2, ToY: 4, Piece: piece.Knight}, ErrIllegalDestination},
+		{"ジャドケンス 二歩", Judkins, "5k/6/6/6/2P3/K5 b P 1", Move{FromX: -1, FromY: -1, ToX: 2, ToY: 2, Piece: piece.Pawn}, ErrNifu},
+	}
+	for _, tt := range tests {
+		b, _, err := tt.variant.ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := b.CheckMove(tt.move); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckMove(%+v) = %v, want %v", tt.name, tt.move, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package board
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"shogi/piece"
+)
+
+var ErrInvalidVariant = errors.New("board: 不正な将棋の種類の定義です")
+
+// 将棋の種類の定義ファイル（JSON）
+type variantFile struct {
+	Name          string         `json:"name"`
+	Title         string         `json:"title"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	PromotionZone int            `json:"promotionZone"`
+	Start         string         `json:"start"` // 初期局面（SFEN）
+	Drops         *bool          `json:"drops"` // 持ち駒を打てるか（省略時は打てる）
+	Win           []WinCondition `json:"win"`
+	Pieces        []pieceFile    `json:"pieces"`
+}
+
+// 独自の駒の定義
+type pieceFile struct {
+	Letter   string     `json:"letter"`   // SFENの文字（先手の大文字）
+	Name     string     `json:"name"`     // 駒の文字
+	Like     string     `json:"like"`     // 同じ動きをする組み込みの駒のSFENの文字
+	Moves    []moveFile `json:"moves"`    // 先手から見た動き（dy が負なら前）
+	Promoted *pieceFile `json:"promoted"` // 成った後の駒（成れなければ省略）
+}
+
+// 駒の動き
+type moveFile struct {
+	DX    int  `json:"dx"`
+	DY    int  `json:"dy"`
+	Slide bool `json:"slide"` // 障害物に当たるまで何マスでも進めるか
+}
+
+// 将棋の種類の定義ファイルを読み込む
+func LoadVariantFile(name string) (*Variant, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadVariant(f)
+}
+
+// JSONで書かれた将棋の種類の定義を読み込む
+// 独自の駒は、定義をすべて確かめてから piece パッケージに登録する
+func LoadVariant(r io.Reader) (*Variant, error) {
+	var vf variantFile
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&vf); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVariant, err)
+	}
+
+	switch {
+	case vf.Name == "":
+		return nil, fmt.Errorf("%w: name がありません", ErrInvalidVariant)
+	case vf.Width < 1 || vf.Width > BoardSize || vf.Height < 1 || vf.Height > BoardSize:
+		return nil, fmt.Errorf("%w: 盤の大きさは%d×%dまでです", ErrInvalidVariant, BoardSize, BoardSize)
+	case vf.PromotionZone < 0 || vf.PromotionZone > vf.Height:
+		return nil, fmt.Errorf("%w: promotionZone が盤の段数を超えています", ErrInvalidVariant)
+	}
+	for _, w := range vf.Win {
+		if w != WinCheckmate && w != WinTry {
+			return nil, fmt.Errorf("%w: 不明な勝ちの条件です: %s", ErrInvalidVariant, w)
+		}
+	}
+
+	v := &Variant{
+		Name:          vf.Name,
+		Title:         vf.Title,
+		Width:         vf.Width,
+		Height:        vf.Height,
+		PromotionZone: vf.PromotionZone,
+		StartSFEN:     vf.Start,
+		NoDrops:       vf.Drops != nil && !*vf.Drops,
+		Wins:          vf.Win,
+		letters:       make(map[piece.Type]byte),
+	}
+	if v.Title == "" {
+		v.Title = v.Name
+	}
+
+	// 独自の駒
+	defs := make([]piece.Definition, len(vf.Pieces))
+	used := make(map[byte]bool)
+	for i, pf := range vf.Pieces {
+		if len(pf.Letter) != 1 || pf.Letter[0] < 'A' || pf.Letter[0] > 'Z' || used[pf.Letter[0]] {
+			return nil, fmt.Errorf("%w: 駒の文字 %q が不正か重複しています", ErrInvalidVariant, pf.Letter)
+		}
+		used[pf.Letter[0]] = true
+		d, err := pf.definition()
+		if err != nil {
+			return nil, err
+		}
+		defs[i] = d
+	}
+
+	// 初期局面（双方に玉が1枚ずつ必要）
+	// 独自の駒は登録する前なので、仮の種類の番号で読んで確かめる
+	pv := *v
+	pv.letters = make(map[piece.Type]byte)
+	pv.provisional = make(map[piece.Type]bool)
+	for i, pf := range vf.Pieces {
+		t := piece.MaxType + 1 + piece.Type(i)
+		pv.letters[t] = pf.Letter[0]
+		pv.provisional[t] = pf.Promoted != nil
+	}
+	b, _, err := pv.ParseSFEN(v.StartSFEN)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 初期局面 %q を読めません", ErrInvalidVariant, v.StartSFEN)
+	}
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		if b.countKings(player) != 1 {
+			return nil, fmt.Errorf("%w: 初期局面には双方に玉が1枚ずつ必要です", ErrInvalidVariant)
+		}
+	}
+
+	types, err := piece.Register(defs...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVariant, err)
+	}
+	for i, t := range types {
+		v.Pieces = append(v.Pieces, t)
+		v.letters[t] = vf.Pieces[i].Letter[0]
+	}
+	return v, nil
+}
+
+// 駒の定義を確かめて piece パッケージの形式にする
+func (pf *pieceFile) definition() (piece.Definition, error) {
+	d := piece.Definition{Name: pf.Name}
+	if pf.Name == "" {
+		return d, fmt.Errorf("%w: 駒 %s に name がありません", ErrInvalidVariant, pf.Letter)
+	}
+
+	if pf.Like != "" {
+		t, _, ok := parseSFENLetter(pf.Like[0])
+		if len(pf.Like) != 1 || !ok {
+			return d, fmt.Errorf("%w: 駒 %s の like %q が不正です", ErrInvalidVariant, pf.Name, pf.Like)
+		}
+		d.Movements = append(d.Movements, piece.Piece{Type: t, Player: piece.Sente}.GetMovements()...)
+	}
+	for _, m := range pf.Moves {
+		if m.DX == 0 && m.DY == 0 ||
+			abs(m.DX) >= BoardSize || abs(m.DY) >= BoardSize ||
+			m.Slide && (abs(m.DX) > 1 || abs(m.DY) > 1) {
+			return d, fmt.Errorf("%w: 駒 %s の動き (%d, %d) が不正です", ErrInvalidVariant, pf.Name, m.DX, m.DY)
+		}
+		d.Movements = append(d.Movements, piece.Direction{DX: m.DX, DY: m.DY, Repeat: m.Slide})
+	}
+	if len(d.Movements) == 0 {
+		return d, fmt.Errorf("%w: 駒 %s に動きがありません", ErrInvalidVariant, pf.Name)
+	}
+
+	if pf.Promoted != nil {
+		if pf.Promoted.Promoted != nil {
+			return d, fmt.Errorf("%w: 成った駒 %s はさらに成れません", ErrInvalidVariant, pf.Promoted.Name)
+		}
+		pf.Promoted.Letter = pf.Letter
+		promoted, err := pf.Promoted.definition()
+		if err != nil {
+			return d, err
+		}
+		d.Promoted = &promoted
+	}
+	return d, nil
+}
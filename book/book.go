@@ -0,0 +1,260 @@
+package book
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shogi/board"
+	"shogi/usi"
+)
+
+var ErrInvalidBook = errors.New("book: 不正な定跡ファイルです")
+
+// 定跡ファイルの先頭行
+const header = "#YANEURAOU-DB2016 1.00"
+
+// 定跡の候補手
+type Entry struct {
+	Move      board.Move
+	Ponder    board.Move // 予想される応手（HasPonder が false なら使わない）
+	HasPonder bool
+	Score     int // 指す側から見た評価値
+	Depth     int // 評価値を求めた探索の深さ
+	Count     int // 出現回数（選ぶときの重み）
+	Wins      int // 指した側が勝った回数（棋譜から作った定跡のみ）
+	Draws     int // 引き分けの回数（棋譜から作った定跡のみ）
+}
+
+// 指した側の勝率（引き分けは0.5勝、出現回数が0なら0.5）
+func (e Entry) WinRate() float64 {
+	if e.Count == 0 {
+		return 0.5
+	}
+	return (float64(e.Wins) + float64(e.Draws)/2) / float64(e.Count)
+}
+
+// 定跡
+type Book struct {
+	positions map[string]*position // 局面のキー（SFENから手数を除いたもの） → 候補手
+}
+
+// 定跡の1局面
+type position struct {
+	ply     int // SFENの手数欄
+	entries []Entry
+}
+
+// 空の定跡を作る
+func New() *Book {
+	return &Book{positions: make(map[string]*position)}
+}
+
+// 定跡ファイルを読み込む
+func Load(name string) (*Book, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// やねうら王の定跡形式（YANEURAOU-DB2016）を読み込む
+//
+//	#YANEURAOU-DB2016 1.00
+//	sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1
+//	7g7f 3c3d 32 0 120 64 3
+//	2g2f none 25 0 80
+//
+// 指し手の行は「指し手 予想応手 評価値 深さ 出現回数」で、棋譜から作った定跡では
+// その後に「勝ち数 引き分け数」を付ける（やねうら王は余分な欄を読み飛ばす）
+func Read(r io.Reader) (*Book, error) {
+	bk := New()
+	var cur *board.Board
+	var ply int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if s, ok := strings.CutPrefix(line, "sfen "); ok {
+			b, n, err := board.ParseSFEN(s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidBook, line)
+			}
+			cur, ply = b, n
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBook, line)
+		}
+
+		e, err := parseEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		bk.add(cur.PositionKey(), ply, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return bk, nil
+}
+
+// 指し手の行を解析する（指し手以外の欄は省略できる）
+func parseEntry(line string) (Entry, error) {
+	invalid := fmt.Errorf("%w: %s", ErrInvalidBook, line)
+	fields := strings.Fields(line)
+
+	var e Entry
+	var err error
+	if e.Move, err = usi.ParseMove(fields[0]); err != nil {
+		return Entry{}, invalid
+	}
+	if len(fields) > 1 && fields[1] != "none" {
+		if e.Ponder, err = usi.ParseMove(fields[1]); err != nil {
+			return Entry{}, invalid
+		}
+		e.HasPonder = true
+	}
+
+	e.Count = 1
+	for i, p := range []*int{&e.Score, &e.Depth, &e.Count, &e.Wins, &e.Draws} {
+		if len(fields) <= i+2 {
+			break
+		}
+		if *p, err = strconv.Atoi(fields[i+2]); err != nil {
+			return Entry{}, invalid
+		}
+	}
+	return e, nil
+}
+
+// 局面に候補手を加える。同じ手があれば回数と勝敗を足し合わせる
+func (bk *Book) Add(b *board.Board, ply int, e Entry) {
+	bk.add(b.PositionKey(), ply, e)
+}
+
+func (bk *Book) add(key string, ply int, e Entry) {
+	pos, ok := bk.positions[key]
+	if !ok {
+		pos = &position{ply: ply}
+		bk.positions[key] = pos
+	}
+	for i := range pos.entries {
+		old := &pos.entries[i]
+		if old.Move == e.Move {
+			old.Count += e.Count
+			old.Wins += e.Wins
+			old.Draws += e.Draws
+			return
+		}
+	}
+	pos.entries = append(pos.entries, e)
+}
+
+// 定跡に含まれる局面の数
+func (bk *Book) Len() int {
+	return len(bk.positions)
+}
+
+// 局面の候補手（合法手だけを出現回数・評価値の多い順に返す）
+func (bk *Book) Moves(b *board.Board) []Entry {
+	pos, ok := bk.positions[b.PositionKey()]
+	if !ok {
+		return nil
+	}
+	var entries []Entry
+	for _, e := range pos.entries {
+		if b.IsLegalMove(e.Move) {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// 出現回数の多い順（同じなら評価値の高い順）に並べる
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Score > entries[j].Score
+	})
+}
+
+// 出現回数を重みにして候補手を1つ選ぶ（定跡になければ false）
+func (bk *Book) Pick(b *board.Board, rnd *rand.Rand) (board.Move, bool) {
+	entries := bk.Moves(b)
+	if len(entries) == 0 {
+		return board.Move{}, false
+	}
+
+	total := 0
+	for _, e := range entries {
+		total += max(e.Count, 1)
+	}
+	n := rnd.Intn(total)
+	for _, e := range entries {
+		n -= max(e.Count, 1)
+		if n < 0 {
+			return e.Move, true
+		}
+	}
+	return entries[0].Move, true
+}
+
+// やねうら王の定跡形式で書き出す（局面はSFENの順に並べる）
+func (bk *Book) Write(w io.Writer) error {
+	keys := make([]string, 0, len(bk.positions))
+	for key := range bk.positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, header)
+	for _, key := range keys {
+		pos := bk.positions[key]
+		fmt.Fprintf(bw, "sfen %s %d\n", key, pos.ply)
+
+		entries := append([]Entry(nil), pos.entries...)
+		sortEntries(entries)
+		for _, e := range entries {
+			ponder := "none"
+			if e.HasPonder {
+				ponder = usi.FormatMove(e.Ponder)
+			}
+			fmt.Fprintf(bw, "%s %s %d %d %d", usi.FormatMove(e.Move), ponder, e.Score, e.Depth, e.Count)
+			if e.Wins > 0 || e.Draws > 0 {
+				fmt.Fprintf(bw, " %d %d", e.Wins, e.Draws)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+// 定跡ファイルに書き出す
+func (bk *Book) Save(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := bk.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
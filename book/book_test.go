@@ -0,0 +1,158 @@
+package book
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"shogi/board"
+	"shogi/kif"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+const testBook = `#YANEURAOU-DB2016 1.00
+sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1
+7g7f 3c3d 32 10 120
+2g2f none 25 8 80 50 4
+5i5h
+`
+
+func TestReadWrite(t *testing.T) {
+	bk, err := Read(strings.NewReader(testBook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := bk.Moves(board.New())
+	if len(entries) != 3 {
+		t.Fatalf("候補手が %d 手, want 3", len(entries))
+	}
+	if got := usi.FormatMove(entries[0].Move); got != "7g7f" || entries[0].Count != 120 || !entries[0].HasPonder {
+		t.Errorf("1番目の候補手 = %s %+v", got, entries[0])
+	}
+	if e := entries[1]; e.Score != 25 || e.Depth != 8 || e.Wins != 50 || e.Draws != 4 {
+		t.Errorf("2番目の候補手 = %+v", e)
+	}
+	if entries[2].Count != 1 {
+		t.Errorf("出現回数を省略した手の回数 = %d, want 1", entries[2].Count)
+	}
+
+	var buf bytes.Buffer
+	if err := bk.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	bk2, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bk2.Moves(board.New()); len(got) != 3 || got[1] != entries[1] {
+		t.Errorf("書き出して読み直した候補手 = %+v", got)
+	}
+
+	m, ok := bk.Pick(board.New(), rand.New(rand.NewSource(1)))
+	if !ok || !board.New().IsLegalMove(m) {
+		t.Errorf("Pick = %+v, %v", m, ok)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []string{
+		"7g7f 3c3d 0 0 1\n",
+		"sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1\n7g7x\n",
+		"sfen lnsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL b - 1\n7g7f none x\n",
+		"sfen xyz\n",
+	}
+	for _, tt := range tests {
+		if _, err := Read(strings.NewReader(tt)); !errors.Is(err, ErrInvalidBook) {
+			t.Errorf("Read(%q) = %v, want ErrInvalidBook", tt, err)
+		}
+	}
+}
+
+// 定跡にない局面や、合法でない手は候補にならない
+func TestMovesIllegal(t *testing.T) {
+	bk, err := Read(strings.NewReader(testBook + "1a1b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bk.Moves(board.New())); n != 3 {
+		t.Errorf("候補手が %d 手, want 3", n)
+	}
+	b := board.New()
+	b.MakeMove(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5})
+	if _, ok := bk.Pick(b, rand.New(rand.NewSource(1))); ok {
+		t.Error("定跡にない局面で候補手が選ばれました")
+	}
+}
+
+func TestBuildDir(t *testing.T) {
+	dir := t.TempDir()
+	moves := func(s ...string) []board.Move {
+		var ms []board.Move
+		for _, m := range s {
+			mv, err := usi.ParseMove(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ms = append(ms, mv)
+		}
+		return ms
+	}
+	records := []kif.Record{
+		{Moves: moves("7g7f", "3c3d", "2g2f"), End: "投了", Winner: piece.Sente},
+		{Moves: moves("7g7f", "8c8d"), End: "投了", Winner: piece.Gote},
+		{Moves: moves("7g7f", "3c3d"), End: "千日手", Winner: piece.None},
+		{Moves: moves("2g2f", "8c8d"), End: "投了", Winner: piece.Sente},
+	}
+	for i, rec := range records {
+		var buf bytes.Buffer
+		if err := kif.WriteRecord(&buf, &rec); err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(dir, string(rune('a'+i))+".kif")
+		if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "broken.kif"), []byte("1 ７七歩\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a record"), 0o644)
+
+	var skipped []string
+	bk, n, err := BuildDir(dir, BuildOptions{MaxPly: 2, MinCount: 2, OnSkip: func(name string, err error) {
+		skipped = append(skipped, filepath.Base(name))
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || len(skipped) != 1 {
+		t.Fatalf("読めた棋譜 = %d, 読めなかった棋譜 = %v", n, skipped)
+	}
+
+	// 初手は7六歩が3回（1勝1敗1分）、2六歩が1回で回数不足
+	entries := bk.Moves(board.New())
+	if len(entries) != 1 {
+		t.Fatalf("初手の候補手 = %+v", entries)
+	}
+	e := entries[0]
+	if usi.FormatMove(e.Move) != "7g7f" || e.Count != 3 || e.Wins != 1 || e.Draws != 1 || e.Score != 0 {
+		t.Errorf("7六歩 = %+v", e)
+	}
+
+	// 2手目の3四歩は2回（後手から見て0勝1敗1分）
+	b := board.New()
+	b.MakeMove(e.Move)
+	entries = bk.Moves(b)
+	if len(entries) != 1 || entries[0].Count != 2 || entries[0].Wins != 0 || entries[0].Score >= 0 {
+		t.Errorf("2手目の候補手 = %+v", entries)
+	}
+
+	// MaxPly より先の手は入らない
+	b.MakeMove(entries[0].Move)
+	if got := bk.Moves(b); len(got) != 0 {
+		t.Errorf("3手目の候補手 = %+v", got)
+	}
+}
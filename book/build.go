@@ -0,0 +1,135 @@
+package book
+
+import (
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"shogi/board"
+	"shogi/csa"
+	"shogi/kif"
+	"shogi/piece"
+)
+
+// 棋譜から定跡を作るときの設定
+type BuildOptions struct {
+	MaxPly   int                          // 何手目までを定跡にするか（0なら30）
+	MinCount int                          // これより少ない回数しか指されていない手は除く（0なら1）
+	OnSkip   func(name string, err error) // 読めなかった棋譜ファイルの通知（nil可）
+}
+
+// 棋譜1局を定跡に加える（winner は勝った側、引き分けなら None）
+// 局面ごとに指された手の回数と、その手を指した側の勝ち数・引き分け数を数える
+func (bk *Book) AddRecord(initial *board.Board, moves []board.Move, winner piece.Player, maxPly int) {
+	if initial == nil {
+		initial = board.New()
+	}
+	b := initial.Clone()
+	for i, m := range moves {
+		if i >= maxPly || !b.IsLegalMove(m) {
+			break
+		}
+		e := Entry{Move: m, Count: 1}
+		switch winner {
+		case b.CurrentPlayer:
+			e.Wins = 1
+		case piece.None:
+			e.Draws = 1
+		}
+		if i+1 < len(moves) {
+			e.Ponder, e.HasPonder = moves[i+1], true
+		}
+		bk.Add(b, i+1, e)
+		b.MakeMove(m)
+	}
+}
+
+// ディレクトリ以下の棋譜ファイル（.kif、.kifu、.csa）から定跡を作る
+// 読めた棋譜の数も返す
+func BuildDir(dir string, opts BuildOptions) (*Book, int, error) {
+	if opts.MaxPly <= 0 {
+		opts.MaxPly = 30
+	}
+	if opts.MinCount <= 0 {
+		opts.MinCount = 1
+	}
+
+	bk := New()
+	records := 0
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		initial, moves, winner, ok, err := readRecordFile(name)
+		if err != nil {
+			if opts.OnSkip != nil {
+				opts.OnSkip(name, err)
+			}
+			return nil
+		}
+		if ok {
+			bk.AddRecord(initial, moves, winner, opts.MaxPly)
+			records++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	bk.finish(opts.MinCount)
+	return bk, records, nil
+}
+
+// 棋譜ファイルを読み込む（棋譜の拡張子でなければ ok は false）
+func readRecordFile(name string) (*board.Board, []board.Move, piece.Player, bool, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".kif" && ext != ".kifu" && ext != ".csa" {
+		return nil, nil, piece.None, false, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, piece.None, false, err
+	}
+	defer f.Close()
+
+	if ext == ".csa" {
+		rec, err := csa.ReadRecord(f)
+		if err != nil {
+			return nil, nil, piece.None, false, err
+		}
+		return rec.Initial, rec.Moves, rec.Winner(), true, nil
+	}
+	rec, err := kif.ReadRecord(f)
+	if err != nil {
+		return nil, nil, piece.None, false, err
+	}
+	return rec.Initial, rec.Moves, rec.Winner, true, nil
+}
+
+// 指された回数の少ない手を除き、勝率から評価値を付ける
+func (bk *Book) finish(minCount int) {
+	for key, pos := range bk.positions {
+		var entries []Entry
+		for _, e := range pos.entries {
+			if e.Count >= minCount {
+				e.Score = winRateScore(e.WinRate())
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			delete(bk.positions, key)
+			continue
+		}
+		pos.entries = entries
+	}
+}
+
+// 勝率を評価値に換算する（勝率50%で0、評価値600ごとに勝ちやすさが e 倍）
+func winRateScore(rate float64) int {
+	rate = math.Min(math.Max(rate, 0.01), 0.99)
+	return int(math.Round(600 * math.Log(rate/(1-rate))))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"shogi/book"
+)
+
+// 棋譜から定跡を作る
+// ディレクトリ以下の棋譜（KIF・CSA）を読み、局面ごとの指し手の回数と勝率を
+// やねうら王の定跡形式で書き出します。
+func main() {
+	dir := flag.String("dir", "records", "棋譜のディレクトリ")
+	out := flag.String("out", "book.db", "書き出す定跡ファイル")
+	maxPly := flag.Int("max-ply", 30, "何手目までを定跡にするか")
+	minCount := flag.Int("min-count", 2, "これより少ない回数しか指されていない手は除く")
+	flag.Parse()
+
+	bk, records, err := book.BuildDir(*dir, book.BuildOptions{
+		MaxPly:   *maxPly,
+		MinCount: *minCount,
+		OnSkip: func(name string, err error) {
+			log.Printf("読み込めません %s: %v", name, err)
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := bk.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d局の棋譜から%d局面の定跡を作りました: %s", records, bk.Len(), *out)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"shogi/csa"
+)
+
+// CSAプロトコルの対局サーバー
+// ログインした順に2人ずつ組み合わせて対局させ、終局した棋譜をCSA形式で保存します。
+func main() {
+	addr := flag.String("addr", ":4081", "待ち受けるアドレス")
+	total := flag.Duration("total", 10*time.Minute, "持ち時間")
+	byoyomi := flag.Duration("byoyomi", 10*time.Second, "秒読み")
+	increment := flag.Duration("increment", 0, "1手ごとの加算時間（フィッシャールール）")
+	leastTime := flag.Duration("least-time", 0, "1手の最低消費時間")
+	maxMoves := flag.Int("max-moves", 256, "最大手数（0なら無制限）")
+	records := flag.String("records", "records", "棋譜の保存先ディレクトリ")
+	agreeWait := flag.Duration("agree-wait", time.Minute, "対局条件の承諾を待つ時間")
+	flag.Parse()
+
+	server := csa.NewServer(csa.ServerConfig{
+		Time: csa.TimeControl{
+			Unit:      time.Second,
+			Total:     *total,
+			Byoyomi:   *byoyomi,
+			Increment: *increment,
+			LeastTime: *leastTime,
+		},
+		MaxMoves:  *maxMoves,
+		AgreeWait: *agreeWait,
+		RecordDir: *records,
+		OnGameEnd: func(rec *csa.Record) {
+			log.Printf("終局 %s: %s", rec.Event, rec.Comment)
+		},
+	})
+
+	log.Println("CSAサーバーを起動しました:", *addr)
+	if err := server.ListenAndServe(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
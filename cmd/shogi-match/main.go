@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"shogi/book"
+	"shogi/match"
+	"shogi/piece"
+)
+
+// エンジン同士の連続対局
+// 2つのエンジン（USIエンジンまたは内蔵の対局者）を先後入れ替えながら対局させ、
+// 勝敗・レーティング差・SPRTの結果を表示します。
+func main() {
+	engine1 := flag.String("engine1", "builtin:search", "対局者1（USIエンジンのパス、builtin:random、builtin:search[:深さ]）")
+	engine2 := flag.String("engine2", "builtin:random", "対局者2")
+	games := flag.Int("games", 100, "対局数")
+	concurrency := flag.Int("concurrency", 1, "同時に指す対局数")
+	openings := flag.String("openings", "", "開始局面のファイル（1行に1局面、SFENまたは startpos moves ...）")
+	total := flag.Duration("total", time.Minute, "持ち時間")
+	byoyomi := flag.Duration("byoyomi", 0, "秒読み")
+	inc := flag.Duration("inc", time.Second, "1手ごとの加算時間")
+	margin := flag.Duration("margin", time.Second, "時間切れ判定の猶予")
+	maxMoves := flag.Int("max-moves", 320, "最大手数（0なら無制限）")
+	resignScore := flag.Int("resign-score", 0, "この評価値以下が続いたら投了とみなす（0なら判定しない）")
+	resignMoves := flag.Int("resign-moves", 3, "投了とみなすまでの手数")
+	out := flag.String("out", "", "棋譜（KIF・CSA）の保存先ディレクトリ")
+	elo0 := flag.Float64("elo0", 0, "SPRTの帰無仮説のレーティング差")
+	elo1 := flag.Float64("elo1", 0, "SPRTの対立仮説のレーティング差（elo0と同じならSPRTを行わない）")
+	alpha := flag.Float64("alpha", 0.05, "SPRTの第1種の誤り率")
+	beta := flag.Float64("beta", 0.05, "SPRTの第2種の誤り率")
+	bookFile := flag.String("book", "", "双方が使う定跡ファイル（やねうら王の定跡形式）")
+	flag.Parse()
+
+	t := &match.Tournament{
+		Config: match.Config{
+			Total:       *total,
+			Byoyomi:     *byoyomi,
+			Increment:   *inc,
+			TimeMargin:  *margin,
+			MaxMoves:    *maxMoves,
+			ResignScore: *resignScore,
+			ResignMoves: *resignMoves,
+		},
+		PlayerA:     match.NewPlayerFactory(*engine1),
+		PlayerB:     match.NewPlayerFactory(*engine2),
+		Games:       *games,
+		Concurrency: *concurrency,
+		OutDir:      *out,
+	}
+
+	if *bookFile != "" {
+		bk, err := book.Load(*bookFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.PlayerA = match.WithBook(t.PlayerA, bk)
+		t.PlayerB = match.WithBook(t.PlayerB, bk)
+	}
+
+	if *openings != "" {
+		f, err := os.Open(*openings)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t.Openings, err = match.ReadOpenings(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var sprt *match.SPRT
+	if *elo0 != *elo1 {
+		sprt = &match.SPRT{Elo0: *elo0, Elo1: *elo1, Alpha: *alpha, Beta: *beta}
+		t.SPRT = sprt
+	}
+
+	t.OnGame = func(n int, res *match.GameResult, aIsSente bool, stats match.Stats) {
+		line := fmt.Sprintf("第%d局 %s vs %s: %s（%s, %d手） 通算 %s",
+			n+1, res.Names[piece.Sente], res.Names[piece.Gote], outcomeText(res.Outcome), res.Reason, len(res.Moves), stats)
+		if res.Err != nil {
+			line += " エラー: " + res.Err.Error()
+		}
+		if sprt != nil {
+			line += fmt.Sprintf(" LLR %.2f", sprt.LLR(stats))
+		}
+		fmt.Println(line)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := t.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("結果:", stats)
+	if sprt != nil {
+		lower, upper := sprt.Bounds()
+		fmt.Printf("SPRT [%.1f, %.1f]: LLR %.2f (%.2f, %.2f) %s\n",
+			sprt.Elo0, sprt.Elo1, sprt.LLR(stats), lower, upper, sprt.Test(stats))
+	}
+}
+
+// 対局結果の表記
+func outcomeText(o match.Outcome) string {
+	switch o {
+	case match.SenteWin:
+		return "先手勝ち"
+	case match.GoteWin:
+		return "後手勝ち"
+	default:
+		return "引き分け"
+	}
+}
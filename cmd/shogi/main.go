@@ -1,8 +1,18 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"os"
+	"path/filepath"
+	"shogi/board"
+	"shogi/book"
 	"shogi/game"
+	"shogi/match"
+	"shogi/network"
+	"shogi/piece"
+	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
@@ -58,6 +68,32 @@ func initFont() (font.Face, font.Face) {
 }
 
 func main() {
+	host := flag.String("host", "", "ネットワーク対局を主催するアドレス（例: :9000）")
+	join := flag.String("join", "", "ネットワーク対局に参加する接続先（例: 192.168.0.2:9000）")
+	kifu := flag.String("kifu", "", "再生する棋譜ファイル（KIF形式またはCSA形式）")
+	variantName := flag.String("variant", "standard", "将棋の種類（standard, minishogi, judkins, dobutsu）または定義ファイル（.json）")
+	bookFile := flag.String("book", "", "定跡ファイル（やねうら王の定跡形式）。定跡手をヒントとして表示する")
+	edit := flag.Bool("edit", false, "局面編集から始める（対局中も E キーで編集できる）")
+	flip := flag.Bool("flip", false, "盤を反転して後手を手前に表示する（F キーでも切り替えられる）")
+	engineSpec := flag.String("engine", "", "対局するエンジン（builtin:random、builtin:search[:深さ]、またはUSIエンジンの実行ファイル）")
+	human := flag.String("human", "sente", "エンジンと対局するときの自分の手番（sente または gote）")
+	engineTime := flag.Duration("engine-time", 3*time.Second, "エンジンが1手に使う時間")
+	assetsDir := flag.String("assets", "assets", "アセット（テーマ・駒と盤の画像・フォント・効果音）のディレクトリ")
+	themeName := flag.String("theme", "", "表示のテーマ（classic, simple, dark または assets/themes/ のテーマ）。T キーでも切り替えられ、設定に保存される")
+	flag.Parse()
+
+	variant := board.VariantByName(*variantName)
+	if variant == nil {
+		if !strings.EqualFold(filepath.Ext(*variantName), ".json") {
+			log.Fatal("不明な将棋の種類です: ", *variantName)
+		}
+		v, err := board.LoadVariantFile(*variantName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		variant = v
+	}
+
 	// ウィンドウ設定
 	ebiten.SetWindowSize(game.ScreenWidth, game.ScreenHeight)
 	ebiten.SetWindowTitle("将棋")
@@ -66,6 +102,120 @@ func main() {
 	normalFont, largeFont := initFont()
 	g := game.NewGame(normalFont, largeFont)
 
+	// テーマと設定の読み込み
+	if err := g.SetAssets(*assetsDir); err != nil {
+		log.Fatal(err)
+	}
+	settings, err := game.LoadSettings()
+	if err != nil {
+		log.Println("設定を読み込めません:", err)
+	}
+	if err := g.ApplySettings(settings); err != nil {
+		log.Println("テーマを読み込めません:", err)
+	}
+	if *themeName != "" {
+		if err := g.SetTheme(*themeName); err != nil {
+			log.Fatal(err)
+		}
+		if err := g.SaveSettings(); err != nil {
+			log.Println("設定を保存できません:", err)
+		}
+	}
+
+	// ネットワーク対局の設定
+	switch {
+	case *host != "" && *join != "":
+		log.Fatal("-host と -join は同時に指定できません")
+	case *kifu != "" && (*host != "" || *join != ""):
+		log.Fatal("-kifu はネットワーク対局と同時に指定できません")
+	case variant != board.Standard && (*host != "" || *join != "" || *kifu != ""):
+		log.Fatal("-variant はネットワーク対局や棋譜の再生と同時に指定できません")
+	case *edit && (*host != "" || *join != ""):
+		log.Fatal("-edit はネットワーク対局と同時に指定できません")
+	case *engineSpec != "" && (*host != "" || *join != "" || *kifu != "" || *edit || variant != board.Standard):
+		log.Fatal("-engine はネットワーク対局・棋譜の再生・局面編集・-variant と同時に指定できません")
+	case *engineSpec != "":
+		side := piece.Sente
+		switch *human {
+		case "sente":
+		case "gote":
+			side = piece.Gote
+		default:
+			log.Fatal("-human には sente か gote を指定してください")
+		}
+		opponent, err := match.NewOpponent(match.NewPlayerFactory(*engineSpec), side, match.Clock{Byoyomi: *engineTime})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer opponent.Close()
+		g.SetRemote(opponent)
+		g.SetPlayerName(side.Opposite(), opponent.Name())
+		ebiten.SetWindowTitle("将棋（エンジンと対局）")
+	case *host != "":
+		server, err := network.Host(*host)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer server.Close()
+		log.Println("対局を待ち受けています:", server.Addr())
+		g.SetRemote(server)
+		ebiten.SetWindowTitle("将棋（先手・主催）")
+	case *join != "":
+		client, err := network.Join(*join)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer client.Close()
+		g.SetRemote(client)
+		ebiten.SetWindowTitle("将棋（後手・参加）")
+	}
+
+	// 将棋の種類
+	if variant != board.Standard {
+		g.SetVariant(variant)
+		ebiten.SetWindowTitle(variant.Title)
+	}
+
+	// 棋譜の読み込み
+	if *kifu != "" {
+		f, err := os.Open(*kifu)
+		if err != nil {
+			log.Fatal(err)
+		}
+		start, moves, err := game.ReadRecordFile(*kifu, f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.LoadRecord(start, moves)
+	}
+
+	// 定跡の読み込み
+	if *bookFile != "" {
+		bk, err := book.Load(*bookFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.SetBook(bk)
+	}
+
+	// 盤の向き（ネットワーク対局・エンジンとの対局では自分の側が手前）
+	if *flip {
+		g.SetPerspective(piece.Gote)
+	}
+
+	// 局面編集
+	if *edit {
+		g.StartEditor()
+	}
+
+	// 自動保存（ネットワーク対局・エンジンとの対局・棋譜の再生・局面編集から始めるときは使わない）
+	if *host == "" && *join == "" && *engineSpec == "" && *kifu == "" && !*edit {
+		if err := g.EnableAutosave(); err != nil {
+			log.Println("自動保存を使えません:", err)
+		}
+	}
+
 	// ゲーム開始
 	if err := ebiten.RunGame(g); err != nil {
 		log.Fatal(err)
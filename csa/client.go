@@ -0,0 +1,248 @@
+package csa
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+var (
+	ErrLoginFailed = errors.New("csa: ログインに失敗しました")
+	ErrRejected    = errors.New("csa: 対局が拒否されました")
+	ErrNoGame      = errors.New("csa: 対局が開始されていません")
+)
+
+// 対局結果
+type Result int
+
+const (
+	ResultNone     Result = iota // 対局中
+	ResultWin                    // 勝ち
+	ResultLose                   // 負け
+	ResultDraw                   // 引き分け
+	ResultCensored               // 打ち切り
+	ResultChudan                 // 中断
+)
+
+// 結果を表す行（#WIN など）
+var resultLines = map[string]Result{
+	"#WIN":      ResultWin,
+	"#LOSE":     ResultLose,
+	"#DRAW":     ResultDraw,
+	"#CENSORED": ResultCensored,
+	"#CHUDAN":   ResultChudan,
+}
+
+// サーバーから届いた出来事（指し手または終局）
+type Event struct {
+	Move    board.Move
+	Player  piece.Player  // 指した側
+	Elapsed time.Duration // 消費時間
+	Reason  string        // 終局理由（SENNICHITE、RESIGN、TIME_UP など）
+	Result  Result        // 終局していれば結果
+}
+
+// 終局を表すか
+func (e *Event) IsGameEnd() bool {
+	return e.Result != ResultNone
+}
+
+// CSAプロトコルのクライアント
+type Client struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	summary *GameSummary
+	board   *board.Board
+	moves   []board.Move
+}
+
+// サーバーに接続
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// 既存の接続からクライアントを作成
+func NewClient(conn net.Conn) *Client {
+	return &Client{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}
+}
+
+// ログイン
+func (c *Client) Login(name, password string) error {
+	if err := c.send("LOGIN " + name + " " + password); err != nil {
+		return err
+	}
+	line, err := readLine(c.r)
+	if err != nil {
+		return err
+	}
+	if line != "LOGIN:"+name+" OK" {
+		return fmt.Errorf("%w: %s", ErrLoginFailed, line)
+	}
+	return nil
+}
+
+// 対局条件が届くまで待つ
+func (c *Client) ReadGameSummary() (*GameSummary, error) {
+	for {
+		line, err := readLine(c.r)
+		if err != nil {
+			return nil, err
+		}
+		if line != "BEGIN Game_Summary" {
+			continue
+		}
+
+		gs, err := readGameSummary(c.r)
+		if err != nil {
+			return nil, err
+		}
+		b, moves, err := ParsePosition(gs.position, gs.Time.Unit)
+		if err != nil {
+			return nil, err
+		}
+		c.summary = gs
+		c.board = b
+		c.moves = moves
+		return gs, nil
+	}
+}
+
+// 対局条件を承諾し、対局開始（START）を待つ
+func (c *Client) Agree() error {
+	if c.summary == nil {
+		return ErrNoGame
+	}
+	if err := c.send("AGREE " + c.summary.GameID); err != nil {
+		return err
+	}
+	for {
+		line, err := readLine(c.r)
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "START:"):
+			return nil
+		case strings.HasPrefix(line, "REJECT:"):
+			return fmt.Errorf("%w: %s", ErrRejected, line)
+		}
+	}
+}
+
+// 対局条件を拒否
+func (c *Client) Reject() error {
+	if c.summary == nil {
+		return ErrNoGame
+	}
+	return c.send("REJECT " + c.summary.GameID)
+}
+
+// 現在の局面（サーバーから届いた指し手まで反映済み）
+func (c *Client) Board() *board.Board {
+	return c.board
+}
+
+// 開始局面からの指し手
+func (c *Client) Moves() []board.Move {
+	return c.moves
+}
+
+// 指し手を送信。盤面への反映はサーバーからの確認（Receive）で行う
+func (c *Client) SendMove(m board.Move) error {
+	if c.board == nil {
+		return ErrNoGame
+	}
+	if c.board.CurrentPlayer != c.summary.YourTurn {
+		return ErrWrongPlayer
+	}
+	if err := c.board.CheckMove(m); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMove, err)
+	}
+	return c.send(FormatMove(c.board, m))
+}
+
+// 投了
+func (c *Client) Resign() error {
+	return c.send("%TORYO")
+}
+
+// 入玉宣言
+func (c *Client) DeclareWin() error {
+	return c.send("%KACHI")
+}
+
+// ログアウト
+func (c *Client) Logout() error {
+	return c.send("LOGOUT")
+}
+
+// 接続を閉じる
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// 次の指し手または終局を受け取る
+// 指し手は盤面で検証してから適用する
+func (c *Client) Receive() (*Event, error) {
+	if c.board == nil {
+		return nil, ErrNoGame
+	}
+
+	reason := ""
+	for {
+		line, err := readLine(c.r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case line == "":
+			// キープアライブ
+		case line[0] == '+' || line[0] == '-':
+			player := c.board.CurrentPlayer
+			m, elapsed, err := ParseMove(c.board, line, c.summary.Time.Unit)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.board.CheckMove(m); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMove, line, err)
+			}
+			c.board.MakeMove(m)
+			c.moves = append(c.moves, m)
+			return &Event{Move: m, Player: player, Elapsed: elapsed}, nil
+		case line[0] == '#':
+			if result, ok := resultLines[line]; ok {
+				return &Event{Reason: reason, Result: result}, nil
+			}
+			reason = line[1:]
+		}
+	}
+}
+
+// 1行送信
+func (c *Client) send(line string) error {
+	_, err := fmt.Fprintf(c.conn, "%s\n", line)
+	return err
+}
+
+// 1行読み取り（改行を除く）
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,281 @@
+package csa
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+const testSummary = `BEGIN Game_Summary
+Protocol_Version:1.2
+Protocol_Mode:Server
+Format:Shogi 1.0
+Game_ID:test-game-1
+Name+:alice
+Name-:bob
+Your_Turn:-
+To_Move:+
+Max_Moves:256
+BEGIN Time
+Time_Unit:1sec
+Total_Time:600
+Byoyomi:10
+END Time
+BEGIN Position
+P1-KY-KE-GI-KI-OU-KI-GI-KE-KY
+P2 * -HI *  *  *  *  * -KA *
+P3-FU-FU-FU-FU-FU-FU-FU-FU-FU
+P4 *  *  *  *  *  *  *  *  *
+P5 *  *  *  *  *  *  *  *  *
+P6 *  *  *  *  *  *  *  *  *
+P7+FU+FU+FU+FU+FU+FU+FU+FU+FU
+P8 * +KA *  *  *  *  * +HI *
+P9+KY+KE+GI+KI+OU+KI+GI+KE+KY
+P+
+P-
++
++2726FU,T12
+END Position
+END Game_Summary
+`
+
+// テスト用の簡易CSAサーバー。script の各行を順に処理し、結果を done に送る
+// script の行が "<" で始まればその内容の受信を待ち、それ以外はそのまま送信する
+func standInServer(t *testing.T, script []string) (string, <-chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		for _, step := range script {
+			if want, ok := strings.CutPrefix(step, "<"); ok {
+				got, err := readLine(r)
+				if err != nil {
+					done <- err
+					return
+				}
+				if got != want {
+					done <- &unexpectedLine{got: got, want: want}
+					return
+				}
+				continue
+			}
+			if _, err := conn.Write([]byte(step + "\n")); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	return l.Addr().String(), done
+}
+
+type unexpectedLine struct{ got, want string }
+
+func (e *unexpectedLine) Error() string {
+	return "got " + e.got + ", want " + e.want
+}
+
+func TestClientGame(t *testing.T) {
+	script := []string{
+		"<LOGIN bob secret",
+		"LOGIN:bob OK",
+		strings.TrimSuffix(testSummary, "\n"),
+		"<AGREE test-game-1",
+		"START:test-game-1",
+		"<-8384FU",
+		"-8384FU,T3",
+		"+7776FU,T5",
+		"<-8485FU",
+		"-8485FU,T2",
+		"%TORYO,T1",
+		"#RESIGN",
+		"#WIN",
+	}
+	addr, done := standInServer(t, script)
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Login("bob", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	gs, err := c.ReadGameSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs.GameID != "test-game-1" || gs.YourTurn != piece.Gote || gs.Names[piece.Sente] != "alice" {
+		t.Fatalf("summary = %+v", gs)
+	}
+	if gs.Time.Total != 600*time.Second || gs.Time.Byoyomi != 10*time.Second {
+		t.Fatalf("time = %+v", gs.Time)
+	}
+	if len(gs.Moves) != 1 || c.Board().CurrentPlayer != piece.Gote {
+		t.Fatalf("resumed moves = %v", gs.Moves)
+	}
+	if err := c.Agree(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 自分の手はサーバーからのエコーで反映される
+	if err := c.SendMove(board.Move{FromX: 1, FromY: 2, ToX: 1, ToY: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendMove(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}); !errors.Is(err, ErrInvalidMove) ||
+		!errors.Is(err, board.ErrNoPiece) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrNoPiece)", err)
+	}
+	ev, err := c.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := board.Move{FromX: 1, FromY: 2, ToX: 1, ToY: 3}
+	if ev.Move != want || ev.Player != piece.Gote || ev.Elapsed != 3*time.Second {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	// 相手の手
+	ev, err = c.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Move != (board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}) || ev.Player != piece.Sente {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	if err := c.SendMove(board.Move{FromX: 1, FromY: 3, ToX: 1, ToY: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Receive(); err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err = c.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Result != ResultWin || ev.Reason != "RESIGN" {
+		t.Fatalf("event = %+v", ev)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientSennichite(t *testing.T) {
+	script := []string{
+		"<LOGIN bob secret",
+		"LOGIN:bob OK",
+		strings.TrimSuffix(testSummary, "\n"),
+		"<AGREE test-game-1",
+		"START:test-game-1",
+		"#SENNICHITE",
+		"#DRAW",
+	}
+	addr, done := standInServer(t, script)
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Login("bob", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadGameSummary(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Agree(); err != nil {
+		t.Fatal(err)
+	}
+	ev, err := c.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ev.IsGameEnd() || ev.Result != ResultDraw || ev.Reason != "SENNICHITE" {
+		t.Fatalf("event = %+v", ev)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientLoginFailed(t *testing.T) {
+	addr, done := standInServer(t, []string{"<LOGIN bob wrong", "LOGIN:incorrect"})
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Login("bob", "wrong"); err == nil {
+		t.Fatal("login succeeded")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientRejectsIllegalMove(t *testing.T) {
+	// 再開局面（２六歩の後）から、先手が後手の角の利きに玉を入れる
+	script := []string{
+		"<LOGIN bob secret",
+		"LOGIN:bob OK",
+		strings.TrimSuffix(testSummary, "\n"),
+		"<AGREE test-game-1",
+		"START:test-game-1",
+		"-3334FU,T1",
+		"+7776FU,T1",
+		"-1314FU,T1",
+		"+5968OU,T1",
+		"-9394FU,T1",
+		"+6877OU,T1",
+	}
+	addr, done := standInServer(t, script)
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Login("bob", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadGameSummary(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Agree(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.Receive(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Receive(); !errors.Is(err, ErrInvalidMove) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrLeavesKingInCheck)", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,170 @@
+package csa
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+var (
+	ErrInvalidMove   = errors.New("csa: 不正な指し手です")
+	ErrWrongPlayer   = errors.New("csa: 手番が違います")
+	ErrPieceMismatch = errors.New("csa: 移動元の駒が一致しません")
+)
+
+// CSA形式の駒の表記
+var pieceCodes = map[piece.Type]string{
+	piece.Pawn:       "FU",
+	piece.Lance:      "KY",
+	piece.Knight:     "KE",
+	piece.Silver:     "GI",
+	piece.Gold:       "KI",
+	piece.Bishop:     "KA",
+	piece.Rook:       "HI",
+	piece.King:       "OU",
+	piece.PromPawn:   "TO",
+	piece.PromLance:  "NY",
+	piece.PromKnight: "NK",
+	piece.PromSilver: "NG",
+	piece.PromBishop: "UM",
+	piece.PromRook:   "RY",
+}
+
+// 駒の種類をCSA形式の表記に変換
+func PieceCode(t piece.Type) string {
+	return pieceCodes[t]
+}
+
+// CSA形式の表記を駒の種類に変換
+func ParsePieceCode(code string) (piece.Type, bool) {
+	for t, c := range pieceCodes {
+		if c == code {
+			return t, true
+		}
+	}
+	return piece.Empty, false
+}
+
+// 手番の記号（+ または -）
+func PlayerSign(p piece.Player) string {
+	if p == piece.Gote {
+		return "-"
+	}
+	return "+"
+}
+
+func parsePlayerSign(c byte) (piece.Player, bool) {
+	switch c {
+	case '+':
+		return piece.Sente, true
+	case '-':
+		return piece.Gote, true
+	}
+	return piece.None, false
+}
+
+// 筋・段（1〜9）を盤の座標に変換
+func squareToXY(file, rank int) (int, int) {
+	return board.BoardSize - file, rank - 1
+}
+
+// 盤の座標を筋・段（1〜9）に変換
+func xyToSquare(x, y int) (int, int) {
+	return board.BoardSize - x, y + 1
+}
+
+// 指し手をCSA形式（例: +7776FU、+0055KA）に変換
+// 成りの判定のため、指す前の盤面を渡す
+func FormatMove(b *board.Board, m board.Move) string {
+	var sb strings.Builder
+	sb.WriteString(PlayerSign(b.CurrentPlayer))
+
+	pt := m.Piece
+	if m.FromX == -1 && m.FromY == -1 {
+		sb.WriteString("00")
+	} else {
+		file, rank := xyToSquare(m.FromX, m.FromY)
+		fmt.Fprintf(&sb, "%d%d", file, rank)
+		pt = b.GetPiece(m.FromX, m.FromY).Type
+		if m.Promote {
+			pt = pt.Promote()
+		}
+	}
+
+	file, rank := xyToSquare(m.ToX, m.ToY)
+	fmt.Fprintf(&sb, "%d%d%s", file, rank, PieceCode(pt))
+	return sb.String()
+}
+
+// CSA形式の指し手（例: +7776FU,T10）を盤面の指し手に変換
+// 消費時間（T）が付いていればその秒数も返す。単位は timeUnit 倍される
+func ParseMove(b *board.Board, s string, timeUnit time.Duration) (board.Move, time.Duration, error) {
+	var elapsed time.Duration
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		t := s[i+1:]
+		s = s[:i]
+		if !strings.HasPrefix(t, "T") {
+			return board.Move{}, 0, ErrInvalidMove
+		}
+		n, err := strconv.Atoi(t[1:])
+		if err != nil {
+			return board.Move{}, 0, ErrInvalidMove
+		}
+		elapsed = time.Duration(n) * timeUnit
+	}
+
+	if len(s) != 7 {
+		return board.Move{}, 0, ErrInvalidMove
+	}
+	player, ok := parsePlayerSign(s[0])
+	if !ok {
+		return board.Move{}, 0, ErrInvalidMove
+	}
+	if player != b.CurrentPlayer {
+		return board.Move{}, 0, ErrWrongPlayer
+	}
+
+	var digits [4]int
+	for i := 0; i < 4; i++ {
+		c := s[1+i]
+		if c < '0' || c > '9' {
+			return board.Move{}, 0, ErrInvalidMove
+		}
+		digits[i] = int(c - '0')
+	}
+	pt, ok := ParsePieceCode(s[5:])
+	if !ok {
+		return board.Move{}, 0, ErrInvalidMove
+	}
+	if digits[2] == 0 || digits[3] == 0 {
+		return board.Move{}, 0, ErrInvalidMove
+	}
+	toX, toY := squareToXY(digits[2], digits[3])
+
+	// 駒打ち
+	if digits[0] == 0 && digits[1] == 0 {
+		return board.Move{FromX: -1, FromY: -1, ToX: toX, ToY: toY, Piece: pt}, elapsed, nil
+	}
+	if digits[0] == 0 || digits[1] == 0 {
+		return board.Move{}, 0, ErrInvalidMove
+	}
+
+	fromX, fromY := squareToXY(digits[0], digits[1])
+	m := board.Move{FromX: fromX, FromY: fromY, ToX: toX, ToY: toY}
+
+	// 移動後の駒の種類から成りを判定
+	src := b.GetPiece(fromX, fromY)
+	switch {
+	case src.Type == pt:
+	case src.Type.CanPromote() && src.Type.Promote() == pt:
+		m.Promote = true
+	default:
+		return board.Move{}, 0, ErrPieceMismatch
+	}
+	return m, elapsed, nil
+}
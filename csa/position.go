@@ -0,0 +1,204 @@
+package csa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+var ErrInvalidPosition = errors.New("csa: 不正な局面です")
+
+// 持ち駒の表記順
+var handOrder = []piece.Type{
+	piece.Rook, piece.Bishop, piece.Gold, piece.Silver,
+	piece.Knight, piece.Lance, piece.Pawn,
+}
+
+// 局面の表記（PI、P1〜P9、P+/P-、手番行、続く指し手）を解析
+// 指し手は局面に適用した上で返す
+func ParsePosition(lines []string, timeUnit time.Duration) (*board.Board, []board.Move, error) {
+	b := board.NewEmpty()
+	var moves []board.Move
+	turnSet := false
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "" || strings.HasPrefix(line, "'"):
+			// 空行とコメントは無視
+		case strings.HasPrefix(line, "PI"):
+			b = board.New()
+			if err := removePieces(b, line[2:]); err != nil {
+				return nil, nil, err
+			}
+		case len(line) >= 2 && line[0] == 'P' && line[1] >= '1' && line[1] <= '9':
+			if err := parseRank(b, int(line[1]-'0'), line[2:]); err != nil {
+				return nil, nil, err
+			}
+		case strings.HasPrefix(line, "P+") || strings.HasPrefix(line, "P-"):
+			if err := parsePieces(b, line); err != nil {
+				return nil, nil, err
+			}
+		case (line == "+" || line == "-") && !turnSet:
+			b.CurrentPlayer, _ = parsePlayerSign(line[0])
+			turnSet = true
+		case line[0] == '+' || line[0] == '-':
+			m, _, err := ParseMove(b, line, timeUnit)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := b.CheckMove(m); err != nil {
+				return nil, nil, fmt.Errorf("%w: %s: %w", ErrInvalidMove, line, err)
+			}
+			b.MakeMove(m)
+			moves = append(moves, m)
+		}
+	}
+	return b, moves, nil
+}
+
+// PI に続く平手からの駒落ち指定（例: 82HI22KA）
+func removePieces(b *board.Board, s string) error {
+	for len(s) >= 4 {
+		file, rank := int(s[0]-'0'), int(s[1]-'0')
+		if file < 1 || file > 9 || rank < 1 || rank > 9 {
+			return ErrInvalidPosition
+		}
+		x, y := squareToXY(file, rank)
+		b.Grid[y][x] = piece.Piece{}
+		s = s[4:]
+	}
+	if s != "" {
+		return ErrInvalidPosition
+	}
+	return nil
+}
+
+// P1〜P9 の1段分（9マス×3文字）
+func parseRank(b *board.Board, rank int, s string) error {
+	// 行末の空白が削られていても読めるようにする
+	if n := board.BoardSize * 3; len(s) < n {
+		s += strings.Repeat(" ", n-len(s))
+	}
+	for file := 9; file >= 1; file-- {
+		if len(s) < 3 {
+			return ErrInvalidPosition
+		}
+		cell := s[:3]
+		s = s[3:]
+		if strings.TrimSpace(cell) == "*" {
+			continue
+		}
+		player, ok := parsePlayerSign(cell[0])
+		if !ok {
+			return ErrInvalidPosition
+		}
+		pt, ok := ParsePieceCode(cell[1:])
+		if !ok {
+			return ErrInvalidPosition
+		}
+		x, y := squareToXY(file, rank)
+		b.Grid[y][x] = piece.Piece{Type: pt, Player: player}
+	}
+	return nil
+}
+
+// P+/P- による駒の配置（00は持ち駒、00AL は残り全部を持ち駒に）
+func parsePieces(b *board.Board, line string) error {
+	player, _ := parsePlayerSign(line[1])
+	s := line[2:]
+	for len(s) >= 4 {
+		file, rank := int(s[0]-'0'), int(s[1]-'0')
+		code := s[2:4]
+		s = s[4:]
+
+		if file == 0 && rank == 0 {
+			if code == "AL" {
+				addRemainingToHand(b, player)
+				continue
+			}
+			pt, ok := ParsePieceCode(code)
+			if !ok || pt.IsPromoted() || pt == piece.King {
+				return ErrInvalidPosition
+			}
+			hand(b, player)[pt]++
+			continue
+		}
+
+		pt, ok := ParsePieceCode(code)
+		if !ok || file < 1 || file > 9 || rank < 1 || rank > 9 {
+			return ErrInvalidPosition
+		}
+		x, y := squareToXY(file, rank)
+		b.Grid[y][x] = piece.Piece{Type: pt, Player: player}
+	}
+	if s != "" {
+		return ErrInvalidPosition
+	}
+	return nil
+}
+
+// 盤上と持ち駒にない駒をすべて持ち駒に加える
+func addRemainingToHand(b *board.Board, player piece.Player) {
+	remaining := map[piece.Type]int{
+		piece.Pawn: 18, piece.Lance: 4, piece.Knight: 4, piece.Silver: 4,
+		piece.Gold: 4, piece.Bishop: 2, piece.Rook: 2,
+	}
+	for y := 0; y < board.BoardSize; y++ {
+		for x := 0; x < board.BoardSize; x++ {
+			if pt := b.Grid[y][x].Type; pt != piece.Empty {
+				remaining[pt.Unpromote()]--
+			}
+		}
+	}
+	for pt := range remaining {
+		remaining[pt] -= b.SenteCaptures[pt] + b.GoteCaptures[pt]
+	}
+	h := hand(b, player)
+	for pt, n := range remaining {
+		if n > 0 {
+			h[pt] += n
+		}
+	}
+}
+
+// プレイヤーの持ち駒
+func hand(b *board.Board, player piece.Player) map[piece.Type]int {
+	if player == piece.Gote {
+		return b.GoteCaptures
+	}
+	return b.SenteCaptures
+}
+
+// 局面をCSA形式（P1〜P9、P+/P-、手番行）で書き出す
+func FormatPosition(b *board.Board) string {
+	var sb strings.Builder
+	for y := 0; y < board.BoardSize; y++ {
+		fmt.Fprintf(&sb, "P%d", y+1)
+		for x := 0; x < board.BoardSize; x++ {
+			p := b.Grid[y][x]
+			if p.Type == piece.Empty {
+				sb.WriteString(" * ")
+				continue
+			}
+			sb.WriteString(PlayerSign(p.Player) + PieceCode(p.Type))
+		}
+		sb.WriteString("\n")
+	}
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		h := hand(b, player)
+		line := "P" + PlayerSign(player)
+		for _, pt := range handOrder {
+			for i := 0; i < h[pt]; i++ {
+				line += "00" + PieceCode(pt)
+			}
+		}
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(PlayerSign(b.CurrentPlayer) + "\n")
+	return sb.String()
+}
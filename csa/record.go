@@ -0,0 +1,222 @@
+package csa
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// CSA形式の棋譜
+type Record struct {
+	Names     map[piece.Player]string
+	Event     string
+	StartTime time.Time
+	EndTime   time.Time
+	Time      *TimeControl    // 持ち時間（なければ書き出さない）
+	Initial   *board.Board    // 開始局面
+	Moves     []board.Move    // 開始局面からの指し手
+	Times     []time.Duration // 各手の消費時間（Moves と同じ長さか空）
+	End       string          // 終局の特殊な手（%TORYO、%SENNICHITE など）
+	Comment   string          // 末尾に付けるコメント（結果など）
+}
+
+// 終局の特殊な手から勝った側を求める（引き分け・不明なら None）
+func (rec *Record) Winner() piece.Player {
+	initial := rec.Initial
+	if initial == nil {
+		initial = board.New()
+	}
+	// 終局の手を指した（宣言した）側
+	turn := initial.CurrentPlayer
+	if len(rec.Moves)%2 == 1 {
+		turn = turn.Opposite()
+	}
+
+	switch {
+	case strings.HasPrefix(rec.End, "%TORYO"), strings.HasPrefix(rec.End, "%TIME_UP"),
+		strings.HasPrefix(rec.End, "%ILLEGAL_MOVE"):
+		return turn.Opposite()
+	case strings.HasPrefix(rec.End, "%KACHI"):
+		return turn
+	case strings.HasPrefix(rec.End, "%+ILLEGAL_ACTION"):
+		return piece.Gote
+	case strings.HasPrefix(rec.End, "%-ILLEGAL_ACTION"):
+		return piece.Sente
+	}
+	return piece.None
+}
+
+// 棋譜をCSA形式（V2.2）で書き出す
+func WriteRecord(w io.Writer, rec *Record) error {
+	var sb strings.Builder
+	sb.WriteString("V2.2\n")
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		if name, ok := rec.Names[player]; ok {
+			fmt.Fprintf(&sb, "N%s%s\n", PlayerSign(player), name)
+		}
+	}
+	if rec.Event != "" {
+		fmt.Fprintf(&sb, "$EVENT:%s\n", rec.Event)
+	}
+	if !rec.StartTime.IsZero() {
+		fmt.Fprintf(&sb, "$START_TIME:%s\n", rec.StartTime.Format("2006/01/02 15:04:05"))
+	}
+	if !rec.EndTime.IsZero() {
+		fmt.Fprintf(&sb, "$END_TIME:%s\n", rec.EndTime.Format("2006/01/02 15:04:05"))
+	}
+	if rec.Time != nil {
+		total := rec.Time.Total
+		fmt.Fprintf(&sb, "$TIME_LIMIT:%02d:%02d+%02d\n",
+			int(total.Hours()), int(total.Minutes())%60, int(rec.Time.Byoyomi.Seconds()))
+	}
+
+	initial := rec.Initial
+	if initial == nil {
+		initial = board.New()
+	}
+	sb.WriteString(FormatPosition(initial))
+
+	b := initial.Clone()
+	for i, m := range rec.Moves {
+		sb.WriteString(FormatMove(b, m) + "\n")
+		if i < len(rec.Times) {
+			fmt.Fprintf(&sb, "T%d\n", int(rec.Times[i]/time.Second))
+		}
+		b.MakeMove(m)
+	}
+	if rec.End != "" {
+		sb.WriteString(rec.End + "\n")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(rec.Comment), "\n") {
+		if line != "" {
+			sb.WriteString("'" + line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// CSA形式の棋譜を読み込む
+func ReadRecord(r io.Reader) (*Record, error) {
+	rec := &Record{Names: map[piece.Player]string{}}
+	var position []string
+	var comments []string
+	var b *board.Board
+	hasTimes := false
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		for _, line := range splitStatements(strings.TrimRight(s.Text(), "\r")) {
+			switch {
+			case line == "" || strings.HasPrefix(line, "V"):
+				// 空行とバージョンは無視
+			case strings.HasPrefix(line, "'"):
+				comments = append(comments, line[1:])
+			case strings.HasPrefix(line, "N+"):
+				rec.Names[piece.Sente] = line[2:]
+			case strings.HasPrefix(line, "N-"):
+				rec.Names[piece.Gote] = line[2:]
+			case strings.HasPrefix(line, "$"):
+				readRecordInfo(rec, line)
+			case strings.HasPrefix(line, "%"):
+				rec.End = line
+			case strings.HasPrefix(line, "T"):
+				if n := len(rec.Times); n > 0 {
+					rec.Times[n-1] = parseTime(line[1:], time.Second)
+					hasTimes = true
+				}
+			case len(line) > 1 && (line[0] == '+' || line[0] == '-'):
+				if b == nil {
+					var err error
+					if b, err = parseInitial(position); err != nil {
+						return nil, err
+					}
+					rec.Initial = b.Clone()
+				}
+				m, _, err := ParseMove(b, line, time.Second)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", err, line)
+				}
+				if err := b.CheckMove(m); err != nil {
+					return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMove, line, err)
+				}
+				b.MakeMove(m)
+				rec.Moves = append(rec.Moves, m)
+				rec.Times = append(rec.Times, 0)
+			default:
+				position = append(position, line)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		initial, err := parseInitial(position)
+		if err != nil {
+			return nil, err
+		}
+		rec.Initial = initial
+	}
+	if !hasTimes {
+		rec.Times = nil
+	}
+	rec.Comment = strings.Join(comments, "\n")
+	return rec, nil
+}
+
+// 棋譜の1行をカンマ区切りの文に分ける（コメントと棋譜情報は分けない）
+func splitStatements(line string) []string {
+	if strings.HasPrefix(line, "'") || strings.HasPrefix(line, "$") || strings.HasPrefix(line, "N") {
+		return []string{line}
+	}
+	return strings.Split(line, ",")
+}
+
+// 開始局面の行を解析
+func parseInitial(lines []string) (*board.Board, error) {
+	hasBoard := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "P") {
+			hasBoard = true
+		}
+	}
+	if !hasBoard {
+		return nil, ErrInvalidPosition
+	}
+	b, _, err := ParsePosition(lines, time.Second)
+	return b, err
+}
+
+// 棋譜情報（$EVENT:... など）を解析
+func readRecordInfo(rec *Record, line string) {
+	key, value, ok := strings.Cut(line[1:], ":")
+	if !ok {
+		return
+	}
+	switch key {
+	case "EVENT":
+		rec.Event = value
+	case "START_TIME":
+		rec.StartTime = parseRecordTime(value)
+	case "END_TIME":
+		rec.EndTime = parseRecordTime(value)
+	}
+}
+
+// 日時（2006/01/02 15:04:05 または 2006/01/02）を解析
+func parseRecordTime(s string) time.Time {
+	for _, layout := range []string{"2006/01/02 15:04:05", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,29 @@
+package csa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"shogi/board"
+)
+
+// ５手目の７七玉は後手の角の利きに入る（自玉が取られる手）
+var kingInCheckMoves = []string{"+7776FU", "-3334FU", "+5968OU", "-1314FU", "+6877OU"}
+
+func TestReadRecordRejectsIllegalMove(t *testing.T) {
+	csa := "V2.2\nPI\n+\n" + strings.Join(kingInCheckMoves, "\n") + "\n"
+	_, err := ReadRecord(strings.NewReader(csa))
+	if !errors.Is(err, ErrInvalidMove) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrLeavesKingInCheck)", err)
+	}
+}
+
+func TestParsePositionRejectsIllegalMove(t *testing.T) {
+	lines := append([]string{"PI", "+"}, kingInCheckMoves...)
+	_, _, err := ParsePosition(lines, time.Second)
+	if !errors.Is(err, ErrInvalidMove) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrLeavesKingInCheck)", err)
+	}
+}
@@ -0,0 +1,534 @@
+package csa
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// サーバーの設定
+type ServerConfig struct {
+	Time      TimeControl
+	MaxMoves  int               // 最大手数（0なら無制限）
+	AgreeWait time.Duration     // 対局条件の承諾（AGREE）を待つ時間（0なら1分）
+	RecordDir string            // 棋譜の保存先（空なら保存しない）
+	OnGameEnd func(rec *Record) // 終局時に呼ばれる（nil可）
+	Logger    *log.Logger       // ログの出力先（nilなら標準のロガー）
+	timeSlack time.Duration     // 時間切れ判定の猶予
+}
+
+// 2人のクライアントを組み合わせて対局させるCSAサーバー
+type Server struct {
+	config  ServerConfig
+	mu      sync.Mutex
+	waiting *serverPlayer // 対局相手を待っているプレイヤー
+	seq     int
+}
+
+// ログイン済みのクライアント
+type serverPlayer struct {
+	name  string
+	conn  net.Conn
+	lines chan string   // 受信した行（切断されると閉じられる）
+	done  chan struct{} // 対局が終わると閉じられる（以降の受信は捨てる）
+}
+
+// サーバーを作成
+func NewServer(config ServerConfig) *Server {
+	if config.Time.Unit == 0 {
+		config.Time.Unit = time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+	if config.AgreeWait == 0 {
+		config.AgreeWait = time.Minute
+	}
+	if config.timeSlack == 0 {
+		config.timeSlack = time.Second
+	}
+	return &Server{config: config}
+}
+
+// 指定アドレスで待ち受ける
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// 接続を受け付ける（リスナーが閉じられるまで戻らない）
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// ログインを受け付けて対局待ちにする
+func (s *Server) handleConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	var p *serverPlayer
+	for p == nil {
+		line, err := readLine(r)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "LOGIN" {
+			if len(fields) > 0 && fields[0] == "LOGOUT" {
+				conn.Close()
+				return
+			}
+			fmt.Fprintln(conn, "LOGIN:incorrect")
+			continue
+		}
+		fmt.Fprintf(conn, "LOGIN:%s OK\n", fields[1])
+		p = &serverPlayer{name: fields[1], conn: conn, lines: make(chan string, 16), done: make(chan struct{})}
+	}
+
+	// 以降の受信は別のゴルーチンでチャネルに流す
+	go func() {
+		defer func() {
+			close(p.lines)
+			// 対局待ちのまま切断されたら待ち行列から外す
+			s.mu.Lock()
+			if s.waiting == p {
+				s.waiting = nil
+			}
+			s.mu.Unlock()
+		}()
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+			// 対局が終わって誰も読まなくなったら、チャネルが一杯でも止まらずに抜ける
+			select {
+			case p.lines <- line:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+
+	s.mu.Lock()
+	opponent := s.waiting
+	if opponent == nil {
+		s.waiting = p
+		s.mu.Unlock()
+		return
+	}
+	s.waiting = nil
+	s.seq++
+	id := fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), s.seq)
+	s.mu.Unlock()
+
+	// 先にログインした方を先手にする
+	g := &serverGame{
+		config:  &s.config,
+		id:      id,
+		players: map[piece.Player]*serverPlayer{piece.Sente: opponent, piece.Gote: p},
+	}
+	g.run()
+}
+
+// 1局分の状態
+type serverGame struct {
+	config    *ServerConfig
+	id        string
+	players   map[piece.Player]*serverPlayer
+	board     *board.Board
+	moves     []board.Move
+	times     []time.Duration
+	history   *board.History // 千日手の判定用
+	remaining map[piece.Player]time.Duration
+	start     time.Time
+}
+
+// 終局の情報
+type gameEnd struct {
+	reason string       // 終局理由（#RESIGN などの # を除いた部分）
+	loser  piece.Player // 負けた側（引き分けなら None）
+	draw   bool         // 引き分け
+	record string       // 棋譜に書く特殊な手
+}
+
+// 対局を進行
+func (g *serverGame) run() {
+	defer func() {
+		for _, p := range g.players {
+			close(p.done)
+			p.conn.Close()
+		}
+	}()
+
+	for player, p := range g.players {
+		g.send(p, g.summary(player))
+	}
+	if !g.waitAgree() {
+		return
+	}
+
+	g.start = time.Now()
+	g.board = board.New()
+	g.history = board.NewHistory(g.board)
+	g.remaining = map[piece.Player]time.Duration{
+		piece.Sente: g.config.Time.Total,
+		piece.Gote:  g.config.Time.Total,
+	}
+	g.broadcast("START:" + g.id)
+
+	end := g.play()
+	g.finish(end)
+}
+
+// 両者の AGREE を待つ（AgreeWait までに揃わなければ、承諾していない側が拒否したものとする）
+func (g *serverGame) waitAgree() bool {
+	timer := time.NewTimer(g.config.AgreeWait)
+	defer timer.Stop()
+
+	agreed := map[piece.Player]bool{}
+	for len(agreed) < 2 {
+		var player piece.Player
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-g.players[piece.Sente].lines:
+			player = piece.Sente
+		case line, ok = <-g.players[piece.Gote].lines:
+			player = piece.Gote
+		case <-timer.C:
+			player = piece.Sente
+			if agreed[piece.Sente] {
+				player = piece.Gote
+			}
+		}
+		p := g.players[player]
+		if !ok || strings.HasPrefix(line, "REJECT") {
+			g.broadcast(fmt.Sprintf("REJECT:%s by %s", g.id, p.name))
+			return false
+		}
+		if strings.HasPrefix(line, "AGREE") {
+			agreed[player] = true
+		}
+	}
+	return true
+}
+
+// 終局まで指し手を受け付ける
+func (g *serverGame) play() gameEnd {
+	for {
+		turn := g.board.CurrentPlayer
+		turnStart := time.Now()
+		limit := g.remaining[turn] + g.config.Time.Byoyomi
+
+		line, end, over := g.waitLine(turn, turnStart.Add(limit+g.config.timeSlack))
+		if over {
+			return end
+		}
+
+		// 消費時間（秒未満切り捨て、最低消費時間あり）
+		elapsed := time.Since(turnStart)
+		if elapsed > limit {
+			return gameEnd{reason: "TIME_UP", loser: turn, record: "%TIME_UP"}
+		}
+		spent := elapsed.Truncate(g.config.Time.Unit)
+		if spent < g.config.Time.LeastTime {
+			spent = g.config.Time.LeastTime
+		}
+
+		switch {
+		case strings.HasPrefix(line, "%TORYO"):
+			g.broadcast(fmt.Sprintf("%%TORYO,T%d", g.timeUnits(spent)))
+			return gameEnd{reason: "RESIGN", loser: turn, record: "%TORYO"}
+		case strings.HasPrefix(line, "%KACHI"):
+			if CanDeclareWin(g.board) {
+				g.broadcast("%KACHI")
+				return gameEnd{reason: "JISHOGI", loser: turn.Opposite(), record: "%KACHI"}
+			}
+			return gameEnd{reason: "ILLEGAL_MOVE", loser: turn, record: "%ILLEGAL_MOVE"}
+		case line[0] == '+' || line[0] == '-':
+			if end, over := g.applyMove(line, spent); over {
+				return end
+			}
+		default:
+			g.config.Logger.Printf("csa: %s からの不明な入力を無視しました: %s", g.players[turn].name, line)
+		}
+	}
+}
+
+// 手番側からの空でない行を期限まで待つ
+// 切断や時間切れで終局した場合は over が true
+func (g *serverGame) waitLine(turn piece.Player, deadline time.Time) (line string, end gameEnd, over bool) {
+	cur, other := g.players[turn], g.players[turn.Opposite()]
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		select {
+		case l, ok := <-cur.lines:
+			if !ok {
+				return "", gameEnd{reason: "ABNORMAL", loser: turn, record: "%CHUDAN"}, true
+			}
+			if l != "" {
+				return l, gameEnd{}, false
+			}
+		case l, ok := <-other.lines:
+			if !ok {
+				return "", gameEnd{reason: "ABNORMAL", loser: turn.Opposite(), record: "%CHUDAN"}, true
+			}
+			// 手番でない側からの行はキープアライブ以外無視する
+			if l != "" {
+				g.config.Logger.Printf("csa: %s の手番外の入力を無視しました: %s", other.name, l)
+			}
+		case <-timer.C:
+			return "", gameEnd{reason: "TIME_UP", loser: turn, record: "%TIME_UP"}, true
+		}
+	}
+}
+
+// 指し手を検証して適用。終局した場合は over が true
+func (g *serverGame) applyMove(line string, spent time.Duration) (end gameEnd, over bool) {
+	turn := g.board.CurrentPlayer
+	if i := strings.IndexByte(line, ','); i >= 0 {
+		line = line[:i]
+	}
+	m, _, err := ParseMove(g.board, line, g.config.Time.Unit)
+	if err != nil || !g.board.IsLegalMove(m) {
+		return gameEnd{reason: "ILLEGAL_MOVE", loser: turn, record: "%ILLEGAL_MOVE"}, true
+	}
+
+	// 持ち時間の更新
+	g.remaining[turn] -= spent
+	if g.remaining[turn] < 0 {
+		g.remaining[turn] = 0
+	}
+	g.remaining[turn] += g.config.Time.Increment
+
+	g.board.MakeMove(m)
+	g.moves = append(g.moves, m)
+	g.times = append(g.times, spent)
+	g.history.Push(g.board)
+	g.broadcast(fmt.Sprintf("%s,T%d", line, g.timeUnits(spent)))
+
+	// 千日手（同一局面4回）
+	switch rep, checker := g.history.Repetition(g.board); rep {
+	case board.RepetitionPerpetualCheck:
+		return gameEnd{reason: "OUTE_SENNICHITE", loser: checker, record: "%SENNICHITE"}, true
+	case board.RepetitionDraw:
+		return gameEnd{reason: "SENNICHITE", draw: true, record: "%SENNICHITE"}, true
+	}
+
+	if g.config.MaxMoves > 0 && len(g.moves) >= g.config.MaxMoves {
+		return gameEnd{reason: "MAX_MOVES", draw: true, record: "%HIKIWAKE"}, true
+	}
+	return gameEnd{}, false
+}
+
+// 終局を通知し、棋譜を保存
+func (g *serverGame) finish(end gameEnd) {
+	g.broadcast("#" + end.reason)
+	for player, p := range g.players {
+		switch {
+		case end.reason == "MAX_MOVES":
+			g.send(p, "#CENSORED")
+		case end.draw:
+			g.send(p, "#DRAW")
+		case player == end.loser:
+			g.send(p, "#LOSE")
+		default:
+			g.send(p, "#WIN")
+		}
+	}
+
+	rec := &Record{
+		Names: map[piece.Player]string{
+			piece.Sente: g.players[piece.Sente].name,
+			piece.Gote:  g.players[piece.Gote].name,
+		},
+		Event:     g.id,
+		StartTime: g.start,
+		EndTime:   time.Now(),
+		Time:      &g.config.Time,
+		Initial:   board.New(),
+		Moves:     g.moves,
+		Times:     g.times,
+		End:       end.record,
+		Comment:   g.resultSummary(end),
+	}
+	if g.config.RecordDir != "" {
+		if err := g.saveRecord(rec); err != nil {
+			g.config.Logger.Printf("csa: 棋譜を保存できません: %v", err)
+		}
+	}
+	if g.config.OnGameEnd != nil {
+		g.config.OnGameEnd(rec)
+	}
+}
+
+// 結果の要約（例: summary:RESIGN:alice lose:bob win）
+func (g *serverGame) resultSummary(end gameEnd) string {
+	result := func(player piece.Player) string {
+		switch {
+		case end.draw:
+			return "draw"
+		case player == end.loser:
+			return "lose"
+		default:
+			return "win"
+		}
+	}
+	return fmt.Sprintf("summary:%s:%s %s:%s %s", end.reason,
+		g.players[piece.Sente].name, result(piece.Sente),
+		g.players[piece.Gote].name, result(piece.Gote))
+}
+
+// 棋譜をファイルに保存
+func (g *serverGame) saveRecord(rec *Record) error {
+	if err := os.MkdirAll(g.config.RecordDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(g.config.RecordDir, g.id+".csa"))
+	if err != nil {
+		return err
+	}
+	if err := WriteRecord(f, rec); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// 各プレイヤーに送る Game_Summary
+func (g *serverGame) summary(yourTurn piece.Player) string {
+	tc := g.config.Time
+	units := func(d time.Duration) int { return int(d / tc.Unit) }
+
+	var sb strings.Builder
+	sb.WriteString("BEGIN Game_Summary\n")
+	sb.WriteString("Protocol_Version:1.2\n")
+	sb.WriteString("Protocol_Mode:Server\n")
+	sb.WriteString("Format:Shogi 1.0\n")
+	sb.WriteString("Declaration:Jishogi 1.1\n")
+	fmt.Fprintf(&sb, "Game_ID:%s\n", g.id)
+	fmt.Fprintf(&sb, "Name+:%s\n", g.players[piece.Sente].name)
+	fmt.Fprintf(&sb, "Name-:%s\n", g.players[piece.Gote].name)
+	fmt.Fprintf(&sb, "Your_Turn:%s\n", PlayerSign(yourTurn))
+	sb.WriteString("Rematch_On_Draw:NO\n")
+	sb.WriteString("To_Move:+\n")
+	fmt.Fprintf(&sb, "Max_Moves:%d\n", g.config.MaxMoves)
+	sb.WriteString("BEGIN Time\n")
+	fmt.Fprintf(&sb, "Time_Unit:%s\n", formatTimeUnit(tc.Unit))
+	fmt.Fprintf(&sb, "Total_Time:%d\n", units(tc.Total))
+	fmt.Fprintf(&sb, "Byoyomi:%d\n", units(tc.Byoyomi))
+	if tc.Increment > 0 {
+		fmt.Fprintf(&sb, "Increment:%d\n", units(tc.Increment))
+	}
+	fmt.Fprintf(&sb, "Least_Time_Per_Move:%d\n", units(tc.LeastTime))
+	sb.WriteString("END Time\n")
+	sb.WriteString("BEGIN Position\n")
+	sb.WriteString(FormatPosition(board.New()))
+	sb.WriteString("END Position\n")
+	sb.WriteString("END Game_Summary")
+	return sb.String()
+}
+
+// 時間を Time_Unit 単位の数値に変換
+func (g *serverGame) timeUnits(d time.Duration) int {
+	return int(d / g.config.Time.Unit)
+}
+
+// Time_Unit の表記
+func formatTimeUnit(unit time.Duration) string {
+	switch {
+	case unit%time.Minute == 0:
+		return fmt.Sprintf("%dmin", unit/time.Minute)
+	case unit%time.Second == 0:
+		return fmt.Sprintf("%dsec", unit/time.Second)
+	default:
+		return fmt.Sprintf("%dmsec", unit/time.Millisecond)
+	}
+}
+
+// 1人に送信
+func (g *serverGame) send(p *serverPlayer, line string) {
+	fmt.Fprintln(p.conn, line)
+}
+
+// 両者に送信
+func (g *serverGame) broadcast(line string) {
+	g.send(g.players[piece.Sente], line)
+	g.send(g.players[piece.Gote], line)
+}
+
+// 入玉宣言の条件（24点法、Declaration:Jishogi 1.1）を満たしているか
+// 手番側が宣言するものとして判定する
+//   - 玉が敵陣三段目以内にいる
+//   - 敵陣にいる玉以外の駒が10枚以上
+//   - 敵陣の駒と持ち駒の点数（大駒5点、小駒1点）が先手28点、後手27点以上
+//   - 王手がかかっていない
+func CanDeclareWin(b *board.Board) bool {
+	player := b.CurrentPlayer
+	inCamp := func(y int) bool {
+		if player == piece.Sente {
+			return y <= 2
+		}
+		return y >= board.BoardSize-3
+	}
+	points := func(t piece.Type) int {
+		switch t.Unpromote() {
+		case piece.Bishop, piece.Rook:
+			return 5
+		case piece.King:
+			return 0
+		default:
+			return 1
+		}
+	}
+
+	if b.IsCheck() {
+		return false
+	}
+
+	kingInCamp := false
+	count, score := 0, 0
+	for y := 0; y < board.BoardSize; y++ {
+		for x := 0; x < board.BoardSize; x++ {
+			p := b.Grid[y][x]
+			if p.Player != player || !inCamp(y) {
+				continue
+			}
+			if p.Type == piece.King {
+				kingInCamp = true
+				continue
+			}
+			count++
+			score += points(p.Type)
+		}
+	}
+	for t, n := range hand(b, player) {
+		score += points(t) * n
+	}
+
+	need := 28
+	if player == piece.Gote {
+		need = 27
+	}
+	return kingInCamp && count >= 10 && score >= need
+}
@@ -0,0 +1,236 @@
+package csa
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"shogi/board"
+)
+
+// テスト用サーバーを起動し、終局した棋譜を受け取るチャネルを返す
+func startServer(t *testing.T, config ServerConfig) (string, <-chan *Record) {
+	t.Helper()
+	records := make(chan *Record, 1)
+	config.OnGameEnd = func(rec *Record) { records <- rec }
+	config.Logger = log.New(io.Discard, "", 0)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go NewServer(config).Serve(l)
+	return l.Addr().String(), records
+}
+
+// ログインして対局を承諾したクライアント
+func joinGame(t *testing.T, addr, name string) *Client {
+	t.Helper()
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Login(name, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// 指定した手を順に指し、終局の結果を返す
+// moves の各要素はCSA形式で、自分の番の手だけを含める。"%TORYO" で投了する
+func playMoves(c *Client, moves []string, result chan<- *Event, errs chan<- error) {
+	gs, err := c.ReadGameSummary()
+	if err == nil {
+		err = c.Agree()
+	}
+	if err != nil {
+		errs <- err
+		return
+	}
+	for {
+		if c.Board().CurrentPlayer == gs.YourTurn && len(moves) > 0 {
+			next := moves[0]
+			moves = moves[1:]
+			if next == "%TORYO" {
+				err = c.Resign()
+			} else {
+				err = c.send(next)
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+		}
+		ev, err := c.Receive()
+		if err != nil {
+			errs <- err
+			return
+		}
+		if ev.IsGameEnd() {
+			result <- ev
+			return
+		}
+	}
+}
+
+// 2人のクライアントで1局指して、それぞれの結果と棋譜を返す
+func playGame(t *testing.T, config ServerConfig, sente, gote []string) (*Event, *Event, *Record) {
+	t.Helper()
+	addr, records := startServer(t, config)
+
+	senteClient := joinGame(t, addr, "alice")
+	senteResult := make(chan *Event, 1)
+	errs := make(chan error, 2)
+	go playMoves(senteClient, sente, senteResult, errs)
+
+	// 先手のログインが先に処理されるよう少し待つ
+	time.Sleep(50 * time.Millisecond)
+	goteClient := joinGame(t, addr, "bob")
+	goteResult := make(chan *Event, 1)
+	go playMoves(goteClient, gote, goteResult, errs)
+
+	var s, g *Event
+	timeout := time.After(10 * time.Second)
+	for s == nil || g == nil {
+		select {
+		case s = <-senteResult:
+		case g = <-goteResult:
+		case err := <-errs:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatal("対局が終わりませんでした")
+		}
+	}
+	return s, g, <-records
+}
+
+func TestServerResign(t *testing.T) {
+	dir := t.TempDir()
+	config := ServerConfig{
+		Time:      TimeControl{Total: 60 * time.Second, Byoyomi: 10 * time.Second},
+		RecordDir: dir,
+	}
+	s, g, rec := playGame(t, config, []string{"+7776FU", "+2726FU"}, []string{"-3334FU", "%TORYO"})
+
+	if s.Result != ResultWin || g.Result != ResultLose || s.Reason != "RESIGN" {
+		t.Fatalf("sente = %+v, gote = %+v", s, g)
+	}
+	if len(rec.Moves) != 3 || rec.End != "%TORYO" {
+		t.Fatalf("record = %+v", rec)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.csa"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("records = %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"N+alice", "N-bob", "+7776FU", "-3334FU", "%TORYO", "summary:RESIGN:alice win:bob lose"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("record file does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestServerSennichite(t *testing.T) {
+	var sente, gote []string
+	for i := 0; i < 3; i++ {
+		sente = append(sente, "+2838HI", "+3828HI")
+		gote = append(gote, "-8272HI", "-7282HI")
+	}
+	config := ServerConfig{Time: TimeControl{Total: 60 * time.Second}}
+	s, g, rec := playGame(t, config, sente, gote)
+
+	if s.Result != ResultDraw || g.Result != ResultDraw || s.Reason != "SENNICHITE" {
+		t.Fatalf("sente = %+v, gote = %+v", s, g)
+	}
+	if len(rec.Moves) != 12 {
+		t.Fatalf("moves = %d, want 12", len(rec.Moves))
+	}
+}
+
+func TestServerIllegalMove(t *testing.T) {
+	config := ServerConfig{Time: TimeControl{Total: 60 * time.Second}}
+	s, g, _ := playGame(t, config, []string{"+7775FU"}, nil)
+
+	if s.Result != ResultLose || g.Result != ResultWin || s.Reason != "ILLEGAL_MOVE" {
+		t.Fatalf("sente = %+v, gote = %+v", s, g)
+	}
+}
+
+func TestServerTimeUp(t *testing.T) {
+	config := ServerConfig{
+		Time:      TimeControl{Total: 0, Byoyomi: 0},
+		timeSlack: 10 * time.Millisecond,
+	}
+	s, g, _ := playGame(t, config, nil, nil)
+
+	if s.Result != ResultLose || g.Result != ResultWin || s.Reason != "TIME_UP" {
+		t.Fatalf("sente = %+v, gote = %+v", s, g)
+	}
+}
+
+// 承諾しない相手を待ち続けず、期限が来たら対局を拒否する
+func TestServerAgreeTimeout(t *testing.T) {
+	addr, _ := startServer(t, ServerConfig{AgreeWait: 100 * time.Millisecond})
+
+	sente := joinGame(t, addr, "alice")
+	errs := make(chan error, 1)
+	go func() {
+		_, err := sente.ReadGameSummary()
+		if err == nil {
+			err = sente.Agree()
+		}
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	gote := joinGame(t, addr, "bob")
+	if _, err := gote.ReadGameSummary(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrRejected) || !strings.Contains(err.Error(), "by bob") {
+			t.Fatalf("err = %v, want ErrRejected by bob", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("承諾の期限が来ても対局が拒否されませんでした")
+	}
+}
+
+func TestCanDeclareWin(t *testing.T) {
+	tests := []struct {
+		sfen string
+		want bool
+	}{
+		// 敵陣に12枚、点数は盤上20点＋持ち駒8点
+		{"KGGSS4/+R+BPPPPPP1/9/9/9/9/9/9/k8 b 8P 1", true},
+		// 先手は28点必要
+		{"KGGSS4/+R+BPPPPPP1/9/9/9/9/9/9/k8 b 7P 1", false},
+		// 玉が敵陣にいない
+		{"1GGSS4/+R+BPPPPPP1/9/9/9/9/9/K8/k8 b 8P 1", false},
+		// 王手がかかっている
+		{"GGSS4K/+R+BPPPPPP1/9/9/9/8r/9/9/k8 b 8P 1", false},
+		{"GGSS4K/+R+BPPPPPP1/9/9/9/9/9/9/k8 b 8P 1", true},
+	}
+	for _, tt := range tests {
+		b, _, err := board.ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := CanDeclareWin(b); got != tt.want {
+			t.Errorf("CanDeclareWin(%s) = %v, want %v", tt.sfen, got, tt.want)
+		}
+	}
+}
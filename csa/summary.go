@@ -0,0 +1,167 @@
+package csa
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+var ErrInvalidSummary = errors.New("csa: 不正な Game_Summary です")
+
+// 持ち時間の設定
+type TimeControl struct {
+	Unit      time.Duration // 時間の単位（Time_Unit）
+	Total     time.Duration // 持ち時間
+	Byoyomi   time.Duration // 秒読み
+	Increment time.Duration // フィッシャールールの加算時間
+	LeastTime time.Duration // 1手の最低消費時間
+}
+
+// 対局条件（Game_Summary）
+type GameSummary struct {
+	GameID   string
+	Names    map[piece.Player]string
+	YourTurn piece.Player
+	ToMove   piece.Player
+	MaxMoves int
+	Time     TimeControl
+	Board    *board.Board // 対局開始時の局面（途中から再開する場合は Moves 適用後）
+	Initial  *board.Board // Moves 適用前の局面
+	Moves    []board.Move // 既に指された手
+
+	position []string // 局面の行（盤面を作り直すために保持）
+}
+
+// BEGIN Game_Summary 〜 END Game_Summary を読み取る
+// 先頭の BEGIN Game_Summary 行は読み取り済みであること
+func readGameSummary(r *bufio.Reader) (*GameSummary, error) {
+	gs := &GameSummary{
+		Names: make(map[piece.Player]string),
+		Time:  TimeControl{Unit: time.Second},
+	}
+
+	var positionLines []string
+	section := ""
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch line {
+		case "END Game_Summary":
+			initial, _, err := ParsePosition(positionLinesWithoutMoves(positionLines), gs.Time.Unit)
+			if err != nil {
+				return nil, err
+			}
+			b, moves, err := ParsePosition(positionLines, gs.Time.Unit)
+			if err != nil {
+				return nil, err
+			}
+			gs.Initial = initial
+			gs.Board = b
+			gs.Moves = moves
+			gs.position = positionLines
+			return gs, nil
+		case "BEGIN Time", "BEGIN Position":
+			section = strings.TrimPrefix(line, "BEGIN ")
+			continue
+		case "END Time", "END Position":
+			section = ""
+			continue
+		}
+
+		if section == "Position" {
+			positionLines = append(positionLines, line)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Game_ID":
+			gs.GameID = value
+		case "Name+":
+			gs.Names[piece.Sente] = value
+		case "Name-":
+			gs.Names[piece.Gote] = value
+		case "Your_Turn", "To_Move":
+			if value == "" {
+				return nil, ErrInvalidSummary
+			}
+			p, ok := parsePlayerSign(value[0])
+			if !ok {
+				return nil, ErrInvalidSummary
+			}
+			if key == "Your_Turn" {
+				gs.YourTurn = p
+			} else {
+				gs.ToMove = p
+			}
+		case "Max_Moves":
+			gs.MaxMoves, _ = strconv.Atoi(value)
+		case "Time_Unit":
+			unit, err := parseTimeUnit(value)
+			if err != nil {
+				return nil, err
+			}
+			gs.Time.Unit = unit
+		case "Total_Time":
+			gs.Time.Total = parseTime(value, gs.Time.Unit)
+		case "Byoyomi":
+			gs.Time.Byoyomi = parseTime(value, gs.Time.Unit)
+		case "Increment":
+			gs.Time.Increment = parseTime(value, gs.Time.Unit)
+		case "Least_Time_Per_Move":
+			gs.Time.LeastTime = parseTime(value, gs.Time.Unit)
+		}
+	}
+}
+
+// 局面の行から指し手を除く（開始局面を得るため）
+func positionLinesWithoutMoves(lines []string) []string {
+	var out []string
+	turnSeen := false
+	for _, line := range lines {
+		if line == "+" || line == "-" {
+			turnSeen = true
+		} else if turnSeen && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// Time_Unit（例: 1sec、1min、1msec）を解析
+func parseTimeUnit(s string) (time.Duration, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, ErrInvalidSummary
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, ErrInvalidSummary
+	}
+	switch s[i:] {
+	case "sec":
+		return time.Duration(n) * time.Second, nil
+	case "min":
+		return time.Duration(n) * time.Minute, nil
+	case "msec":
+		return time.Duration(n) * time.Millisecond, nil
+	}
+	return 0, ErrInvalidSummary
+}
+
+func parseTime(s string, unit time.Duration) time.Duration {
+	n, _ := strconv.Atoi(s)
+	return time.Duration(n) * unit
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"context"
+	"sort"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 詰みを表す評価値
+const MateScore = 100000
+
+// 駒の価値（盤上）
+var pieceValues = map[piece.Type]int{
+	piece.Pawn:       100,
+	piece.Lance:      300,
+	piece.Knight:     400,
+	piece.Silver:     550,
+	piece.Gold:       600,
+	piece.Bishop:     800,
+	piece.Rook:       1000,
+	piece.PromPawn:   600,
+	piece.PromLance:  600,
+	piece.PromKnight: 600,
+	piece.PromSilver: 600,
+	piece.PromBishop: 1100,
+	piece.PromRook:   1300,
+	piece.Giraffe:    500,
+	piece.Elephant:   450,
+}
+
+// 駒の価値（将棋の種類の定義ファイルで決めた駒は動きの数から見積もる）
+func pieceValue(t piece.Type) int {
+	if v, ok := pieceValues[t]; ok || !t.IsCustom() {
+		return v
+	}
+	v := 0
+	for _, dir := range (piece.Piece{Type: t, Player: piece.Sente}).GetMovements() {
+		if dir.Repeat {
+			v += 250
+		} else {
+			v += 100
+		}
+	}
+	return v
+}
+
+// 持ち駒は盤上より少し高く評価する
+const handBonus = 10
+
+// 探索の途中経過・結果
+type Info struct {
+	Depth int          // 探索した深さ
+	Score int          // 手番側から見た評価値
+	PV    []board.Move // 読み筋（先頭が最善手）
+	Nodes int64        // 探索した局面数
+}
+
+// 最善手（読み筋がなければ false）
+func (i Info) BestMove() (board.Move, bool) {
+	if len(i.PV) == 0 {
+		return board.Move{}, false
+	}
+	return i.PV[0], true
+}
+
+// 局面の評価値（手番側から見た駒得）
+func Evaluate(b *board.Board) int {
+	score := 0
+	for y := 0; y < board.BoardSize; y++ {
+		for x := 0; x < board.BoardSize; x++ {
+			p := b.Grid[y][x]
+			if p.Type == piece.Empty {
+				continue
+			}
+			if p.Player == b.CurrentPlayer {
+				score += pieceValue(p.Type)
+			} else {
+				score -= pieceValue(p.Type)
+			}
+		}
+	}
+	for t, n := range b.SenteCaptures {
+		v := (pieceValue(t) + handBonus) * n
+		if b.CurrentPlayer == piece.Sente {
+			score += v
+		} else {
+			score -= v
+		}
+	}
+	for t, n := range b.GoteCaptures {
+		v := (pieceValue(t) + handBonus) * n
+		if b.CurrentPlayer == piece.Gote {
+			score += v
+		} else {
+			score -= v
+		}
+	}
+	return score
+}
+
+// 反復深化で maxDepth まで探索する
+// ctx が終わると、最後に読み終えた深さの結果を返す。onInfo は深さごとに呼ばれる（nil可）
+func Search(ctx context.Context, b *board.Board, maxDepth int, onInfo func(Info)) Info {
+	s := &searcher{ctx: ctx}
+	var best Info
+
+	root := b.Clone()
+	for depth := 1; depth <= maxDepth; depth++ {
+		var pv []board.Move
+		score := s.negamax(root, depth, 0, -MateScore-1, MateScore+1, &pv, best.PV)
+		if s.aborted {
+			break
+		}
+		best = Info{Depth: depth, Score: score, PV: pv, Nodes: s.nodes}
+		if onInfo != nil {
+			onInfo(best)
+		}
+		// 詰みが見つかればそれ以上読まない
+		if score >= MateScore-depth || score <= -MateScore+depth {
+			break
+		}
+	}
+	return best
+}
+
+type searcher struct {
+	ctx     context.Context
+	nodes   int64
+	aborted bool
+}
+
+// αβ法による探索。pv には読み筋を返す。prevPV は前の深さの読み筋（手順の並べ替えに使う）
+func (s *searcher) negamax(b *board.Board, depth, ply, alpha, beta int, pv *[]board.Move, prevPV []board.Move) int {
+	s.nodes++
+	if s.nodes%1024 == 0 && s.ctx.Err() != nil {
+		s.aborted = true
+	}
+	if s.aborted {
+		return 0
+	}
+
+	moves := b.LegalMoves()
+	if len(moves) == 0 {
+		// 合法手がなければ負け（詰み）
+		return -MateScore + ply
+	}
+	if depth == 0 {
+		return Evaluate(b)
+	}
+
+	orderMoves(b, moves, prevPV)
+	for _, m := range moves {
+		next := b.Clone()
+		next.MakeMove(m)
+
+		var childPV, childPrev []board.Move
+		if len(prevPV) > 1 && prevPV[0] == m {
+			childPrev = prevPV[1:]
+		}
+		score := -s.negamax(next, depth-1, ply+1, -beta, -alpha, &childPV, childPrev)
+		if s.aborted {
+			return 0
+		}
+		if score > alpha {
+			alpha = score
+			*pv = append([]board.Move{m}, childPV...)
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return alpha
+}
+
+// 前の読み筋の手、駒を取る手、成る手の順に並べる
+func orderMoves(b *board.Board, moves []board.Move, prevPV []board.Move) {
+	priority := func(m board.Move) int {
+		if len(prevPV) > 0 && prevPV[0] == m {
+			return 1 << 20
+		}
+		p := 0
+		if m.FromX != -1 {
+			p += pieceValue(b.Grid[m.ToY][m.ToX].Type)
+		}
+		if m.Promote {
+			p += 50
+		}
+		return p
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return priority(moves[i]) > priority(moves[j])
+	})
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 画面の描画（盤面を読むだけ）と検討の探索が同じ盤面を同時に読んでも競合しない
+// go test -race で確かめる
+func TestSearchWhileDrawing(t *testing.T) {
+	b := board.New()
+	for _, m := range []board.Move{
+		{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, // ▲７六歩
+		{FromX: 6, FromY: 2, ToX: 6, ToY: 3}, // △３四歩
+	} {
+		if err := b.ApplyMove(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := b.SFEN(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		SearchMultiPV(ctx, b, 2, 3, nil)
+	}()
+
+	// 描画で行う読み出し
+	for i := 0; i < 50; i++ {
+		for y := 0; y < b.Height(); y++ {
+			for x := 0; x < b.Width(); x++ {
+				b.GetPiece(x, y)
+			}
+		}
+		b.GetCaptures(piece.Sente)
+		b.GetCaptures(piece.Gote)
+		b.IsCheck()
+		b.Winner()
+		b.PositionKey()
+	}
+	wg.Wait()
+
+	if got := b.SFEN(1); got != want {
+		t.Errorf("探索の後の盤面 = %s, want %s", got, want)
+	}
+}
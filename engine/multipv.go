@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"context"
+	"sort"
+
+	"shogi/board"
+)
+
+// 上位 multiPV 手の候補手をそれぞれの読み筋とともに探索する（検討用）
+// 結果は評価値の高い順。ctx が終わると、最後に読み終えた深さの結果を返す。onInfo は深さごとに呼ばれる（nil可）
+func SearchMultiPV(ctx context.Context, b *board.Board, maxDepth, multiPV int, onInfo func([]Info)) []Info {
+	if multiPV < 1 {
+		multiPV = 1
+	}
+	s := &searcher{ctx: ctx}
+	var best []Info
+
+	root := b.Clone()
+	moves := root.LegalMoves()
+	for depth := 1; depth <= maxDepth && len(moves) > 0; depth++ {
+		// 前の深さの順位の手から読む
+		prevPV := make(map[board.Move][]board.Move, len(best))
+		for _, info := range best {
+			prevPV[info.PV[0]] = info.PV[1:]
+		}
+		orderMoves(root, moves, nil)
+		sort.SliceStable(moves, func(i, j int) bool {
+			_, pi := prevPV[moves[i]]
+			_, pj := prevPV[moves[j]]
+			return pi && !pj
+		})
+
+		var results []Info
+		for _, m := range moves {
+			// 上位に入らない手は、その評価値を下限にして手早く切り捨てる
+			alpha := -MateScore - 1
+			if len(results) == multiPV {
+				alpha = results[multiPV-1].Score
+			}
+
+			next := root.Clone()
+			next.MakeMove(m)
+			var childPV []board.Move
+			score := -s.negamax(next, depth-1, 1, -MateScore-1, -alpha, &childPV, prevPV[m])
+			if s.aborted {
+				break
+			}
+			if score <= alpha {
+				continue
+			}
+
+			info := Info{Depth: depth, Score: score, PV: append([]board.Move{m}, childPV...)}
+			i := sort.Search(len(results), func(i int) bool { return results[i].Score < score })
+			results = append(results[:i], append([]Info{info}, results[i:]...)...)
+			if len(results) > multiPV {
+				results = results[:multiPV]
+			}
+		}
+		if s.aborted {
+			break
+		}
+
+		for i := range results {
+			results[i].Nodes = s.nodes
+		}
+		best = results
+		if onInfo != nil {
+			onInfo(best)
+		}
+		// 最善手で詰みが見つかればそれ以上読まない
+		if score := best[0].Score; score >= MateScore-depth || score <= -MateScore+depth {
+			break
+		}
+	}
+	return best
+}
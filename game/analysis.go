@@ -0,0 +1,259 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+
+	"shogi/board"
+	"shogi/engine"
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// 検討の設定
+const (
+	analysisMultiPV     = 3    // 表示する候補手の数
+	analysisMaxDepth    = 6    // 探索する最大の深さ
+	analysisPVMoves     = 6    // 読み筋を表示する手数
+	analysisMovesPerRow = 2    // 読み筋の1行に表示する手数
+	analysisMateRange   = 1000 // 詰みとして表示する評価値の幅
+)
+
+// 候補手の矢印の色（順位の高い手ほど濃い）
+var analysisColors = []color.RGBA{
+	{0, 90, 200, 200},
+	{0, 90, 200, 130},
+	{0, 90, 200, 80},
+}
+
+// 検討の状態（探索は別のゴルーチンで行い、局面が変わったら止めて読み直す）
+type analysis struct {
+	key     board.Position     // 探索中の局面
+	cancel  context.CancelFunc // 探索を止める（探索していなければnil）
+	result  *analysisResult
+	version int           // 表示に反映した探索結果の版
+	infos   []engine.Info // 候補手（評価値の高い順）
+	lines   [][]string    // 候補手ごとの表示（指し手と評価値、読み筋）
+	done    bool          // 探索が終わったか
+}
+
+// 探索の途中経過（探索のゴルーチンが書き込み、Update で読み出す）
+type analysisResult struct {
+	mu      sync.Mutex
+	infos   []engine.Info
+	version int
+	done    bool
+}
+
+// 検討パネルの表示切り替え（A キー）
+func (g *Game) handleAnalysisInput() {
+	if !inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		return
+	}
+	if g.analysis == nil {
+		g.analysis = &analysis{}
+	} else {
+		g.analysis.stop()
+		g.analysis = nil
+	}
+}
+
+// 局面が変わったら探索をやり直し、探索の途中経過を表示に反映する
+func (g *Game) updateAnalysis() {
+	a := g.analysis
+	if a == nil {
+		return
+	}
+	// 編集中の局面は玉がないこともあるので探索しない
+	if g.editor != nil {
+		a.stop()
+		return
+	}
+
+	pos := g.board.Position()
+	if a.cancel == nil || pos != a.key {
+		a.start(pos)
+	}
+
+	r := a.result
+	r.mu.Lock()
+	infos, version, done := r.infos, r.version, r.done
+	r.mu.Unlock()
+	a.done = done
+	if version != a.version {
+		a.version = version
+		a.infos = infos
+		var last *board.Move
+		if n := len(g.history); n > 0 {
+			last = &g.history[n-1]
+		}
+		a.lines = formatAnalysis(g.board, last, infos)
+	}
+}
+
+// 局面の探索を始める（前の探索は止める）
+// 探索のゴルーチンには局面の値を渡し、そこから作った盤面を使うので描画中の盤面とは共有しない
+func (a *analysis) start(pos board.Position) {
+	a.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &analysisResult{}
+	a.key, a.cancel, a.result = pos, cancel, r
+	a.version, a.infos, a.lines, a.done = 0, nil, nil, false
+
+	go func() {
+		engine.SearchMultiPV(ctx, pos.Board(), analysisMaxDepth, analysisMultiPV, func(infos []engine.Info) {
+			r.mu.Lock()
+			r.infos = infos
+			r.version++
+			r.mu.Unlock()
+		})
+		r.mu.Lock()
+		r.done = true
+		r.mu.Unlock()
+	}()
+}
+
+// 探索を止める
+func (a *analysis) stop() {
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+}
+
+// 候補手ごとの表示（1行目は順位・指し手・評価値、続けて読み筋）
+// last は局面の直前の指し手（「同」の判定に使う、なければnil）
+func formatAnalysis(b *board.Board, last *board.Move, infos []engine.Info) [][]string {
+	var lines [][]string
+	for i, info := range infos {
+		pos := b.Clone()
+		var moves []string
+		prev := last
+		for j, m := range info.PV {
+			if j >= analysisPVMoves {
+				break
+			}
+			moves = append(moves, formatMove(pos, m, prev))
+			pos.MakeMove(m)
+			prev = &info.PV[j]
+		}
+
+		entry := []string{fmt.Sprintf("%d. %s %s", i+1, moves[0], formatScore(info.Score, b.CurrentPlayer))}
+		for j := 1; j < len(moves); j += analysisMovesPerRow {
+			entry = append(entry, "   "+strings.Join(moves[j:min(j+analysisMovesPerRow, len(moves))], ""))
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+// 評価値の表記（先手から見た値。詰みは詰むまでの手数）
+func formatScore(score int, player piece.Player) string {
+	if player == piece.Gote {
+		score = -score
+	}
+	switch {
+	case score >= engine.MateScore-analysisMateRange:
+		return fmt.Sprintf("+詰%d", engine.MateScore-score)
+	case score <= -engine.MateScore+analysisMateRange:
+		return fmt.Sprintf("-詰%d", engine.MateScore+score)
+	}
+	return fmt.Sprintf("%+d", score)
+}
+
+// 候補手の矢印を描画（駒打ちは打つマスを丸で囲む）
+func (g *Game) drawAnalysisArrows(screen *ebiten.Image) {
+	a := g.analysis
+	if a == nil || g.editor != nil {
+		return
+	}
+	cell := float32(g.cellSize())
+	center := func(x, y int) (float32, float32) {
+		sx, sy := g.squarePosition(x, y)
+		return float32(sx) + cell/2, float32(sy) + cell/2
+	}
+
+	// 順位の低い手から描いて、最善手を上に重ねる
+	for i := len(a.infos) - 1; i >= 0; i-- {
+		m := a.infos[i].PV[0]
+		clr := analysisColors[min(i, len(analysisColors)-1)]
+		width := cell / 10
+		if i > 0 {
+			width = cell / 14
+		}
+
+		tx, ty := center(m.ToX, m.ToY)
+		if m.FromX < 0 {
+			vector.StrokeCircle(screen, tx, ty, cell/3, width, clr, true)
+			continue
+		}
+		fx, fy := center(m.FromX, m.FromY)
+		drawArrow(screen, fx, fy, tx, ty, width, cell/3, clr)
+	}
+}
+
+// 矢印（先端に2本の線で矢じりを付ける）
+func drawArrow(screen *ebiten.Image, x0, y0, x1, y1, width, head float32, clr color.Color) {
+	vector.StrokeLine(screen, x0, y0, x1, y1, width, clr, true)
+	angle := math.Atan2(float64(y1-y0), float64(x1-x0))
+	for _, d := range []float64{math.Pi * 5 / 6, -math.Pi * 5 / 6} {
+		hx := x1 + head*float32(math.Cos(angle+d))
+		hy := y1 + head*float32(math.Sin(angle+d))
+		vector.StrokeLine(screen, x1, y1, hx, hy, width, clr, true)
+	}
+}
+
+// 検討パネルを描画
+func (g *Game) drawAnalysisPanel(screen *ebiten.Image) {
+	a := g.analysis
+
+	// パネルの背景
+	ebitenutil.DrawRect(screen,
+		float64(KifuPanelX),
+		float64(BoardMarginY),
+		float64(KifuPanelWidth),
+		float64(boardAreaSize),
+		color.RGBA{245, 240, 230, 255})
+	text.Draw(screen, "検討", g.font, KifuPanelX+10, BoardMarginY+22, color.Black)
+
+	// 探索の状況
+	status := "探索中…"
+	switch {
+	case len(a.infos) > 0 && a.done:
+		status = fmt.Sprintf("深さ%d 完了", a.infos[0].Depth)
+	case len(a.infos) > 0:
+		status = fmt.Sprintf("深さ%d 探索中…", a.infos[0].Depth)
+	case a.done:
+		status = "合法手がありません"
+	}
+	text.Draw(screen, status, g.font, KifuPanelX+70, BoardMarginY+22, color.RGBA{100, 100, 100, 255})
+
+	// 候補手と読み筋
+	y := BoardMarginY + kifuTitleHeight + KifuRowHeight
+	for i, entry := range a.lines {
+		for j, line := range entry {
+			clr := color.Color(color.Black)
+			if j > 0 {
+				clr = color.RGBA{80, 80, 80, 255}
+			}
+			text.Draw(screen, line, g.font, KifuPanelX+10, y, clr)
+			y += KifuRowHeight
+		}
+		if i < len(a.lines)-1 {
+			y += KifuRowHeight / 2
+		}
+	}
+	if len(a.infos) > 0 {
+		text.Draw(screen, fmt.Sprintf("%d局面", a.infos[0].Nodes), g.font,
+			KifuPanelX+10, BoardMarginY+boardAreaSize-10, color.RGBA{100, 100, 100, 255})
+	}
+}
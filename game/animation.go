@@ -0,0 +1,165 @@
+package game
+
+import (
+	"shogi/board"
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// 駒が動くアニメーションの長さ（Update の回数、約0.2秒）
+const animationTicks = 12
+
+// 指し手のアニメーションと効果音を出すために覚えておく、直前に表示した対局の状態
+type moveEffects struct {
+	moves    []board.Move   // 最後に見た指し手リスト
+	board    *board.Board   // その局面
+	gameOver bool           // 終局していたか
+	dragged  bool           // 最後の手をドラッグで指した（駒はもう移動先にあるので滑らせない）
+	anim     *moveAnimation // 再生中のアニメーション（なければnil）
+}
+
+// 1手分のアニメーション（位置は画面上の駒の中心）
+type moveAnimation struct {
+	start    int         // 始めたときの ticks
+	piece    piece.Piece // 動かした駒（成ったなら成った駒）
+	toX, toY int         // 盤上の移動先（アニメーション中はそのマスの駒を描かない）
+	from, to [2]float64
+
+	// 取った駒は移動先から持ち駒エリアへ飛ばす（取らなければ Empty）
+	captured       piece.Piece
+	capFrom, capTo [2]float64
+	hideHand       bool // 初めて持つ種類なら、着くまで持ち駒エリアに描かない
+}
+
+// 指し手リストが1手増えたら、その手のアニメーションと効果音を始める
+// 一手戻したり棋譜の別の局面へ移ったりしたときは、アニメーションなしで表示を切り替える
+func (g *Game) updateEffects() {
+	e := &g.effects
+	over := g.state.State == StateGameOver
+	if g.editor != nil {
+		e.moves, e.board, e.anim, e.gameOver = nil, nil, nil, over
+		return
+	}
+
+	if e.board == nil || !equalMoves(e.moves, g.history) {
+		n := len(g.history)
+		if e.board != nil && n == len(e.moves)+1 && equalMoves(e.moves, g.history[:n-1]) {
+			g.startMoveEffects(e.board, g.history[n-1], over)
+		} else {
+			e.anim = nil
+		}
+		e.moves = append([]board.Move(nil), g.history...)
+		e.board = g.board.Clone()
+		e.dragged = false
+	}
+
+	if over && !e.gameOver {
+		g.playSound(soundGameEnd)
+	}
+	e.gameOver = over
+
+	if e.anim != nil && g.ticks-e.anim.start >= animationTicks {
+		e.anim = nil
+	}
+}
+
+// 指す前の局面 prev で指した手のアニメーションと効果音を始める（終局したなら終局の音だけ鳴らす）
+func (g *Game) startMoveEffects(prev *board.Board, m board.Move, over bool) {
+	mover := prev.CurrentPlayer
+	a := &moveAnimation{
+		start: g.ticks,
+		piece: g.board.GetPiece(m.ToX, m.ToY),
+		toX:   m.ToX,
+		toY:   m.ToY,
+		to:    g.squareCenter(m.ToX, m.ToY),
+	}
+	switch {
+	case g.effects.dragged:
+		a.from = a.to
+	case m.IsDrop():
+		a.from = g.handPosition(prev, mover, m.Piece)
+	default:
+		a.from = g.squareCenter(m.FromX, m.FromY)
+	}
+
+	if !m.IsDrop() {
+		if dest := prev.GetPiece(m.ToX, m.ToY); dest.Type != piece.Empty {
+			t := dest.Type.Unpromote()
+			a.captured = piece.Piece{Type: t, Player: mover}
+			a.capFrom = a.to
+			a.capTo = g.handPosition(g.board, mover, t)
+			a.hideHand = handIndex(prev, mover, t) < 0
+		}
+	}
+	g.effects.anim = a
+
+	switch {
+	case over:
+	case g.board.IsCheck():
+		g.playSound(soundCheck)
+	case a.captured.Type != piece.Empty:
+		g.playSound(soundCapture)
+	default:
+		g.playSound(soundMove)
+	}
+}
+
+// マスの中心の画面上の位置
+func (g *Game) squareCenter(x, y int) [2]float64 {
+	sx, sy := g.squarePosition(x, y)
+	cell := g.cellSize()
+	return [2]float64{float64(sx + cell/2), float64(sy + cell/2)}
+}
+
+// 持ち駒の並びでの位置（持っていなければ -1）
+func handIndex(b *board.Board, player piece.Player, t piece.Type) int {
+	for i, ht := range b.GetCaptures(player) {
+		if ht == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// 持ち駒エリアでその種類の駒を描く位置（持っていなければ先頭の位置）
+func (g *Game) handPosition(b *board.Board, player piece.Player, t piece.Type) [2]float64 {
+	area := g.captureArea(player)
+	i := max(handIndex(b, player, t), 0)
+	return [2]float64{float64(area.X + area.Width/2), float64(area.Y + 40 + i*area.Spacing)}
+}
+
+// アニメーション中で、盤上のそのマスの駒を描かないか
+func (g *Game) isAnimatingSquare(x, y int) bool {
+	a := g.effects.anim
+	return a != nil && a.toX == x && a.toY == y
+}
+
+// アニメーション中で、持ち駒エリアにその駒を描かないか
+func (g *Game) isAnimatingHand(player piece.Player, t piece.Type) bool {
+	a := g.effects.anim
+	return a != nil && a.hideHand && a.captured.Player == player && a.captured.Type == t
+}
+
+// 動いている駒と、持ち駒エリアへ飛んでいる取った駒を描画
+func (g *Game) drawAnimation(screen *ebiten.Image) {
+	a := g.effects.anim
+	if a == nil {
+		return
+	}
+	// 速く動き始めてゆっくり止まる
+	t := min(float64(g.ticks-a.start)/animationTicks, 1)
+	t = 1 - (1-t)*(1-t)
+
+	if a.captured.Type != piece.Empty {
+		x, y := lerp(a.capFrom, a.capTo, t)
+		g.drawPiece(screen, a.captured, x, y)
+	}
+	x, y := lerp(a.from, a.to, t)
+	g.drawPiece(screen, a.piece, x, y)
+}
+
+// 2点の間の位置
+func lerp(from, to [2]float64, t float64) (int, int) {
+	return int(from[0] + (to[0]-from[0])*t), int(from[1] + (to[1]-from[1])*t)
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"shogi/book"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// 定跡手のヒントとして表示する手の数
+const bookHintMoves = 3
+
+// 定跡を設定し、定跡手のヒントを表示する（B キーで表示を切り替える）
+func (g *Game) SetBook(bk *book.Book) {
+	g.book = bk
+	g.showBook = bk != nil
+}
+
+// 定跡手のヒントの表示切り替え
+func (g *Game) handleBookInput() {
+	if g.book != nil && inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		g.showBook = !g.showBook
+	}
+}
+
+// 定跡手の移動元・移動先と、出現回数の割合を表示
+func (g *Game) drawBookHints(screen *ebiten.Image) {
+	if g.book == nil || !g.showBook || g.editor != nil {
+		return
+	}
+	entries := g.book.Moves(g.board)
+	total := 0
+	for _, e := range entries {
+		total += max(e.Count, 1)
+	}
+	if len(entries) > bookHintMoves {
+		entries = entries[:bookHintMoves]
+	}
+
+	cell := g.cellSize()
+	for _, e := range entries {
+		m := e.Move
+		if m.FromX >= 0 && m.FromY >= 0 {
+			x, y := g.squarePosition(m.FromX, m.FromY)
+			ebitenutil.DrawRect(screen, float64(x), float64(y), float64(cell), float64(cell),
+				color.RGBA{0, 120, 255, 40})
+		}
+		x, y := g.squarePosition(m.ToX, m.ToY)
+		ebitenutil.DrawRect(screen, float64(x), float64(y), float64(cell), float64(cell),
+			color.RGBA{0, 120, 255, 96})
+		label := fmt.Sprintf("%d%%", max(e.Count, 1)*100/total)
+		text.Draw(screen, label, g.font, x+2, y+18, color.RGBA{0, 60, 160, 255})
+	}
+}
@@ -3,7 +3,7 @@ package game
 import (
 	"fmt"
 	"image/color"
-	"shogi/board"
+	"math"
 	"shogi/piece"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -14,78 +14,124 @@ import (
 // 画面描画
 func (g *Game) Draw(screen *ebiten.Image) {
 	// 背景を描画
-	screen.Fill(color.RGBA{220, 220, 220, 255})
+	screen.Fill(g.theme.Background)
 
-	// 将棋盤を描画
+	// 将棋盤と筋・段の番号を描画
 	g.drawBoard(screen)
+	g.drawCoordinates(screen)
 
 	// 駒を描画
 	g.drawPieces(screen)
+	g.drawCursor(screen)
+
+	// 検討の候補手の矢印を描画
+	g.drawAnalysisArrows(screen)
 
 	// 持ち駒エリアを描画
 	g.drawCaptureAreas(screen)
 
+	// 動いている駒を描画
+	g.drawAnimation(screen)
+
+	// 局面編集パネル、検討パネル、棋譜パネル、指し手一覧のいずれかを描画
+	switch {
+	case g.editor != nil:
+		g.drawEditorPanel(screen)
+	case g.analysis != nil:
+		g.drawAnalysisPanel(screen)
+	case g.replay != nil:
+		g.drawKifuPanel(screen)
+	default:
+		g.drawMoveList(screen)
+	}
+
 	// UI要素を描画
 	g.drawUI(screen)
+
+	// コマンドの入力欄、再開の確認とファイル名の入力
+	g.drawCommandBar(screen)
+	g.drawFileDialogs(screen)
 }
 
 // 将棋盤を描画
 func (g *Game) drawBoard(screen *ebiten.Image) {
-	boardWidth := board.BoardSize * CellSize
-	boardHeight := board.BoardSize * CellSize
+	cell := g.cellSize()
+	ox, oy := g.boardOrigin()
+	boardWidth := g.board.Width() * cell
+	boardHeight := g.board.Height() * cell
+
+	// 盤の背景（テーマに盤の画像があれば画像）
+	if img := g.themeAssets.board; img != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(boardWidth)/float64(img.Bounds().Dx()), float64(boardHeight)/float64(img.Bounds().Dy()))
+		op.GeoM.Translate(float64(ox), float64(oy))
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(img, op)
+	} else {
+		ebitenutil.DrawRect(screen,
+			float64(ox),
+			float64(oy),
+			float64(boardWidth),
+			float64(boardHeight),
+			g.theme.Board)
+	}
 
-	// 盤の背景
-	ebitenutil.DrawRect(screen,
-		float64(BoardMarginX),
-		float64(BoardMarginY),
-		float64(boardWidth),
-		float64(boardHeight),
-		color.RGBA{210, 180, 140, 255})
+	// 最後に指した手と王手のハイライト表示
+	g.drawLastMove(screen)
+	g.drawCheckHighlight(screen)
+
+	// 定跡手のヒント
+	g.drawBookHints(screen)
 
 	// 移動可能なマスをハイライト表示
 	for _, pos := range g.state.ValidMoves {
+		x, y := g.squarePosition(pos[0], pos[1])
 		ebitenutil.DrawRect(screen,
-			float64(BoardMarginX+pos[0]*CellSize),
-			float64(BoardMarginY+pos[1]*CellSize),
-			float64(CellSize),
-			float64(CellSize),
+			float64(x),
+			float64(y),
+			float64(cell),
+			float64(cell),
 			color.RGBA{0, 255, 0, 64})
 	}
 
-	// マス目を描画
-	for i := 0; i <= board.BoardSize; i++ {
-		// 横線
+	// マス目を描画（横線）
+	for i := 0; i <= g.board.Height(); i++ {
 		ebitenutil.DrawLine(screen,
-			float64(BoardMarginX),
-			float64(BoardMarginY+i*CellSize),
-			float64(BoardMarginX+boardWidth),
-			float64(BoardMarginY+i*CellSize),
-			color.Black)
+			float64(ox),
+			float64(oy+i*cell),
+			float64(ox+boardWidth),
+			float64(oy+i*cell),
+			g.theme.Line)
+	}
 
-		// 縦線
+	// マス目を描画（縦線）
+	for i := 0; i <= g.board.Width(); i++ {
 		ebitenutil.DrawLine(screen,
-			float64(BoardMarginX+i*CellSize),
-			float64(BoardMarginY),
-			float64(BoardMarginX+i*CellSize),
-			float64(BoardMarginY+boardHeight),
-			color.Black)
+			float64(ox+i*cell),
+			float64(oy),
+			float64(ox+i*cell),
+			float64(oy+boardHeight),
+			g.theme.Line)
 	}
 }
 
 // 盤上の駒を描画
 func (g *Game) drawPieces(screen *ebiten.Image) {
-	for y := 0; y < board.BoardSize; y++ {
-		for x := 0; x < board.BoardSize; x++ {
+	cell := g.cellSize()
+	for y := 0; y < g.board.Height(); y++ {
+		for x := 0; x < g.board.Width(); x++ {
+			sx, sy := g.squarePosition(x, y)
+
 			// 選択された駒のハイライト
 			if g.state.State == StateSelected &&
 				x == g.state.SelectedX &&
 				y == g.state.SelectedY &&
 				g.state.Dragging == DragNone {
 				ebitenutil.DrawRect(screen,
-					float64(BoardMarginX+x*CellSize),
-					float64(BoardMarginY+y*CellSize),
-					float64(CellSize),
-					float64(CellSize),
+					float64(sx),
+					float64(sy),
+					float64(cell),
+					float64(cell),
 					color.RGBA{255, 255, 0, 128})
 			}
 
@@ -94,10 +140,9 @@ func (g *Game) drawPieces(screen *ebiten.Image) {
 			if p.Type != piece.Empty &&
 				!(g.state.Dragging == DragBoard &&
 					x == g.state.SelectedX &&
-					y == g.state.SelectedY) {
-				g.drawPiece(screen, p,
-					BoardMarginX+x*CellSize+CellSize/2,
-					BoardMarginY+y*CellSize+CellSize/2)
+					y == g.state.SelectedY) &&
+				!g.isAnimatingSquare(x, y) {
+				g.drawPiece(screen, p, sx+cell/2, sy+cell/2)
 			}
 		}
 	}
@@ -105,10 +150,17 @@ func (g *Game) drawPieces(screen *ebiten.Image) {
 
 // 持ち駒エリアを描画
 func (g *Game) drawCaptureAreas(screen *ebiten.Image) {
+	if g.editor != nil {
+		g.drawEditorHands(screen)
+		return
+	}
+
 	// 先手の持ち駒エリア
-	g.drawCaptureArea(screen, &g.senteCaptures)
+	sente := g.captureArea(piece.Sente)
+	g.drawCaptureArea(screen, &sente)
 	// 後手の持ち駒エリア
-	g.drawCaptureArea(screen, &g.goteCaptures)
+	gote := g.captureArea(piece.Gote)
+	g.drawCaptureArea(screen, &gote)
 
 	// ドラッグ中の駒を描画
 	if g.state.Dragging != DragNone {
@@ -128,7 +180,7 @@ func (g *Game) drawCaptureArea(screen *ebiten.Image, area *CaptureArea) {
 		float64(area.Y),
 		float64(area.Width),
 		float64(area.Height),
-		color.RGBA{230, 220, 210, 255})
+		g.theme.Hand)
 
 	// "持駒" のラベルを描画
 	text.Draw(screen,
@@ -136,7 +188,7 @@ func (g *Game) drawCaptureArea(screen *ebiten.Image, area *CaptureArea) {
 		g.font,
 		area.X+area.Width/2-20,
 		area.Y+25,
-		color.Black)
+		g.theme.Label)
 
 	// 持ち駒の描画
 	captures := g.board.GetCaptures(area.Player)
@@ -147,6 +199,19 @@ func (g *Game) drawCaptureArea(screen *ebiten.Image, area *CaptureArea) {
 			g.state.DragPieceOwner == area.Player {
 			continue
 		}
+		// 取った駒が持ち駒エリアへ飛んでいる間は描かない
+		if g.isAnimatingHand(area.Player, pieceType) {
+			continue
+		}
+
+		// 選んだ持ち駒（ドラッグ中でなければ）
+		if g.state.State == StateSelected &&
+			g.state.SelectedX < 0 &&
+			g.state.Dragging == DragNone &&
+			g.state.DragPieceType == pieceType &&
+			g.state.DragPieceOwner == area.Player {
+			g.drawHandSelection(screen, area, i)
+		}
 
 		p := piece.Piece{
 			Type:   pieceType,
@@ -154,16 +219,17 @@ func (g *Game) drawCaptureArea(screen *ebiten.Image, area *CaptureArea) {
 		}
 		g.drawPiece(screen, p,
 			area.X+area.Width/2,
-			area.Y+40+i*CellSize)
+			area.Y+40+i*area.Spacing)
 	}
 }
 
 // UI要素を描画
 func (g *Game) drawUI(screen *ebiten.Image) {
-	// 手番表示の背景を描画
+	// 手番表示の背景を描画（盤の上は筋の番号があるので左下に表示）
+	turnX, turnY := CaptureAreaMargin+CaptureAreaWidth/2, ScreenHeight-35
 	ebitenutil.DrawRect(screen,
-		float64(ScreenWidth/2-60),
-		float64(BoardMarginY-5),
+		float64(turnX-60),
+		float64(turnY),
 		120,
 		25,
 		color.RGBA{230, 230, 230, 255})
@@ -175,8 +241,8 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 	}
 	bounds := text.BoundString(g.font, playerText)
 	text.Draw(screen, playerText, g.font,
-		ScreenWidth/2-bounds.Dx()/2, // 中央揃え
-		BoardMarginY+15,             // 将棋盤の上部に表示
+		turnX-bounds.Dx()/2, // 中央揃え
+		turnY+20,
 		color.Black)
 
 	// メッセージ表示
@@ -211,30 +277,52 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 
 // 個々の駒を描画
 func (g *Game) drawPiece(screen *ebiten.Image, p piece.Piece, centerX, centerY int) {
+	g.drawPieceSized(screen, p, centerX, centerY, g.cellSize()/2-5)
+}
+
+// 大きさ（丸の半径）を指定して駒を描画
+func (g *Game) drawPieceSized(screen *ebiten.Image, p piece.Piece, centerX, centerY, radius int) {
 	if p.Type == piece.Empty {
 		return
 	}
 
-	// 駒の背景（丸）
-	ebitenutil.DrawCircle(screen,
-		float64(centerX),
-		float64(centerY),
-		float64(CellSize/2-5),
-		color.RGBA{240, 215, 160, 255})
-
-	// 文字色の設定（黒または赤）
-	textColor := color.RGBA{0, 0, 0, 255} // 黒
-	if p.Player == piece.Gote {
-		textColor = color.RGBA{200, 0, 0, 255} // 赤
+	upright := g.isUpright(p.Player)
+
+	// テーマに駒の画像があれば画像で描く
+	size := float32(2*radius) * pieceScale(p.Type)
+	if img := g.themeAssets.pieces[p.Type]; img != nil {
+		drawPieceImage(screen, img, float32(centerX), float32(centerY), size, upright)
+		return
+	}
+
+	// 駒の地（五角形または丸）
+	if g.theme.Shape == ShapeCircle {
+		ebitenutil.DrawCircle(screen,
+			float64(centerX),
+			float64(centerY),
+			float64(radius),
+			g.theme.Piece)
+	} else {
+		drawPentagon(screen, float32(centerX), float32(centerY), size, upright, g.theme.Piece, g.theme.PieceEdge)
 	}
 
-	// 駒の文字を描画（中央揃え）
+	// 文字色の設定（成駒は赤）
+	textColor := g.theme.Text
+	if p.Type.IsPromoted() {
+		textColor = g.theme.Promoted
+	}
+
+	// 駒の文字を描画（中央揃え、相手の駒は180度回す）
 	pieceStr := p.String()
 	bounds := text.BoundString(g.font, pieceStr)
-	text.Draw(screen, pieceStr, g.font,
-		centerX-bounds.Dx()/2,
-		centerY+bounds.Dy()/2,
-		textColor)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(bounds.Min.X+bounds.Max.X)/2, -float64(bounds.Min.Y+bounds.Max.Y)/2)
+	if !upright {
+		op.GeoM.Rotate(math.Pi)
+	}
+	op.GeoM.Translate(float64(centerX), float64(centerY))
+	op.ColorScale.ScaleWithColor(textColor)
+	text.DrawWithOptions(screen, pieceStr, g.font, op)
 }
 
 // 持ち駒を描画
@@ -251,7 +339,7 @@ func (g *Game) drawCapturedPieces(screen *ebiten.Image, player piece.Player,
 
 		p := piece.Piece{Type: pt, Player: player}
 		// Y位置を調整して、上部の"持駒"テキストの下から開始
-		yPos := area.Y + (i+1)*CellSize + 30
+		yPos := area.Y + (i+1)*area.Spacing + 30
 
 		if count > 0 {
 			g.drawPiece(screen, p,
@@ -0,0 +1,428 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"sort"
+
+	"shogi/board"
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// 局面編集パネルの定数
+const (
+	editorPaletteSize  = 42 // 駒を選ぶマスの大きさ
+	editorPaletteCols  = 6
+	editorButtonHeight = 32
+	editorLineHeight   = 22
+	editorSFENColumns  = 24 // SFENを折り返す文字数
+)
+
+// 局面編集パネルのボタン
+var editorButtons = []string{"手番交代", "初期局面", "盤を空に", "SFEN出力", "対局開始", "やめる"}
+
+// 局面編集の状態
+type editor struct {
+	types    []piece.Type // 置ける駒の種類（将棋の種類の初期局面に出てくる駒）
+	hand     []piece.Type // 持ち駒にできる駒の種類（持ち駒を打てない種類では空）
+	selected piece.Piece  // 空いたマスをクリックしたときに置く駒
+	problems []string     // 局面の問題点（なくなるまで対局開始・SFEN出力はできない）
+	sfen     string       // 書き出したSFEN
+
+	// やめたときに戻す局面
+	prevBoard   *board.Board
+	prevHistory []board.Move
+}
+
+// 局面編集を始める（表示中の局面から編集する）
+func (g *Game) StartEditor() {
+	e := &editor{
+		types:       editorPieceTypes(g.variant),
+		prevBoard:   g.board,
+		prevHistory: g.history,
+	}
+	if !g.variant.NoDrops {
+		e.hand = e.types[1:]
+	}
+	e.selected = piece.Piece{Type: e.types[0], Player: piece.Sente}
+
+	g.editor = e
+	g.board = g.board.Clone()
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+	g.editorChanged()
+}
+
+// 将棋の種類で使う駒（玉を先頭に、残りは駒の種類の順）
+func editorPieceTypes(v *board.Variant) []piece.Type {
+	b := v.New()
+	seen := map[piece.Type]bool{piece.King: true}
+	var types []piece.Type
+	add := func(t piece.Type) {
+		t = t.Unpromote()
+		if t != piece.Empty && !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			add(b.GetPiece(x, y).Type)
+		}
+	}
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		for _, t := range b.GetCaptures(player) {
+			add(t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return append([]piece.Type{piece.King}, types...)
+}
+
+// 局面を変えたら問題点を調べ直す
+func (g *Game) editorChanged() {
+	g.editor.problems = validatePosition(g.board)
+	g.editor.sfen = ""
+}
+
+// 局面編集の操作
+// 盤：左クリックで空いたマスに駒を置き、駒があれば成る・先後を入れ替える。右クリックで取り除く
+// 持ち駒エリア：左クリックで1枚増やし、右クリックで1枚減らす
+func (g *Game) handleEditorInput() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.cancelEditor()
+		return
+	}
+
+	left := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft)
+	right := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight)
+	if !left && !right {
+		return
+	}
+	x, y := g.state.MouseX, g.state.MouseY
+
+	if bx, by, ok := g.getBoardCoordinates(x, y); ok {
+		g.editSquare(bx, by, right)
+		return
+	}
+	if t, player, ok := g.editorHandAt(x, y); ok {
+		delta := 1
+		if right {
+			delta = -1
+		}
+		g.editHand(player, t, delta)
+		return
+	}
+	if !left {
+		return
+	}
+	if p, ok := g.editor.paletteAt(x, y); ok {
+		g.editor.selected = p
+		return
+	}
+	if i, ok := g.editor.buttonAt(x, y); ok {
+		g.pressEditorButton(i)
+	}
+}
+
+// 盤のマスを編集する
+func (g *Game) editSquare(x, y int, remove bool) {
+	p := g.board.GetPiece(x, y)
+	switch {
+	case remove:
+		p = piece.Piece{}
+	case p.Type == piece.Empty:
+		p = g.editor.selected
+	default:
+		p = nextEditPiece(p)
+	}
+	g.board.Grid[y][x] = p
+	g.editorChanged()
+}
+
+// クリックするたびに 先手 → 先手の成駒 → 後手 → 後手の成駒 → 先手 の順に変える
+func nextEditPiece(p piece.Piece) piece.Piece {
+	switch {
+	case !p.Type.IsPromoted() && p.Type.CanPromote():
+		return piece.Piece{Type: p.Type.Promote(), Player: p.Player}
+	case p.Player == piece.Sente:
+		return piece.Piece{Type: p.Type.Unpromote(), Player: piece.Gote}
+	default:
+		return piece.Piece{Type: p.Type.Unpromote(), Player: piece.Sente}
+	}
+}
+
+// 持ち駒の枚数を増減する
+func (g *Game) editHand(player piece.Player, t piece.Type, delta int) {
+	captures := g.board.SenteCaptures
+	if player == piece.Gote {
+		captures = g.board.GoteCaptures
+	}
+	captures[t] = max(captures[t]+delta, 0)
+	g.editorChanged()
+}
+
+// 局面編集パネルのボタンを押す
+func (g *Game) pressEditorButton(i int) {
+	e := g.editor
+	switch i {
+	case 0:
+		g.board.CurrentPlayer = g.board.CurrentPlayer.Opposite()
+		g.editorChanged()
+	case 1:
+		g.board = g.variant.New()
+		g.editorChanged()
+	case 2:
+		b := g.variant.New()
+		b.Grid = [board.BoardSize][board.BoardSize]piece.Piece{}
+		clear(b.SenteCaptures)
+		clear(b.GoteCaptures)
+		g.board = b
+		g.editorChanged()
+	case 3:
+		if len(e.problems) == 0 {
+			e.sfen = g.board.SFEN(1)
+			log.Println("SFEN:", e.sfen)
+		}
+	case 4:
+		g.finishEditor()
+	case 5:
+		g.cancelEditor()
+	}
+}
+
+// 編集した局面から対局を始める（問題点があれば始めない）
+func (g *Game) finishEditor() {
+	if len(g.editor.problems) > 0 {
+		return
+	}
+	g.editor = nil
+	g.replay = nil
+	g.start = g.board.Clone()
+	g.history = nil
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+}
+
+// 編集をやめて元の局面に戻る
+func (g *Game) cancelEditor() {
+	g.board = g.editor.prevBoard
+	g.history = g.editor.prevHistory
+	g.editor = nil
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+	g.updateCheckMessage()
+}
+
+// 局面の問題点の説明
+func validatePosition(b *board.Board) []string {
+	var problems []string
+	for _, p := range b.Validate() {
+		problems = append(problems, p.String())
+	}
+	return problems
+}
+
+// 指し手の表記で使う先手・後手の記号
+var playerMarks = map[piece.Player]string{
+	piece.Sente: "▲",
+	piece.Gote:  "△",
+}
+
+// 段の漢数字
+var rankNames = []string{"一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// マスの表記（例: 7六）
+func squareName(b *board.Board, x, y int) string {
+	return fmt.Sprintf("%d%s", b.Width()-x, rankNames[y])
+}
+
+// 駒を選ぶ欄の行数（先手・後手それぞれ）
+func (e *editor) paletteRows() int {
+	return (len(e.types) + editorPaletteCols - 1) / editorPaletteCols
+}
+
+// 駒を選ぶ欄の i 番目のマスの位置（後手の駒の下に先手の駒を並べる）
+func (e *editor) paletteRect(i int) (x, y, w, h int) {
+	return KifuPanelX + 5 + i%editorPaletteCols*editorPaletteSize,
+		BoardMarginY + kifuTitleHeight + i/editorPaletteCols*editorPaletteSize,
+		editorPaletteSize, editorPaletteSize
+}
+
+// 駒を選ぶ欄の i 番目のマスの駒
+func (e *editor) paletteAt(px, py int) (piece.Piece, bool) {
+	half := e.paletteRows() * editorPaletteCols
+	for i := 0; i < 2*half; i++ {
+		x, y, w, h := e.paletteRect(i)
+		if px < x || px >= x+w || py < y || py >= y+h {
+			continue
+		}
+		p := piece.Piece{Player: piece.Gote}
+		if i >= half {
+			i -= half
+			p.Player = piece.Sente
+		}
+		if i >= len(e.types) {
+			return piece.Piece{}, false
+		}
+		p.Type = e.types[i]
+		return p, true
+	}
+	return piece.Piece{}, false
+}
+
+// ボタンの位置（駒を選ぶ欄の下に2列で並べる）
+func (e *editor) buttonRect(i int) (x, y, w, h int) {
+	top := BoardMarginY + kifuTitleHeight + 2*e.paletteRows()*editorPaletteSize + 8
+	w = (KifuPanelWidth - 10) / 2
+	return KifuPanelX + 5 + i%2*w, top + i/2*(editorButtonHeight+4), w - 4, editorButtonHeight
+}
+
+// 座標にあるボタン
+func (e *editor) buttonAt(px, py int) (int, bool) {
+	for i := range editorButtons {
+		x, y, w, h := e.buttonRect(i)
+		if px >= x && px < x+w && py >= y && py < y+h {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// 持ち駒エリアの1行の高さ
+func (e *editor) handSpacing(area CaptureArea) int {
+	return min(area.Spacing, (area.Height-40)/max(len(e.hand), 1))
+}
+
+// 座標にある持ち駒の行
+func (g *Game) editorHandAt(x, y int) (piece.Type, piece.Player, bool) {
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		area := g.captureArea(player)
+		if !area.Contains(x, y) || y < area.Y+40 {
+			continue
+		}
+		i := (y - area.Y - 40) / g.editor.handSpacing(area)
+		if i < len(g.editor.hand) {
+			return g.editor.hand[i], player, true
+		}
+	}
+	return piece.Empty, piece.None, false
+}
+
+// 局面編集中の持ち駒エリア（駒の種類ごとに枚数を表示する）
+func (g *Game) drawEditorHands(screen *ebiten.Image) {
+	for _, player := range []piece.Player{piece.Sente, piece.Gote} {
+		area := g.captureArea(player)
+		ebitenutil.DrawRect(screen,
+			float64(area.X),
+			float64(area.Y),
+			float64(area.Width),
+			float64(area.Height),
+			g.theme.Hand)
+		text.Draw(screen, "持駒", g.font, area.X+area.Width/2-20, area.Y+25, g.theme.Label)
+
+		captures := g.board.SenteCaptures
+		if player == piece.Gote {
+			captures = g.board.GoteCaptures
+		}
+		spacing := g.editor.handSpacing(area)
+		for i, t := range g.editor.hand {
+			cy := area.Y + 40 + i*spacing + spacing/2
+			g.drawPieceSized(screen, piece.Piece{Type: t, Player: player}, area.X+35, cy,
+				min(spacing/2-3, g.cellSize()/2-5))
+			textColor := color.Color(g.theme.Label)
+			if captures[t] == 0 {
+				textColor = color.RGBA{150, 150, 150, 255}
+			}
+			text.Draw(screen, fmt.Sprintf("x%d", captures[t]), g.font, area.X+65, cy+7, textColor)
+		}
+	}
+}
+
+// 局面編集パネルを描画
+func (g *Game) drawEditorPanel(screen *ebiten.Image) {
+	e := g.editor
+	if e == nil {
+		return
+	}
+
+	// パネルの背景
+	ebitenutil.DrawRect(screen,
+		float64(KifuPanelX),
+		float64(BoardMarginY),
+		float64(KifuPanelWidth),
+		float64(boardAreaSize),
+		color.RGBA{245, 240, 230, 255})
+	text.Draw(screen, "局面編集", g.font, KifuPanelX+10, BoardMarginY+22, color.Black)
+
+	// 駒を選ぶ欄
+	half := e.paletteRows() * editorPaletteCols
+	for i, t := range e.types {
+		for _, slot := range []struct {
+			index  int
+			player piece.Player
+		}{{i, piece.Gote}, {half + i, piece.Sente}} {
+			x, y, w, h := e.paletteRect(slot.index)
+			p := piece.Piece{Type: t, Player: slot.player}
+			if p == e.selected {
+				ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h),
+					color.RGBA{255, 220, 100, 255})
+			}
+			g.drawPieceSized(screen, p, x+w/2, y+h/2, w/2-3)
+		}
+	}
+
+	// ボタン
+	for i, label := range editorButtons {
+		x, y, w, h := e.buttonRect(i)
+		buttonColor := color.RGBA{210, 200, 185, 255}
+		if (i == 3 || i == 4) && len(e.problems) > 0 {
+			buttonColor = color.RGBA{225, 220, 210, 255}
+		}
+		ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h), buttonColor)
+		bounds := text.BoundString(g.font, label)
+		text.Draw(screen, label, g.font,
+			x+w/2-bounds.Dx()/2,
+			y+h/2+bounds.Dy()/2,
+			color.Black)
+	}
+
+	// 問題点または書き出したSFEN
+	_, by, _, bh := e.buttonRect(len(editorButtons) - 1)
+	top := by + bh + editorLineHeight
+	bottom := BoardMarginY + boardAreaSize - 2*editorLineHeight
+	var lines []string
+	lineColor := color.Color(color.RGBA{200, 0, 0, 255})
+	switch {
+	case e.sfen != "":
+		lines = append(lines, "SFEN:")
+		for s := e.sfen; s != ""; {
+			n := min(len(s), editorSFENColumns)
+			lines = append(lines, s[:n])
+			s = s[n:]
+		}
+		lineColor = color.Black
+	default:
+		lines = e.problems
+	}
+	for i, line := range lines {
+		y := top + i*editorLineHeight
+		if y > bottom {
+			break
+		}
+		text.Draw(screen, line, g.font, KifuPanelX+10, y, lineColor)
+	}
+
+	// 操作の説明
+	help := []string{"左：置く・成る・先後", "右：取り除く（持駒は減らす）"}
+	for i, line := range help {
+		text.Draw(screen, line, g.font, KifuPanelX+10, bottom+(i+1)*editorLineHeight-4,
+			color.RGBA{100, 100, 100, 255})
+	}
+}
@@ -1,20 +1,25 @@
 package game
 
 import (
+	"log"
+	"time"
+
 	"shogi/board"
+	"shogi/book"
 	"shogi/piece"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"golang.org/x/image/font"
 )
 
 const (
-	ScreenWidth  = 1000
+	ScreenWidth  = 1200
 	ScreenHeight = 600
 	BoardMarginX = 200 // 左右のマージン
 
 	BoardMarginY = 20 // 上下のマージン（小さくする）
-	CellSize     = 60
+	CellSize     = 60 // 本将棋のマスの大きさ（盤が小さい種類では大きくする）
 
 	StateNormal   = iota // 通常状態
 	StateSelected        // 駒が選択された状態
@@ -25,15 +30,22 @@ const (
 	DragCapture        // 持ち駒をドラッグ中
 )
 
+// ドラッグを始めるまでにマウスを動かす距離（これより短ければクリック）
+const DragThreshold = 6
+
 // 持ち駒エリアの定数
 const (
 	CaptureAreaWidth  = 120 // 持ち駒エリアを少し広げる
 	CaptureAreaMargin = 40  // マージンを広げる
 )
 
+// 盤を描く領域の大きさ（本将棋の盤の大きさ）
+const boardAreaSize = board.BoardSize * CellSize
+
 // 持ち駒エリアの情報
 type CaptureArea struct {
 	X, Y, Width, Height int
+	Spacing             int // 持ち駒1枚分の高さ
 	Player              piece.Player
 }
 
@@ -45,52 +57,202 @@ type GameState struct {
 	MouseX         int
 	MouseY         int
 	Dragging       int
+	Pressing       bool // 選んだ駒の上でマウスボタンを押している（ドラッグかクリックかはまだ決まらない）
+	PressX         int  // マウスボタンを押した位置
+	PressY         int
+	Deselect       bool // 選んでいた駒を押したので、クリックで離したら選択をやめる
 	DragPieceType  piece.Type
 	DragPieceOwner piece.Player
 	Message        string
 	ValidMoves     [][2]int // 移動可能なマスの座標リスト
 }
 
+// ネットワーク対局などの相手との接続
+type Remote interface {
+	Side() piece.Player           // ローカル側の手番
+	Send(move board.Move) error   // ローカルの指し手を相手に送る
+	Updates() <-chan []board.Move // 確定した指し手リストの更新
+	Result() string               // 相手の投了などで終わったときの結果の表示（続いていれば空）
+}
+
 // ゲーム管理構造体
 type Game struct {
-	board         *board.Board
-	state         GameState
-	font          font.Face
-	largeFont     font.Face
-	senteCaptures CaptureArea
-	goteCaptures  CaptureArea
+	board       *board.Board
+	variant     *board.Variant          // 新しく始める対局の種類
+	start       *board.Board            // 新しく始める対局の開始局面（nilなら将棋の種類の初期局面）
+	history     []board.Move            // 初期局面からの指し手
+	remote      Remote                  // ネットワーク対局の相手（なければnil）
+	replay      *replay                 // 再生中の棋譜（なければnil）
+	book        *book.Book              // 定跡（なければnil）
+	showBook    bool                    // 定跡手のヒントを表示するか
+	editor      *editor                 // 局面編集中の状態（編集中でなければnil）
+	analysis    *analysis               // 検討中の状態（検討していなければnil）
+	flipped     bool                    // 盤を反転して後手を手前に表示するか
+	moveList    moveList                // 指し手一覧の表記
+	ticks       int                     // Update の呼び出し回数（点滅などのアニメーションに使う）
+	times       []time.Duration         // 各手の消費時間（history と同じ長さ）
+	moveStart   time.Time               // 今の手番が始まった時刻
+	players     map[piece.Player]string // 対局者の名前（保存する棋譜に書く）
+	autosave    *autosaver              // 自動保存の状態（自動保存しなければnil）
+	resume      *savedGame              // 再開するか確認中の対局（なければnil）
+	fileCommand *fileCommand            // 入力中の棋譜の保存・読み込み（なければnil）
+	cursor      cursor                  // キーボードで動かすカーソル
+	command     *string                 // 入力中のコマンド（入力していなければnil）
+	confirm     *confirmation           // 確認中の操作（なければnil）
+	effects     moveEffects             // 指し手のアニメーションと効果音
+	sounds      *sounds                 // 効果音（読み込んでいなければnil）
+	state       GameState
+	font        font.Face
+	largeFont   font.Face
+
+	// 表示のテーマと設定
+	theme         *Theme
+	themeAssets   *themeAssets
+	themes        []*Theme // 選べるテーマ
+	assetsDir     string
+	baseFont      font.Face // テーマでフォントを指定しないときのフォント
+	baseLargeFont font.Face
+	settings      Settings
 }
 
 // 新しいゲームを作成
 func NewGame(normalFont, largeFont font.Face) *Game {
-	boardWidth := board.BoardSize * CellSize
 	game := &Game{
-		board: board.New(),
+		board:   board.New(),
+		variant: board.Standard,
 		state: GameState{
 			State:     StateNormal,
 			SelectedX: -1,
 			SelectedY: -1,
 		},
-		font:      normalFont,
-		largeFont: largeFont,
-		senteCaptures: CaptureArea{
-			X:      BoardMarginX + boardWidth + CaptureAreaMargin,
-			Y:      BoardMarginY, // 変更
-			Width:  CaptureAreaWidth,
-			Height: CellSize * 7,
-			Player: piece.Sente,
-		},
-		goteCaptures: CaptureArea{
-			X:      BoardMarginX - CaptureAreaWidth - CaptureAreaMargin,
-			Y:      BoardMarginY, // 変更
-			Width:  CaptureAreaWidth,
-			Height: CellSize * 7,
-			Player: piece.Gote,
-		},
+		settings:      DefaultSettings(),
+		font:          normalFont,
+		largeFont:     largeFont,
+		theme:         Classic,
+		themeAssets:   &themeAssets{},
+		themes:        builtinThemes,
+		baseFont:      normalFont,
+		baseLargeFont: largeFont,
 	}
 	return game
 }
 
+// 将棋の種類を設定し、その初期局面から始める
+func (g *Game) SetVariant(v *board.Variant) {
+	g.variant = v
+	g.start = nil
+	g.board = v.New()
+	g.history = nil
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+}
+
+// 新しく始める対局の開始局面
+func (g *Game) newBoard() *board.Board {
+	if g.start != nil {
+		return g.start.Clone()
+	}
+	return g.variant.New()
+}
+
+// マスの大きさ（盤が小さい種類では大きく表示する）
+func (g *Game) cellSize() int {
+	size := boardAreaSize / max(g.board.Width(), g.board.Height())
+	return min(size, CellSize*3/2)
+}
+
+// 盤の左上の位置（盤を描く領域の中央に置く）
+func (g *Game) boardOrigin() (int, int) {
+	cell := g.cellSize()
+	return BoardMarginX + (boardAreaSize-g.board.Width()*cell)/2,
+		BoardMarginY + (boardAreaSize-g.board.Height()*cell)/2
+}
+
+// マスの左上の位置（盤を反転していれば180度回した位置）
+func (g *Game) squarePosition(x, y int) (int, int) {
+	ox, oy := g.boardOrigin()
+	cell := g.cellSize()
+	col, row := g.viewSquare(x, y)
+	return ox + col*cell, oy + row*cell
+}
+
+// 持ち駒エリア（手前のプレイヤーは盤の右、相手は盤の左）
+func (g *Game) captureArea(player piece.Player) CaptureArea {
+	cell := g.cellSize()
+	x, y := g.boardOrigin()
+	area := CaptureArea{
+		Y:       y,
+		Width:   CaptureAreaWidth,
+		Height:  g.board.Height() * cell,
+		Spacing: cell,
+		Player:  player,
+	}
+	if g.isUpright(player) {
+		area.X = x + g.board.Width()*cell + CaptureAreaMargin
+	} else {
+		area.X = x - CaptureAreaWidth - CaptureAreaMargin
+	}
+	return area
+}
+
+// ネットワーク対局やエンジンとの対局の相手を設定（ローカル側を手前に表示する）
+func (g *Game) SetRemote(r Remote) {
+	g.remote = r
+	g.SetPerspective(r.Side())
+}
+
+// ローカルのプレイヤーが操作できる手番か
+func (g *Game) isLocalTurn() bool {
+	if g.remote == nil {
+		return true
+	}
+	return g.board.CurrentPlayer == g.remote.Side()
+}
+
+// 相手から届いた指し手リストに盤面を合わせ、相手の投了などによる終局を反映する
+func (g *Game) syncRemote() {
+	select {
+	case moves := <-g.remote.Updates():
+		if equalMoves(moves, g.history) {
+			break
+		}
+		b := board.New()
+		for i, m := range moves {
+			if err := b.ApplyMove(m); err != nil {
+				log.Printf("相手の指し手リストの%d手目が指せない手です: %v", i+1, err)
+				g.state.Message = "相手の指し手が不正です"
+				return
+			}
+		}
+		g.board = b
+		g.history = moves
+		g.resetSelection()
+		g.updateCheckMessage()
+	default:
+	}
+
+	if g.state.State != StateGameOver {
+		if result := g.remote.Result(); result != "" {
+			g.resetSelection()
+			g.state.Message = result
+			g.state.State = StateGameOver
+		}
+	}
+}
+
+// 指し手リストが一致するか
+func equalMoves(a, b []board.Move) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // 座標が持ち駒エリア内かチェック
 func (ca *CaptureArea) Contains(x, y int) bool {
 	return x >= ca.X && x < ca.X+ca.Width &&
@@ -104,16 +266,20 @@ func (ca *CaptureArea) GetPieceIndex(x, y int) int {
 	}
 	// クリックされた位置から持ち駒のインデックスを計算
 	localY := y - ca.Y
-	return localY / CellSize
+	return localY / ca.Spacing
 }
 
 // マウス位置から盤上の座標を計算
 func (g *Game) getBoardCoordinates(x, y int) (int, int, bool) {
-	boardX := (x - BoardMarginX) / CellSize
-	boardY := (y - BoardMarginY) / CellSize
+	ox, oy := g.boardOrigin()
+	if x < ox || y < oy {
+		return -1, -1, false
+	}
+	col := (x - ox) / g.cellSize()
+	row := (y - oy) / g.cellSize()
 
-	if boardX >= 0 && boardX < board.BoardSize &&
-		boardY >= 0 && boardY < board.BoardSize {
+	if g.board.InBounds(col, row) {
+		boardX, boardY := g.viewSquare(col, row)
 		return boardX, boardY, true
 	}
 	return -1, -1, false
@@ -122,240 +288,135 @@ func (g *Game) getBoardCoordinates(x, y int) (int, int, bool) {
 // 持ち駒エリアの座標を取得
 func (g *Game) getCaptureCoordinates(x, y int) (int, piece.Player, bool) {
 	// 先手の持ち駒エリアをチェック
-	if index := g.senteCaptures.GetPieceIndex(x, y); index >= 0 {
+	sente := g.captureArea(piece.Sente)
+	if index := sente.GetPieceIndex(x, y); index >= 0 {
 		return index, piece.Sente, true
 	}
 
 	// 後手の持ち駒エリアをチェック
-	if index := g.goteCaptures.GetPieceIndex(x, y); index >= 0 {
+	gote := g.captureArea(piece.Gote)
+	if index := gote.GetPieceIndex(x, y); index >= 0 {
 		return index, piece.Gote, true
 	}
 
 	return -1, piece.None, false
 }
 
-// 持ち駒のインデックスから駒の種類を取得
+// 持ち駒のインデックスから駒の種類を取得（持ち駒エリアの表示と同じ並び）
 func (g *Game) getPieceTypeFromCaptureIndex(index int, player piece.Player) piece.Type {
-	if index < 0 || index >= 7 {
+	captures := g.board.GetCaptures(player)
+	if index < 0 || index >= len(captures) {
 		return piece.Empty
 	}
-
-	pieceTypes := []piece.Type{
-		piece.Pawn, piece.Lance, piece.Knight,
-		piece.Silver, piece.Gold, piece.Bishop, piece.Rook,
-	}
-
-	var count int
-	if player == piece.Sente {
-		count = g.board.SenteCaptures[pieceTypes[index]]
-	} else {
-		count = g.board.GoteCaptures[pieceTypes[index]]
-	}
-
-	if index < len(pieceTypes) && count > 0 {
-		return pieceTypes[index]
-	}
-	return piece.Empty
-}
-
-// 持ち駒をドラッグ開始
-func (g *Game) startCaptureDrag(x, y int) {
-	var targetArea *CaptureArea
-	if g.senteCaptures.Contains(x, y) {
-		targetArea = &g.senteCaptures
-	} else if g.goteCaptures.Contains(x, y) {
-		targetArea = &g.goteCaptures
-	}
-
-	if targetArea != nil {
-		index := targetArea.GetPieceIndex(x, y)
-		if index >= 0 {
-			captures := g.board.GetCaptures(targetArea.Player)
-			if index < len(captures) {
-				pieceType := captures[index]
-				if targetArea.Player == g.board.CurrentPlayer {
-					g.state.Dragging = DragCapture
-					g.state.DragPieceType = pieceType
-					g.state.DragPieceOwner = targetArea.Player
-					// 持ち駒の配置可能な位置を計算
-					g.state.ValidMoves = g.board.GetValidDropPositions(pieceType)
-				}
-			}
-		}
-	}
-}
-
-// 持ち駒の配置を試みる
-func (g *Game) tryDropPiece(x, y int) bool {
-	boardX, boardY, valid := g.getBoardCoordinates(x, y)
-	if !valid {
-		return false
-	}
-
-	// 移動可能な位置かチェック
-	validPosition := false
-	for _, pos := range g.state.ValidMoves {
-		if pos[0] == boardX && pos[1] == boardY {
-			validPosition = true
-			break
-		}
-	}
-
-	if validPosition {
-		// 持ち駒を配置
-		g.board.DropPiece(boardX, boardY, g.state.DragPieceType)
-		return true
-	}
-	return false
+	return captures[index]
 }
 
 // 入力の更新処理
 func (g *Game) Update() error {
 	// マウス位置の更新
 	g.state.MouseX, g.state.MouseY = ebiten.CursorPosition()
+	g.ticks++
 
-	// ゲームオーバー状態の場合
-	if g.state.State == StateGameOver {
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-			// クリックで新しいゲームを開始
-			g.board = board.New()
-			g.state = GameState{State: StateNormal}
-		}
+	// 前回の対局を再開するかの確認と、ファイル名の入力中はそれだけを受け付ける
+	if g.resume != nil {
+		g.handleResumeInput()
+		return nil
+	}
+	if g.fileCommand != nil {
+		g.handleFileCommandInput()
+		return nil
+	}
+	if g.command != nil {
+		g.handleCommandInput()
+		return nil
+	}
+	if g.confirm != nil {
+		g.handleConfirmInput()
 		return nil
 	}
+	g.handleFileShortcuts()
 
-	// マウスの入力処理
-	g.handleMouseInput()
+	// 盤の反転とテーマの切り替え
+	g.handleFlipInput()
+	g.handleThemeInput()
+	g.handleSoundInput()
 
-	return nil
-}
+	// 検討の表示切り替えと、局面が変わったときの探索のやり直し
+	g.handleAnalysisInput()
+	g.updateAnalysis()
 
-// マウス入力の処理
-func (g *Game) handleMouseInput() {
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		if g.state.Dragging == DragNone {
-			g.handleMousePress()
-		}
-	} else {
-		if g.state.Dragging != DragNone {
-			g.handleMouseRelease()
-		}
-	}
-}
+	// 指し手一覧の表記を最新にする
+	g.updateMoveList()
 
-// マウスボタン押下時の処理
-func (g *Game) handleMousePress() {
-	// 盤上の座標を取得
-	boardX, boardY, onBoard := g.getBoardCoordinates(g.state.MouseX, g.state.MouseY)
+	// 消費時間の記録と自動保存、指し手のアニメーションと効果音
+	g.updateClock()
+	g.updateAutosave()
+	g.updateEffects()
 
-	if onBoard {
-		g.handleBoardPress(boardX, boardY)
-	} else {
-		// 持ち駒エリアの処理
-		index, player, inCaptureArea := g.getCaptureCoordinates(g.state.MouseX, g.state.MouseY)
-		if inCaptureArea && player == g.board.CurrentPlayer {
-			// テスト用に持ち駒の位置を出力
-			println("Capture area clicked:", index, player)
-			pieceType := g.getPieceTypeFromCaptureIndex(index, player)
-			if pieceType != piece.Empty {
-				// 持ち駒をドラッグ開始
-				g.state.State = StateSelected
-				g.state.Dragging = DragCapture
-				g.state.DragPieceType = pieceType
-				g.state.DragPieceOwner = player
-				g.state.ValidMoves = g.board.GetValidDropPositions(pieceType)
-				// テスト用に有効な移動位置の数を出力
-				println("Valid moves:", len(g.state.ValidMoves))
-			}
-		}
+	// 局面編集中は編集の操作だけを受け付ける
+	if g.editor != nil {
+		g.handleEditorInput()
+		return nil
 	}
-}
 
-// 盤上でのマウス押下処理
-func (g *Game) handleBoardPress(x, y int) {
-	p := g.board.GetPiece(x, y)
-
-	if g.state.State == StateNormal {
-		// 自分の駒を選択
-		if p.Type != piece.Empty && p.Player == g.board.CurrentPlayer {
-			g.state.SelectedX = x
-			g.state.SelectedY = y
-			g.state.State = StateSelected
-			g.state.Dragging = DragBoard
-			g.state.DragPieceType = p.Type
-			g.state.DragPieceOwner = p.Player
-			g.calculateValidMoves() // 移動可能なマスを計算
-		}
-	} else if g.state.State == StateSelected {
-		// 移動先の選択
-		move := board.Move{
-			FromX: g.state.SelectedX,
-			FromY: g.state.SelectedY,
-			ToX:   x,
-			ToY:   y,
-		}
-
-		// 同じ位置なら選択解除
-		if g.state.SelectedX == x && g.state.SelectedY == y {
-			g.resetSelection()
-			return
-		}
+	// ネットワーク対局の相手の指し手を反映
+	if g.remote != nil {
+		g.syncRemote()
+	} else {
+		// ドロップされた棋譜ファイルを読み込む
+		g.handleDroppedFiles()
+	}
 
-		// 移動処理
-		if g.board.IsValidMove(move) {
-			g.handleMove(move)
-		} else if p.Type != piece.Empty && p.Player == g.board.CurrentPlayer {
-			// 無効な移動先なら、新しい駒を選択
-			g.state.SelectedX = x
-			g.state.SelectedY = y
-			g.state.Dragging = DragBoard
-			g.state.DragPieceType = p.Type
-			g.state.DragPieceOwner = p.Player
-		} else {
-			g.resetSelection()
-		}
+	// 棋譜の再生操作
+	if g.replay != nil {
+		g.handleReplayInput()
 	}
-}
 
-// マウスボタン解放時の処理
-func (g *Game) handleMouseRelease() {
-	if g.state.Dragging == DragNone {
-		return
+	// 定跡手のヒントの表示切り替え
+	g.handleBookInput()
+
+	// 一手戻す・投了・新しい対局・コマンド入力のショートカット
+	g.handleShortcutInput()
+	if g.command != nil {
+		return nil
 	}
 
-	boardX, boardY, onBoard := g.getBoardCoordinates(g.state.MouseX, g.state.MouseY)
-	if !onBoard {
-		g.resetSelection()
-		return
+	// 局面編集を始める（ネットワーク対局中はできない）
+	if g.remote == nil && inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		g.StartEditor()
+		return nil
 	}
 
-	if g.state.Dragging == DragCapture {
-		move := board.Move{
-			FromX: -1,
-			FromY: -1,
-			ToX:   boardX,
-			ToY:   boardY,
-			Piece: g.state.DragPieceType,
+	// ゲームオーバー状態の場合
+	if g.state.State == StateGameOver {
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			if g.remote != nil || g.replay != nil {
+				// ネットワーク対局では盤面を共有し、棋譜の再生中は棋譜に戻れるよう表示を閉じるだけ
+				g.state = GameState{State: StateNormal}
+				g.resetSelection()
+			} else {
+				// クリックで新しいゲームを開始
+				g.newGame()
+			}
 		}
+		return nil
+	}
 
-		if g.board.IsValidMove(move) {
-			g.board.DropPiece(boardX, boardY, g.state.DragPieceType)
-		}
-	} else if g.state.Dragging == DragBoard {
-		// 盤上の駒の移動処理は変更なし
-		move := board.Move{
-			FromX: g.state.SelectedX,
-			FromY: g.state.SelectedY,
-			ToX:   boardX,
-			ToY:   boardY,
-		}
+	// キーボードの入力処理
+	g.handleKeyboardInput()
 
-		if g.board.IsValidMove(move) {
-			g.handleMove(move)
+	// 相手の手番中は操作を受け付けない
+	if !g.isLocalTurn() {
+		if g.state.Dragging != DragNone || g.state.State == StateSelected {
+			g.resetSelection()
 		}
+		return nil
 	}
 
-	g.resetSelection()
+	// マウスの入力処理
+	g.handleMouseInput()
+
+	return nil
 }
 
 // 移動の実行
@@ -364,19 +425,52 @@ func (g *Game) handleMove(move board.Move) {
 	if g.shouldPromote(move) {
 		move.Promote = true
 	}
+	g.playMove(move)
+}
+
+// 指し手を指す（成るかどうかは指し手のとおり）
+func (g *Game) playMove(move board.Move) {
+	// ネットワーク対局では相手に送信（拒否されたら指さない）
+	if g.remote != nil {
+		if err := g.remote.Send(move); err != nil {
+			g.state.Message = "送信できません"
+			g.resetSelection()
+			return
+		}
+	}
 
 	// 移動を実行
 	g.board.MakeMove(move)
+	g.history = append(g.history, move)
+	if g.replay != nil {
+		g.replay.follow(move)
+	}
 
 	// 王手判定
+	g.updateCheckMessage()
+
+	g.resetSelection()
+}
+
+// 王手判定してメッセージを更新
+func (g *Game) updateCheckMessage() {
+	// 将棋の種類ごとの勝ちの条件（トライなど）
+	if winner := g.board.Winner(); winner != piece.None {
+		g.state.Message = "先手の勝ち"
+		if winner == piece.Gote {
+			g.state.Message = "後手の勝ち"
+		}
+		if g.replay == nil || g.replay.branched {
+			g.state.State = StateGameOver
+		}
+		return
+	}
+
 	if g.board.IsCheck() {
 		g.state.Message = "王手！"
-		g.state.State = StateGameOver
 	} else {
 		g.state.Message = ""
 	}
-
-	g.resetSelection()
 }
 
 // 選択状態のリセット
@@ -385,6 +479,7 @@ func (g *Game) resetSelection() {
 	g.state.SelectedX = -1
 	g.state.SelectedY = -1
 	g.state.Dragging = DragNone
+	g.state.Pressing = false
 	g.state.ValidMoves = nil // 移動可能なマスをクリア
 }
 
@@ -400,75 +495,8 @@ func (g *Game) shouldPromote(move board.Move) bool {
 		return false
 	}
 
-	// 強制的な成り
-	if g.board.CurrentPlayer == piece.Sente {
-		if (p.Type == piece.Pawn || p.Type == piece.Lance) && move.ToY == 0 {
-			return true
-		}
-		if p.Type == piece.Knight && move.ToY <= 1 {
-			return true
-		}
-	} else {
-		if (p.Type == piece.Pawn || p.Type == piece.Lance) && move.ToY == board.BoardSize-1 {
-			return true
-		}
-		if p.Type == piece.Knight && move.ToY >= board.BoardSize-2 {
-			return true
-		}
-	}
-
-	// 成れる場所での成り判定
-	// 実装では常に成ることにします（実際のゲームではダイアログ等で確認が必要）
-	canPromote := false
-	if g.board.CurrentPlayer == piece.Sente {
-		if move.FromY <= 2 || move.ToY <= 2 {
-			canPromote = true
-		}
-	} else {
-		if move.FromY >= 6 || move.ToY >= 6 {
-			canPromote = true
-		}
-	}
-
-	return canPromote
-}
-
-// 移動可能なマスを計算
-func (g *Game) calculateValidMoves() {
-	g.state.ValidMoves = nil
-	if g.state.Dragging == DragNone {
-		return
-	}
-
-	// 盤上の全てのマスをチェック
-	for y := 0; y < board.BoardSize; y++ {
-		for x := 0; x < board.BoardSize; x++ {
-			var move board.Move
-			if g.state.Dragging == DragBoard {
-				move = board.Move{
-					FromX: g.state.SelectedX,
-					FromY: g.state.SelectedY,
-					ToX:   x,
-					ToY:   y,
-				}
-			} else if g.state.Dragging == DragCapture {
-				move = board.Move{
-					FromX: -1,
-					FromY: -1,
-					ToX:   x,
-					ToY:   y,
-					Piece: g.state.DragPieceType,
-				}
-			}
-
-			// 移動が有効な場合、座標を追加
-			if g.board.IsValidMove(move) {
-				if g.state.Dragging == DragBoard &&
-					x == g.state.SelectedX && y == g.state.SelectedY {
-					continue // 同じ場所は除外
-				}
-				g.state.ValidMoves = append(g.state.ValidMoves, [2]int{x, y})
-			}
-		}
-	}
+	// 成れる手なら常に成る（実際のゲームではダイアログ等で確認が必要）
+	// 強制的な成りや敵陣の段数、自玉が取られないかは将棋の種類に合わせて盤が判定する
+	move.Promote = true
+	return g.board.CheckMove(move) == nil
 }
@@ -0,0 +1,198 @@
+package game
+
+import (
+	"shogi/board"
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// マウス入力の処理
+//
+// 駒（盤上の駒か持ち駒）を押すと選び、押したまま DragThreshold より動かすとドラッグになる。
+// ドラッグして離したマスに指せればそこに指し、指せなければ選んだままにする。
+// 動かさずに離したときはクリックで、選んだまま次に移動先をクリックすれば指す。
+// 選んでいた駒をもう一度クリックするか、右クリックで選択をやめる
+func (g *Game) handleMouseInput() {
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
+		g.resetSelection()
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		g.handleMousePress()
+	case ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		g.handleMouseHold()
+	case g.state.Pressing:
+		g.handleMouseRelease()
+	}
+}
+
+// マウスボタン押下時の処理
+func (g *Game) handleMousePress() {
+	// マウスで操作し始めたらキーボードのカーソルは隠す
+	g.cursor.visible = false
+
+	mx, my := g.state.MouseX, g.state.MouseY
+	if x, y, onBoard := g.getBoardCoordinates(mx, my); onBoard {
+		// 選んだ駒の移動先なら指す
+		if g.tryMoveSelected(x, y) {
+			return
+		}
+		if p := g.board.GetPiece(x, y); p.Type != piece.Empty && p.Player == g.board.CurrentPlayer {
+			again := g.isSelectedSquare(x, y)
+			g.selectSquare(x, y)
+			g.startPress(again)
+			return
+		}
+	} else if index, player, ok := g.getCaptureCoordinates(mx, my); ok && player == g.board.CurrentPlayer {
+		if t := g.getPieceTypeFromCaptureIndex(index, player); t != piece.Empty {
+			again := g.isSelectedHand(t)
+			g.selectHand(t)
+			g.startPress(again)
+			return
+		}
+	}
+
+	// 駒でも移動先でもないところを押したら選択をやめる
+	g.resetSelection()
+}
+
+// 選んだ駒の上で押し始める
+func (g *Game) startPress(deselect bool) {
+	g.state.Pressing = true
+	g.state.PressX, g.state.PressY = g.state.MouseX, g.state.MouseY
+	g.state.Deselect = deselect
+}
+
+// 押したままマウスを動かしたら、離れた距離でドラッグを始める
+func (g *Game) handleMouseHold() {
+	if !g.state.Pressing || g.state.Dragging != DragNone {
+		return
+	}
+	dx, dy := g.state.MouseX-g.state.PressX, g.state.MouseY-g.state.PressY
+	if dx*dx+dy*dy < DragThreshold*DragThreshold {
+		return
+	}
+	if g.state.SelectedX < 0 {
+		g.state.Dragging = DragCapture
+	} else {
+		g.state.Dragging = DragBoard
+	}
+}
+
+// マウスボタン解放時の処理
+func (g *Game) handleMouseRelease() {
+	g.state.Pressing = false
+
+	// クリックなら選んだままにする（選んでいた駒のクリックなら選択をやめる）
+	if g.state.Dragging == DragNone {
+		if g.state.Deselect {
+			g.resetSelection()
+		}
+		return
+	}
+
+	// ドラッグを離したマスに指せれば指す（指せなければ駒を戻して選んだままにする）
+	g.state.Dragging = DragNone
+	if x, y, onBoard := g.getBoardCoordinates(g.state.MouseX, g.state.MouseY); onBoard {
+		if g.tryMoveSelected(x, y) {
+			g.effects.dragged = true
+		}
+	}
+}
+
+// 盤上の駒を選ぶ
+func (g *Game) selectSquare(x, y int) {
+	p := g.board.GetPiece(x, y)
+	g.resetSelection()
+	g.state.State = StateSelected
+	g.state.SelectedX = x
+	g.state.SelectedY = y
+	g.state.DragPieceType = p.Type
+	g.state.DragPieceOwner = p.Player
+	g.calculateValidMoves()
+}
+
+// 手番の側の持ち駒を選ぶ
+func (g *Game) selectHand(t piece.Type) {
+	g.resetSelection()
+	g.state.State = StateSelected
+	g.state.DragPieceType = t
+	g.state.DragPieceOwner = g.board.CurrentPlayer
+	g.calculateValidMoves()
+}
+
+// 盤上のその駒を選んでいるか
+func (g *Game) isSelectedSquare(x, y int) bool {
+	return g.state.State == StateSelected && g.state.SelectedX == x && g.state.SelectedY == y
+}
+
+// その種類の持ち駒を選んでいるか
+func (g *Game) isSelectedHand(t piece.Type) bool {
+	return g.state.State == StateSelected && g.state.SelectedX < 0 && g.state.DragPieceType == t
+}
+
+// 選んだ駒をそのマスに指す（移動可能なマスでなければ指さずに false を返す）
+func (g *Game) tryMoveSelected(x, y int) bool {
+	if g.state.State != StateSelected {
+		return false
+	}
+	valid := false
+	for _, pos := range g.state.ValidMoves {
+		if pos[0] == x && pos[1] == y {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return false
+	}
+
+	move := board.Move{FromX: g.state.SelectedX, FromY: g.state.SelectedY, ToX: x, ToY: y}
+	if g.state.SelectedX < 0 {
+		move = board.Move{FromX: -1, FromY: -1, ToX: x, ToY: y, Piece: g.state.DragPieceType}
+	}
+	g.handleMove(move)
+	return true
+}
+
+// 選んだ駒の移動可能なマスを計算
+// コマンド入力と同じく CheckMove で調べるので、王手を放置する手や打ち歩詰めのマスは含めない
+func (g *Game) calculateValidMoves() {
+	g.state.ValidMoves = nil
+	if g.state.State != StateSelected {
+		return
+	}
+
+	// 持ち駒なら打てるマス
+	if g.state.SelectedX < 0 {
+		for _, pos := range g.board.GetValidDropPositions(g.state.DragPieceType) {
+			move := board.Move{FromX: -1, FromY: -1, ToX: pos[0], ToY: pos[1], Piece: g.state.DragPieceType}
+			if g.board.CheckMove(move) == nil {
+				g.state.ValidMoves = append(g.state.ValidMoves, pos)
+			}
+		}
+		return
+	}
+
+	// 盤上の全てのマスをチェック
+	for y := 0; y < g.board.Height(); y++ {
+		for x := 0; x < g.board.Width(); x++ {
+			if x == g.state.SelectedX && y == g.state.SelectedY {
+				continue // 同じ場所は除外
+			}
+			move := board.Move{
+				FromX: g.state.SelectedX,
+				FromY: g.state.SelectedY,
+				ToX:   x,
+				ToY:   y,
+			}
+			// 成らなければ指せないマス（行き所のない駒）も移動先に含める
+			promoted := move
+			promoted.Promote = true
+			if g.board.CheckMove(move) == nil || g.board.CheckMove(promoted) == nil {
+				g.state.ValidMoves = append(g.state.ValidMoves, [2]int{x, y})
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package game
+
+import (
+	"image/color"
+	"log"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/usi"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// キーボードで動かす盤上のカーソル
+type cursor struct {
+	x, y    int  // 盤上の座標
+	visible bool // キーボードで操作し始めたら表示する
+}
+
+// カーソルを動かすキー（矢印キーと vi の hjkl）と、画面上の向き
+var cursorKeys = []struct {
+	key    ebiten.Key
+	arrow  bool // 矢印キーか（棋譜の再生中は再生の操作に使う）
+	dx, dy int
+}{
+	{ebiten.KeyArrowLeft, true, -1, 0},
+	{ebiten.KeyArrowRight, true, 1, 0},
+	{ebiten.KeyArrowUp, true, 0, -1},
+	{ebiten.KeyArrowDown, true, 0, 1},
+	{ebiten.KeyH, false, -1, 0},
+	{ebiten.KeyL, false, 1, 0},
+	{ebiten.KeyK, false, 0, -1},
+	{ebiten.KeyJ, false, 0, 1},
+}
+
+// 1行の文字入力（Enter で確定、Esc でやめる）
+func readLine(s string) (line string, enter, cancel bool) {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return s, false, true
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		return s, true, false
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		if r := []rune(s); len(r) > 0 {
+			s = string(r[:len(r)-1])
+		}
+		return s, false, false
+	}
+	return s + string(ebiten.AppendInputChars(nil)), false, false
+}
+
+// 確認してから行う操作
+type confirmation struct {
+	message string
+	action  func()
+}
+
+// 対局の操作のショートカット（U: 一手戻す、R: 投了、N: 新しい対局、/: コマンド入力）
+// 投了と新しい対局は、押し間違えで対局を失わないよう確認してから行う
+func (g *Game) handleShortcutInput() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyU):
+		g.undo()
+	case inpututil.IsKeyJustPressed(ebiten.KeyR):
+		if g.remote == nil && g.state.State != StateGameOver {
+			g.confirm = &confirmation{message: "投了しますか？", action: g.resign}
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyN):
+		if g.remote == nil {
+			g.confirm = &confirmation{message: "新しい対局を始めますか？", action: g.newGame}
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeySlash):
+		g.command = new(string)
+	}
+}
+
+// 操作の確認（Y キーか Enter で行い、N キーか Esc でやめる）
+func (g *Game) handleConfirmInput() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyY) || inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		action := g.confirm.action
+		g.confirm = nil
+		action()
+	case inpututil.IsKeyJustPressed(ebiten.KeyN) || inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.confirm = nil
+	}
+}
+
+// 一手戻す（ネットワーク対局・エンジンとの対局・棋譜の再生中はできない）
+func (g *Game) undo() {
+	if g.remote != nil || g.replay != nil || len(g.history) == 0 {
+		return
+	}
+	moves := g.history[:len(g.history)-1]
+	b := g.historyStart()
+	for _, m := range moves {
+		b.MakeMove(m)
+	}
+	g.board = b
+	g.history = append([]board.Move(nil), moves...)
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+	g.updateCheckMessage()
+}
+
+// 手番の側が投了する（ネットワーク対局・エンジンとの対局では相手に伝えられないのでできない）
+func (g *Game) resign() {
+	if g.remote != nil || g.state.State == StateGameOver {
+		return
+	}
+	g.resetSelection()
+	g.state.Message = "先手の投了"
+	if g.board.CurrentPlayer == piece.Gote {
+		g.state.Message = "後手の投了"
+	}
+	g.state.State = StateGameOver
+}
+
+// 新しい対局を始める（ネットワーク対局・エンジンとの対局ではできない）
+// 自動保存は新しい対局で指すまで残すので、間違えて始めても次回の起動時に再開できる
+func (g *Game) newGame() {
+	if g.remote != nil {
+		return
+	}
+	g.replay = nil
+	g.board = g.newBoard()
+	g.history = nil
+	g.times = nil
+	g.moveStart = time.Now()
+	g.effects = moveEffects{}
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+}
+
+// キーボードでの指し手の入力
+// カーソルを動かし、Enter（Space）で駒を選んで移動先に指す。Tab で持ち駒を選び、Esc で選択をやめる
+func (g *Game) handleKeyboardInput() {
+	for _, k := range cursorKeys {
+		if k.arrow && g.replay != nil {
+			continue
+		}
+		if inpututil.IsKeyJustPressed(k.key) {
+			g.moveCursor(k.dx, k.dy)
+		}
+	}
+
+	if !g.isLocalTurn() {
+		return
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		g.showCursor()
+		g.selectCursor()
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab):
+		g.showCursor()
+		g.selectNextHand()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.resetSelection()
+	}
+}
+
+// カーソルを表示する（初めは盤の中央に置く）
+func (g *Game) showCursor() {
+	if !g.cursor.visible {
+		g.cursor = cursor{x: g.board.Width() / 2, y: g.board.Height() / 2, visible: true}
+	}
+}
+
+// カーソルを画面上の向きに動かす（盤を反転していても見た目の向きに動く）
+func (g *Game) moveCursor(dx, dy int) {
+	if !g.cursor.visible {
+		g.showCursor()
+		return
+	}
+	col, row := g.viewSquare(g.cursor.x, g.cursor.y)
+	col = min(max(col+dx, 0), g.board.Width()-1)
+	row = min(max(row+dy, 0), g.board.Height()-1)
+	g.cursor.x, g.cursor.y = g.viewSquare(col, row)
+}
+
+// カーソルのマスで駒を選ぶか、選んだ駒をカーソルのマスに指す
+func (g *Game) selectCursor() {
+	x, y := g.cursor.x, g.cursor.y
+	if !g.board.InBounds(x, y) {
+		return
+	}
+
+	// 選んだ駒の移動先なら指す
+	if g.tryMoveSelected(x, y) {
+		return
+	}
+	// 自分の駒なら選ぶ（選んでいた駒なら選択をやめる）
+	if p := g.board.GetPiece(x, y); p.Type != piece.Empty && p.Player == g.board.CurrentPlayer && !g.isSelectedSquare(x, y) {
+		g.selectSquare(x, y)
+		return
+	}
+	g.resetSelection()
+}
+
+// 手番の側の持ち駒を順に選ぶ
+func (g *Game) selectNextHand() {
+	captures := g.board.GetCaptures(g.board.CurrentPlayer)
+	if len(captures) == 0 {
+		return
+	}
+	next := 0
+	if g.state.State == StateSelected && g.state.SelectedX < 0 {
+		for i, t := range captures {
+			if t == g.state.DragPieceType {
+				next = (i + 1) % len(captures)
+			}
+		}
+	}
+	g.selectHand(captures[next])
+}
+
+// コマンドの入力（USI形式の指し手か、undo・resign・new・flip）
+func (g *Game) handleCommandInput() {
+	line, enter, cancel := readLine(*g.command)
+	*g.command = line
+	if cancel {
+		g.command = nil
+		return
+	}
+	if !enter {
+		return
+	}
+	g.command = nil
+	g.runCommand(strings.TrimSpace(line))
+}
+
+// 入力したコマンドを実行
+func (g *Game) runCommand(line string) {
+	switch line {
+	case "":
+	case "undo":
+		g.undo()
+	case "resign":
+		g.resign()
+	case "new":
+		g.newGame()
+	case "flip":
+		g.flipped = !g.flipped
+	default:
+		move, err := usi.ParseMoveOn(g.board, line)
+		if err != nil {
+			g.state.Message = "指し手を読めません"
+			return
+		}
+		if g.editor != nil || g.state.State == StateGameOver || !g.isLocalTurn() {
+			g.state.Message = "今は指せません"
+			return
+		}
+		if err := g.board.CheckMove(move); err != nil {
+			log.Println(err)
+			g.state.Message = "指せない手です"
+			return
+		}
+		// 成るかどうかも入力どおりにする
+		g.playMove(move)
+	}
+}
+
+// キーボードのカーソルを描画
+func (g *Game) drawCursor(screen *ebiten.Image) {
+	if !g.cursor.visible || g.editor != nil || !g.board.InBounds(g.cursor.x, g.cursor.y) {
+		return
+	}
+	x, y := g.squarePosition(g.cursor.x, g.cursor.y)
+	cell := g.cellSize()
+	vector.StrokeRect(screen, float32(x)+1.5, float32(y)+1.5, float32(cell)-3, float32(cell)-3, 3,
+		color.RGBA{0, 120, 255, 255}, false)
+}
+
+// 選んだ持ち駒のハイライト表示
+func (g *Game) drawHandSelection(screen *ebiten.Image, area *CaptureArea, index int) {
+	ebitenutil.DrawRect(screen,
+		float64(area.X),
+		float64(area.Y+40+index*area.Spacing-area.Spacing/2),
+		float64(area.Width),
+		float64(area.Spacing),
+		color.RGBA{255, 255, 0, 128})
+}
+
+// コマンドの入力欄を描画
+func (g *Game) drawCommandBar(screen *ebiten.Image) {
+	if g.command == nil {
+		return
+	}
+	ebitenutil.DrawRect(screen, float64(BoardMarginX), ScreenHeight-45, float64(boardAreaSize), 35, color.RGBA{0, 0, 0, 220})
+	text.Draw(screen, "> "+*g.command+"_", g.font, BoardMarginX+10, ScreenHeight-20, color.White)
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"shogi/board"
+	"shogi/notation"
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// 指し手一覧の表記（指し手リストが変わったときだけ作り直す）
+type moveList struct {
+	moves []board.Move
+	lines []string
+}
+
+// 指し手リストの開始局面
+func (g *Game) historyStart() *board.Board {
+	if g.replay != nil {
+		return g.replay.start.Clone()
+	}
+	return g.newBoard()
+}
+
+// 指し手一覧の表記を指し手リストに合わせる
+func (g *Game) updateMoveList() {
+	if equalMoves(g.moveList.moves, g.history) {
+		return
+	}
+	b := g.historyStart()
+	lines := make([]string, 0, len(g.history))
+	var prev *board.Move
+	for i, m := range g.history {
+		lines = append(lines, formatMove(b, m, prev))
+		b.MakeMove(m)
+		prev = &g.history[i]
+	}
+	g.moveList = moveList{moves: append([]board.Move(nil), g.history...), lines: lines}
+}
+
+// 指し手の日本式の表記（例: ▲７六歩）。指す前の盤面を渡す
+// 本将棋以外の種類では筋・段と駒の文字だけで表す（例: ▲3二飛成、▲2三麒打）
+func formatMove(b *board.Board, m board.Move, prev *board.Move) string {
+	if b.Variant == nil {
+		return notation.FormatJapanese(b, m, prev)
+	}
+
+	s := playerMarks[b.CurrentPlayer]
+	if prev != nil && prev.ToX == m.ToX && prev.ToY == m.ToY {
+		s += "同"
+	} else {
+		s += squareName(b, m.ToX, m.ToY)
+	}
+	if m.FromX < 0 {
+		return s + piece.Piece{Type: m.Piece, Player: b.CurrentPlayer}.String() + "打"
+	}
+	s += b.GetPiece(m.FromX, m.FromY).String()
+	if m.Promote {
+		s += "成"
+	}
+	return s
+}
+
+// 最後に指した手の移動元・移動先をハイライト表示
+func (g *Game) drawLastMove(screen *ebiten.Image) {
+	if len(g.history) == 0 || g.editor != nil {
+		return
+	}
+	m := g.history[len(g.history)-1]
+	cell := g.cellSize()
+	if m.FromX >= 0 && m.FromY >= 0 {
+		x, y := g.squarePosition(m.FromX, m.FromY)
+		ebitenutil.DrawRect(screen,
+			float64(x),
+			float64(y),
+			float64(cell),
+			float64(cell),
+			color.RGBA{255, 160, 0, 48})
+	}
+	x, y := g.squarePosition(m.ToX, m.ToY)
+	ebitenutil.DrawRect(screen,
+		float64(x),
+		float64(y),
+		float64(cell),
+		float64(cell),
+		color.RGBA{255, 160, 0, 112})
+}
+
+// 王手をかけられている玉のマスを点滅させる
+func (g *Game) drawCheckHighlight(screen *ebiten.Image) {
+	if g.editor != nil || !g.board.IsCheck() {
+		return
+	}
+	for y := 0; y < g.board.Height(); y++ {
+		for x := 0; x < g.board.Width(); x++ {
+			p := g.board.GetPiece(x, y)
+			if p.Type != piece.King || p.Player != g.board.CurrentPlayer {
+				continue
+			}
+			// 約1秒周期で濃さを変える
+			alpha := 110 + 70*math.Sin(float64(g.ticks)*2*math.Pi/60)
+			sx, sy := g.squarePosition(x, y)
+			cell := g.cellSize()
+			ebitenutil.DrawRect(screen,
+				float64(sx),
+				float64(sy),
+				float64(cell),
+				float64(cell),
+				color.RGBA{230, 0, 0, uint8(alpha)})
+		}
+	}
+}
+
+// 対局中の指し手一覧を描画（最新の手が見えるように末尾を表示する）
+func (g *Game) drawMoveList(screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen,
+		float64(KifuPanelX),
+		float64(BoardMarginY),
+		float64(KifuPanelWidth),
+		float64(boardAreaSize),
+		color.RGBA{245, 240, 230, 255})
+	text.Draw(screen, "棋譜", g.font, KifuPanelX+10, BoardMarginY+22, color.Black)
+
+	lines := g.moveList.lines
+	rows := (boardAreaSize - kifuTitleHeight - 8) / KifuRowHeight
+	first := max(len(lines)-rows, 0)
+	top := BoardMarginY + kifuTitleHeight
+	for i, line := range lines[first:] {
+		y := top + i*KifuRowHeight
+		if first+i == len(lines)-1 {
+			ebitenutil.DrawRect(screen,
+				float64(KifuPanelX+2),
+				float64(y),
+				float64(KifuPanelWidth-4),
+				float64(KifuRowHeight),
+				color.RGBA{255, 220, 100, 255})
+		}
+		text.Draw(screen, fmt.Sprintf("%3d %s", first+i+1, line), g.font,
+			KifuPanelX+10, y+KifuRowHeight-5, color.Black)
+	}
+}
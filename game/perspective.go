@@ -0,0 +1,56 @@
+package game
+
+import (
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// 筋の全角数字
+var fileNames = []string{"", "１", "２", "３", "４", "５", "６", "７", "８", "９"}
+
+// 手前に表示するプレイヤーを設定する（後手なら盤を反転する）
+func (g *Game) SetPerspective(player piece.Player) {
+	g.flipped = player == piece.Gote
+}
+
+// 盤の反転（F キー）
+func (g *Game) handleFlipInput() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		g.flipped = !g.flipped
+	}
+}
+
+// 盤上の座標と画面上の列・行の変換（反転していれば180度回す。逆の変換も同じ）
+func (g *Game) viewSquare(x, y int) (int, int) {
+	if g.flipped {
+		return g.board.Width() - 1 - x, g.board.Height() - 1 - y
+	}
+	return x, y
+}
+
+// 手前のプレイヤーの駒か（相手の駒は180度回して描く）
+func (g *Game) isUpright(player piece.Player) bool {
+	return (player == piece.Sente) != g.flipped
+}
+
+// 盤の上に筋（１～９）、右に段（一～九）を描画
+func (g *Game) drawCoordinates(screen *ebiten.Image) {
+	cell := g.cellSize()
+	ox, oy := g.boardOrigin()
+
+	for col := 0; col < g.board.Width(); col++ {
+		x, _ := g.viewSquare(col, 0)
+		label := fileNames[g.board.Width()-x]
+		bounds := text.BoundString(g.font, label)
+		text.Draw(screen, label, g.font, ox+col*cell+cell/2-bounds.Dx()/2, oy-3, g.theme.Label)
+	}
+	for row := 0; row < g.board.Height(); row++ {
+		_, y := g.viewSquare(0, row)
+		label := rankNames[y]
+		bounds := text.BoundString(g.font, label)
+		text.Draw(screen, label, g.font, ox+g.board.Width()*cell+6, oy+row*cell+cell/2+bounds.Dy()/2, g.theme.Label)
+	}
+}
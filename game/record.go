@@ -0,0 +1,51 @@
+package game
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"shogi/board"
+	"shogi/csa"
+	"shogi/kif"
+	"shogi/usi"
+)
+
+// 棋譜ファイルを読み込み、開始局面と指し手を返す
+// 拡張子が .csa ならCSA形式、.sfen・.usi・.txt なら position コマンドの形式、それ以外はKIF形式として読む
+// 開始局面にルール違反があればログに出す（詰将棋など玉のない局面もあるので読み込みは続ける）
+func ReadRecordFile(name string, r io.Reader) (*board.Board, []board.Move, error) {
+	var start *board.Board
+	var moves []board.Move
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csa":
+		rec, err := csa.ReadRecord(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		start, moves = rec.Initial, rec.Moves
+	case ".sfen", ".usi", ".txt":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 先頭の "position " は省略できる
+		line := strings.TrimPrefix(strings.TrimSpace(string(data)), "position ")
+		start, moves, err = usi.ParsePosition(line)
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		rec, err := kif.ReadRecord(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		start, moves = rec.Initial, rec.Moves
+	}
+
+	for _, p := range start.Validate() {
+		log.Printf("%s: 開始局面の問題: %s", name, p)
+	}
+	return start, moves, nil
+}
@@ -0,0 +1,287 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+
+	"shogi/board"
+	"shogi/kif"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// 棋譜パネルの定数
+const (
+	KifuPanelX      = 920
+	KifuPanelWidth  = 260
+	KifuRowHeight   = 24
+	kifuTitleHeight = 30
+	kifuButtonSize  = 36
+)
+
+// 棋譜パネルの操作ボタン（先頭へ、1手戻る、1手進む、末尾へ）
+var kifuButtons = []string{"|<", "<", ">", ">|"}
+
+// 棋譜の再生状態
+type replay struct {
+	start    *board.Board
+	moves    []board.Move
+	notation []string // 各手のKIF形式の表記
+	ply      int      // 表示中の手数（分岐中は分岐した手数）
+	branched bool     // 棋譜から分岐して自由に指しているか
+	scroll   int      // 棋譜パネルの先頭に表示する行
+}
+
+// 棋譜を読み込んで再生モードにする（開始局面を表示する）
+func (g *Game) LoadRecord(start *board.Board, moves []board.Move) {
+	r := &replay{start: start.Clone(), moves: moves}
+	b := start.Clone()
+	var prev *board.Move
+	for i, m := range moves {
+		r.notation = append(r.notation, kif.FormatMove(b, m, prev))
+		b.MakeMove(m)
+		prev = &moves[i]
+	}
+	g.replay = r
+	g.jumpTo(0)
+}
+
+// 棋譜の ply 手目の局面を表示する
+func (g *Game) jumpTo(ply int) {
+	r := g.replay
+	if ply < 0 {
+		ply = 0
+	}
+	if ply > len(r.moves) {
+		ply = len(r.moves)
+	}
+
+	b := r.start.Clone()
+	for _, m := range r.moves[:ply] {
+		b.MakeMove(m)
+	}
+	g.board = b
+	g.history = append([]board.Move(nil), r.moves[:ply]...)
+	r.ply = ply
+	r.branched = false
+
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+	g.updateCheckMessage()
+	r.scrollTo(ply)
+}
+
+// 指された手を棋譜と照合する。棋譜と違う手なら分岐して自由に指せるようにする
+func (r *replay) follow(m board.Move) {
+	if r.branched {
+		return
+	}
+	if r.ply < len(r.moves) && r.moves[r.ply] == m {
+		r.ply++
+		r.scrollTo(r.ply)
+		return
+	}
+	r.branched = true
+}
+
+// 棋譜パネルに表示できる行数
+func kifuVisibleRows() int {
+	return (boardAreaSize - kifuTitleHeight - kifuButtonSize - 8) / KifuRowHeight
+}
+
+// 指定の行が見えるようにスクロールする
+func (r *replay) scrollTo(row int) {
+	visible := kifuVisibleRows()
+	if row < r.scroll {
+		r.scroll = row
+	}
+	if row >= r.scroll+visible {
+		r.scroll = row - visible + 1
+	}
+	r.clampScroll()
+}
+
+// スクロール位置を表示できる範囲に収める
+func (r *replay) clampScroll() {
+	max := len(r.moves) + 1 - kifuVisibleRows()
+	if r.scroll > max {
+		r.scroll = max
+	}
+	if r.scroll < 0 {
+		r.scroll = 0
+	}
+}
+
+// 1手進める・戻す（分岐中はまず分岐した局面に戻る）
+func (g *Game) stepReplay(delta int) {
+	r := g.replay
+	if r.branched {
+		g.jumpTo(r.ply)
+		return
+	}
+	g.jumpTo(r.ply + delta)
+}
+
+// 棋譜の再生操作（矢印キー・Home/End・棋譜パネルのクリックとスクロール）
+func (g *Game) handleReplayInput() {
+	r := g.replay
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		g.stepReplay(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		g.stepReplay(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		g.jumpTo(0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		g.jumpTo(len(r.moves))
+	}
+
+	// 検討パネルの表示中は棋譜パネルを操作できない
+	x, y := g.state.MouseX, g.state.MouseY
+	if g.analysis != nil || x < KifuPanelX || x >= KifuPanelX+KifuPanelWidth {
+		return
+	}
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		r.scroll -= int(dy)
+		r.clampScroll()
+	}
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+
+	// 操作ボタン
+	if i, ok := kifuButtonAt(x, y); ok {
+		switch i {
+		case 0:
+			g.jumpTo(0)
+		case 1:
+			g.stepReplay(-1)
+		case 2:
+			g.stepReplay(1)
+		case 3:
+			g.jumpTo(len(r.moves))
+		}
+		return
+	}
+
+	// 指し手の行
+	top := BoardMarginY + kifuTitleHeight
+	if y >= top && y < top+kifuVisibleRows()*KifuRowHeight {
+		row := r.scroll + (y-top)/KifuRowHeight
+		if row <= len(r.moves) {
+			g.jumpTo(row)
+		}
+	}
+}
+
+// 操作ボタンの位置
+func kifuButtonRect(i int) (x, y, w, h int) {
+	w = (KifuPanelWidth - 10) / len(kifuButtons)
+	return KifuPanelX + 5 + i*w, BoardMarginY + boardAreaSize - kifuButtonSize - 4, w - 4, kifuButtonSize
+}
+
+// 座標にある操作ボタン
+func kifuButtonAt(px, py int) (int, bool) {
+	for i := range kifuButtons {
+		x, y, w, h := kifuButtonRect(i)
+		if px >= x && px < x+w && py >= y && py < y+h {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// ドロップされた棋譜ファイルを読み込む
+func (g *Game) handleDroppedFiles() {
+	files := ebiten.DroppedFiles()
+	if files == nil {
+		return
+	}
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	name := entries[0].Name()
+	f, err := files.Open(name)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer f.Close()
+
+	start, moves, err := ReadRecordFile(name, f)
+	if err != nil {
+		log.Println(err)
+		g.state.Message = "棋譜を読み込めません"
+		return
+	}
+	g.LoadRecord(start, moves)
+}
+
+// 棋譜パネルを描画
+func (g *Game) drawKifuPanel(screen *ebiten.Image) {
+	r := g.replay
+	if r == nil {
+		return
+	}
+
+	// パネルの背景
+	ebitenutil.DrawRect(screen,
+		float64(KifuPanelX),
+		float64(BoardMarginY),
+		float64(KifuPanelWidth),
+		float64(boardAreaSize),
+		color.RGBA{245, 240, 230, 255})
+
+	title := "棋譜"
+	if r.branched {
+		title = fmt.Sprintf("棋譜（%d手目から分岐中）", r.ply)
+	}
+	text.Draw(screen, title, g.font, KifuPanelX+10, BoardMarginY+22, color.Black)
+
+	// 指し手の一覧
+	top := BoardMarginY + kifuTitleHeight
+	for i := 0; i < kifuVisibleRows(); i++ {
+		row := r.scroll + i
+		if row > len(r.moves) {
+			break
+		}
+		y := top + i*KifuRowHeight
+		if row == r.ply {
+			highlight := color.RGBA{255, 220, 100, 255}
+			if r.branched {
+				highlight = color.RGBA{200, 200, 200, 255}
+			}
+			ebitenutil.DrawRect(screen,
+				float64(KifuPanelX+2),
+				float64(y),
+				float64(KifuPanelWidth-4),
+				float64(KifuRowHeight),
+				highlight)
+		}
+
+		line := "開始局面"
+		if row > 0 {
+			line = fmt.Sprintf("%3d %s", row, r.notation[row-1])
+		}
+		text.Draw(screen, line, g.font, KifuPanelX+10, y+KifuRowHeight-5, color.Black)
+	}
+
+	// 操作ボタン
+	for i, label := range kifuButtons {
+		x, y, w, h := kifuButtonRect(i)
+		ebitenutil.DrawRect(screen, float64(x), float64(y), float64(w), float64(h),
+			color.RGBA{210, 200, 185, 255})
+		bounds := text.BoundString(g.font, label)
+		text.Draw(screen, label, g.font,
+			x+w/2-bounds.Dx()/2,
+			y+h/2+bounds.Dy()/2,
+			color.Black)
+	}
+}
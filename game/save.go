@@ -0,0 +1,337 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/kif"
+	"shogi/piece"
+	"shogi/usi"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+var (
+	ErrUnknownFormat      = errors.New("game: 対応していない棋譜の形式です")
+	ErrUnsupportedVariant = errors.New("game: 本将棋以外の対局はKIF形式やSFEN形式で保存できません")
+)
+
+// 自動保存する対局（ユーザーの設定ディレクトリの shogi/autosave.json）
+type savedGame struct {
+	Variant string          `json:"variant"` // 将棋の種類の識別名
+	Start   string          `json:"start"`   // 開始局面（SFEN）
+	Moves   []board.Move    `json:"moves"`
+	Times   []time.Duration `json:"times"` // 各手の消費時間
+	Sente   string          `json:"sente,omitempty"`
+	Gote    string          `json:"gote,omitempty"`
+	Flipped bool            `json:"flipped"`
+	SavedAt time.Time       `json:"savedAt"`
+}
+
+// 自動保存の状態
+type autosaver struct {
+	path    string
+	written bool         // 保存ファイルに対局が書かれているか
+	moves   []board.Move // 最後に保存した指し手リスト
+}
+
+// 名前を入力して棋譜を保存する・開く操作
+type fileCommand struct {
+	save  bool
+	input string
+}
+
+// 自動保存ファイルの場所
+func AutosavePath() (string, error) {
+	return configPath("autosave.json")
+}
+
+// 対局者の名前を設定（保存する棋譜に書く）
+func (g *Game) SetPlayerName(player piece.Player, name string) {
+	if g.players == nil {
+		g.players = make(map[piece.Player]string)
+	}
+	g.players[player] = name
+}
+
+// 対局中の局面を指すたびに自動保存する（ネットワーク対局・エンジンとの対局・棋譜の再生中は保存しない）
+// 前回の対局が途中で保存されていれば、再開するか尋ねる
+func (g *Game) EnableAutosave() error {
+	name, err := AutosavePath()
+	if err != nil {
+		return err
+	}
+	g.autosave = &autosaver{path: name}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s savedGame
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	g.autosave.written = true
+	if s.Variant == g.variant.Name && len(s.Moves) > 0 {
+		g.resume = &s
+	}
+	return nil
+}
+
+// 指し手ごとの消費時間を指し手リストに合わせる
+// 手が増えたら前の手からの時間を記録し、手が戻ったら記録も戻す
+func (g *Game) updateClock() {
+	now := time.Now()
+	if g.moveStart.IsZero() {
+		g.moveStart = now
+	}
+	if len(g.times) > len(g.history) {
+		g.times = g.times[:len(g.history)]
+		g.moveStart = now
+	}
+	for len(g.times) < len(g.history) {
+		g.times = append(g.times, now.Sub(g.moveStart))
+		g.moveStart = now
+	}
+}
+
+// 指し手リストが変わったら自動保存する（終局したら保存ファイルを消す）
+// まだ指していなければ前の保存ファイルを残す（新しい対局で最初の手を指したときに置き換える）
+func (g *Game) updateAutosave() {
+	a := g.autosave
+	if a == nil || g.resume != nil || g.remote != nil || g.replay != nil || g.editor != nil || len(g.history) == 0 {
+		return
+	}
+
+	if g.state.State == StateGameOver {
+		if a.written {
+			if err := os.Remove(a.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Println("自動保存を消せません:", err)
+			}
+			a.written, a.moves = false, nil
+		}
+		return
+	}
+	if a.written && equalMoves(a.moves, g.history) {
+		return
+	}
+
+	s := savedGame{
+		Variant: g.variant.Name,
+		Start:   g.historyStart().SFEN(1),
+		Moves:   g.history,
+		Times:   g.times,
+		Sente:   g.players[piece.Sente],
+		Gote:    g.players[piece.Gote],
+		Flipped: g.flipped,
+		SavedAt: time.Now(),
+	}
+	if err := writeJSON(a.path, s); err != nil {
+		log.Println("自動保存できません:", err)
+		return
+	}
+	a.written, a.moves = true, append([]board.Move(nil), g.history...)
+}
+
+// JSONでファイルに書き出す（ディレクトリがなければ作る）
+func writeJSON(name string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
+
+// 前回の対局を再開するかの確認（Y キーで再開、N キーで新しく始める）
+func (g *Game) handleResumeInput() {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyY) || inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		s := g.resume
+		g.resume = nil
+		if err := g.restore(s); err != nil {
+			log.Println("前回の対局を再開できません:", err)
+			g.state.Message = "再開できません"
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyN) || inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.resume = nil
+	}
+}
+
+// 保存した対局を再開する
+func (g *Game) restore(s *savedGame) error {
+	start, _, err := g.variant.ParseSFEN(s.Start)
+	if err != nil {
+		return err
+	}
+	if err := g.startFrom(start, s.Moves); err != nil {
+		return err
+	}
+	if len(s.Times) == len(s.Moves) {
+		g.times = append([]time.Duration(nil), s.Times...)
+	}
+	g.players = nil
+	if s.Sente != "" {
+		g.SetPlayerName(piece.Sente, s.Sente)
+	}
+	if s.Gote != "" {
+		g.SetPlayerName(piece.Gote, s.Gote)
+	}
+	g.flipped = s.Flipped
+	return nil
+}
+
+// 開始局面から指し手を進めた局面で対局を続ける
+func (g *Game) startFrom(start *board.Board, moves []board.Move) error {
+	b := start.Clone()
+	for i, m := range moves {
+		if err := b.ApplyMove(m); err != nil {
+			return fmt.Errorf("%d手目: %w", i+1, err)
+		}
+	}
+	// 盤の大きさや持ち駒の扱いは棋譜の開始局面の種類に合わせる
+	g.variant = start.Variant
+	if g.variant == nil {
+		g.variant = board.Standard
+	}
+	g.replay = nil
+	g.start = start.Clone()
+	g.board = b
+	g.history = append([]board.Move(nil), moves...)
+	g.times = make([]time.Duration, len(moves))
+	g.moveStart = time.Now()
+	g.effects = moveEffects{}
+	g.state = GameState{State: StateNormal}
+	g.resetSelection()
+	g.updateCheckMessage()
+	return nil
+}
+
+// 棋譜の保存（Ctrl+S）と読み込み（Ctrl+O）を始める
+func (g *Game) handleFileShortcuts() {
+	if !ebiten.IsKeyPressed(ebiten.KeyControl) && !ebiten.IsKeyPressed(ebiten.KeyMeta) {
+		return
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		g.fileCommand = &fileCommand{save: true, input: time.Now().Format("20060102-150405") + ".kif"}
+	case inpututil.IsKeyJustPressed(ebiten.KeyO) && g.remote == nil && g.editor == nil:
+		g.fileCommand = &fileCommand{}
+	}
+}
+
+// ファイル名の入力（Enter で実行、Esc でやめる）
+func (g *Game) handleFileCommandInput() {
+	c := g.fileCommand
+	line, enter, cancel := readLine(c.input)
+	c.input = line
+	if cancel {
+		g.fileCommand = nil
+		return
+	}
+	if !enter {
+		return
+	}
+	g.fileCommand = nil
+	name := strings.TrimSpace(line)
+	if name == "" {
+		return
+	}
+	if c.save {
+		if err := g.SaveRecord(name); err != nil {
+			log.Println(err)
+			g.state.Message = "保存できません"
+			return
+		}
+		g.state.Message = "保存しました"
+	} else if err := g.OpenRecord(name); err != nil {
+		log.Println(err)
+		g.state.Message = "棋譜を読み込めません"
+	}
+}
+
+// 対局をファイルに保存する
+// 拡張子が .kif ならKIF形式、.sfen・.usi・.txt なら position コマンドの形式（sfen ... moves ...）
+func (g *Game) SaveRecord(name string) error {
+	start := g.historyStart()
+	if start.Variant != nil {
+		return ErrUnsupportedVariant
+	}
+
+	var data string
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".kif":
+		rec := &kif.Record{Names: g.players, Initial: start, Moves: g.history}
+		if len(g.times) == len(g.history) {
+			rec.Times = g.times
+		}
+		var sb strings.Builder
+		if err := kif.WriteRecord(&sb, rec); err != nil {
+			return err
+		}
+		data = sb.String()
+	case ".sfen", ".usi", ".txt":
+		data = "position " + usi.FormatPosition(start, g.history) + "\n"
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+	return os.WriteFile(name, []byte(data), 0o644)
+}
+
+// 棋譜ファイルを開き、最後の局面から対局を続ける
+// 将棋の種類は棋譜の開始局面に合わせて切り替える
+func (g *Game) OpenRecord(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	start, moves, err := ReadRecordFile(name, f)
+	if err != nil {
+		return err
+	}
+	return g.startFrom(start, moves)
+}
+
+// 再開や操作の確認、ファイル名の入力の画面を描画
+func (g *Game) drawFileDialogs(screen *ebiten.Image) {
+	var lines []string
+	switch {
+	case g.resume != nil:
+		lines = []string{
+			fmt.Sprintf("前回の対局（%d手目）を再開しますか？", len(g.resume.Moves)),
+			g.resume.SavedAt.Format("2006/01/02 15:04") + " に保存",
+			"Y: 再開する　N: 新しく始める",
+		}
+	case g.confirm != nil:
+		lines = []string{g.confirm.message, "", "Y: はい　N: いいえ"}
+	case g.fileCommand != nil && g.fileCommand.save:
+		lines = []string{"保存するファイル名（.kif / .sfen）", g.fileCommand.input + "_", "Enter: 保存　Esc: やめる"}
+	case g.fileCommand != nil:
+		lines = []string{"開くファイル名（.kif / .csa / .sfen）", g.fileCommand.input + "_", "Enter: 開く　Esc: やめる"}
+	default:
+		return
+	}
+
+	ebitenutil.DrawRect(screen, 250, 200, 500, 150, color.RGBA{0, 0, 0, 220})
+	for i, line := range lines {
+		text.Draw(screen, line, g.font, 275, 240+i*40, color.White)
+	}
+}
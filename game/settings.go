@@ -0,0 +1,76 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ユーザー設定（ユーザーの設定ディレクトリの shogi/settings.json に保存する）
+type Settings struct {
+	Theme  string        `json:"theme"` // テーマの名前
+	Sounds SoundSettings `json:"sounds"`
+}
+
+// 効果音の設定（種類ごとに鳴らすか）
+type SoundSettings struct {
+	Muted   bool `json:"muted"` // すべての効果音を消す（M キーで切り替える）
+	Move    bool `json:"move"`
+	Capture bool `json:"capture"`
+	Check   bool `json:"check"`
+	GameEnd bool `json:"gameEnd"`
+}
+
+// 既定の設定（効果音はすべて鳴らす）
+func DefaultSettings() Settings {
+	return Settings{Sounds: SoundSettings{Move: true, Capture: true, Check: true, GameEnd: true}}
+}
+
+// 設定ファイルの場所
+func SettingsPath() (string, error) {
+	return configPath("settings.json")
+}
+
+// ユーザーの設定ディレクトリの shogi/ にあるファイルの場所
+func configPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shogi", name), nil
+}
+
+// 設定を読み込む（設定ファイルがなければ既定の設定、ファイルにない項目も既定のまま）
+func LoadSettings() (Settings, error) {
+	s := DefaultSettings()
+	name, err := SettingsPath()
+	if err != nil {
+		return s, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+// 設定を保存する
+func (s Settings) Save() error {
+	name, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
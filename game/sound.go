@@ -0,0 +1,115 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// 効果音を再生するサンプリングレート
+const sampleRate = 44100
+
+// 効果音の種類
+type soundEffect int
+
+const (
+	soundMove    soundEffect = iota // 駒を動かした・打った
+	soundCapture                    // 駒を取った
+	soundCheck                      // 王手
+	soundGameEnd                    // 終局
+)
+
+// 効果音のファイル（アセットのディレクトリの sounds/ の下）
+var soundFiles = map[soundEffect]string{
+	soundMove:    "move.wav",
+	soundCapture: "capture.wav",
+	soundCheck:   "check.wav",
+	soundGameEnd: "gameend.wav",
+}
+
+// 読み込んだ効果音（デコードしたPCM）
+type sounds struct {
+	context *audio.Context
+	data    map[soundEffect][]byte
+}
+
+// アセットのディレクトリから効果音を読み込む（ファイルのない効果音は鳴らさない）
+func loadSounds(assetsDir string) (*sounds, error) {
+	ctx := audio.CurrentContext()
+	if ctx == nil {
+		ctx = audio.NewContext(sampleRate)
+	}
+	s := &sounds{context: ctx, data: make(map[soundEffect][]byte)}
+	for e, name := range soundFiles {
+		data, err := os.ReadFile(filepath.Join(assetsDir, "sounds", name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stream, err := wav.DecodeWithSampleRate(sampleRate, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		pcm, err := io.ReadAll(stream)
+		if err != nil {
+			return nil, err
+		}
+		s.data[e] = pcm
+	}
+	return s, nil
+}
+
+// 効果音を鳴らすか（設定で種類ごとに切り替えられる）
+func (s SoundSettings) enabled(e soundEffect) bool {
+	if s.Muted {
+		return false
+	}
+	switch e {
+	case soundMove:
+		return s.Move
+	case soundCapture:
+		return s.Capture
+	case soundCheck:
+		return s.Check
+	case soundGameEnd:
+		return s.GameEnd
+	}
+	return false
+}
+
+// 効果音を鳴らす
+func (g *Game) playSound(e soundEffect) {
+	if g.sounds == nil || !g.settings.Sounds.enabled(e) {
+		return
+	}
+	data, ok := g.sounds.data[e]
+	if !ok {
+		return
+	}
+	g.sounds.context.NewPlayerFromBytes(data).Play()
+}
+
+// 効果音の消音の切り替え（M キー、設定に保存する）
+func (g *Game) handleSoundInput() {
+	if !inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		return
+	}
+	g.settings.Sounds.Muted = !g.settings.Sounds.Muted
+	g.state.Message = "効果音：オン"
+	if g.settings.Sounds.Muted {
+		g.state.Message = "効果音：オフ"
+	}
+	if err := g.SaveSettings(); err != nil {
+		log.Println("設定を保存できません:", err)
+	}
+}
@@ -0,0 +1,424 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"shogi/piece"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+)
+
+var ErrInvalidTheme = errors.New("game: 不正なテーマです")
+
+// 色（JSONでは "#rrggbb" または "#rrggbbaa"）
+type Color color.RGBA
+
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.RGBA(c).RGBA()
+}
+
+func (c Color) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A))
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	c.A = 255
+	var n int
+	var err error
+	switch len(s) {
+	case 7:
+		n, err = fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 9:
+		n, err = fmt.Sscanf(s, "#%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	}
+	if n < 3 || err != nil {
+		return fmt.Errorf("%w: 色 %s", ErrInvalidTheme, s)
+	}
+	return nil
+}
+
+// 駒の形
+const (
+	ShapePentagon = "pentagon" // 五角形
+	ShapeCircle   = "circle"   // 丸
+)
+
+// 表示のテーマ（色・駒の形・フォント・駒と盤の画像）
+type Theme struct {
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Background Color  `json:"background"` // 画面の背景
+	Board      Color  `json:"board"`      // 盤（盤の画像がなければ）
+	Line       Color  `json:"line"`       // マス目の線
+	Hand       Color  `json:"hand"`       // 持ち駒エリア
+	Label      Color  `json:"label"`      // 筋・段の番号
+	Piece      Color  `json:"piece"`      // 駒の地
+	PieceEdge  Color  `json:"pieceEdge"`  // 駒の縁
+	Text       Color  `json:"text"`       // 駒の文字
+	Promoted   Color  `json:"promoted"`   // 成駒の文字
+	Shape      string `json:"shape"`      // 駒の形（pentagon または circle）
+	PieceSet   string `json:"pieceSet"`   // 駒の画像のディレクトリ（assets/pieces/ の下、空なら文字で描く）
+	BoardImage string `json:"boardImage"` // 盤の画像（assets/ からの相対パス、空なら単色）
+	Font       string `json:"font"`       // フォントファイル（assets/ からの相対パス、空なら既定のフォント）
+}
+
+// 組み込みのテーマ
+var (
+	Classic = &Theme{
+		Name:       "classic",
+		Title:      "標準",
+		Background: Color{220, 220, 220, 255},
+		Board:      Color{210, 180, 140, 255},
+		Line:       Color{0, 0, 0, 255},
+		Hand:       Color{230, 220, 210, 255},
+		Label:      Color{80, 60, 40, 255},
+		Piece:      Color{240, 215, 160, 255},
+		PieceEdge:  Color{120, 90, 50, 255},
+		Text:       Color{0, 0, 0, 255},
+		Promoted:   Color{200, 0, 0, 255},
+		Shape:      ShapePentagon,
+	}
+	Simple = &Theme{
+		Name:       "simple",
+		Title:      "シンプル（丸い駒）",
+		Background: Color{220, 220, 220, 255},
+		Board:      Color{210, 180, 140, 255},
+		Line:       Color{0, 0, 0, 255},
+		Hand:       Color{230, 220, 210, 255},
+		Label:      Color{80, 60, 40, 255},
+		Piece:      Color{240, 215, 160, 255},
+		PieceEdge:  Color{240, 215, 160, 255},
+		Text:       Color{0, 0, 0, 255},
+		Promoted:   Color{200, 0, 0, 255},
+		Shape:      ShapeCircle,
+	}
+	Dark = &Theme{
+		Name:       "dark",
+		Title:      "ダーク",
+		Background: Color{40, 40, 45, 255},
+		Board:      Color{110, 85, 60, 255},
+		Line:       Color{30, 25, 20, 255},
+		Hand:       Color{70, 65, 60, 255},
+		Label:      Color{200, 190, 170, 255},
+		Piece:      Color{205, 185, 145, 255},
+		PieceEdge:  Color{60, 45, 30, 255},
+		Text:       Color{20, 20, 20, 255},
+		Promoted:   Color{170, 20, 20, 255},
+		Shape:      ShapePentagon,
+	}
+)
+
+var builtinThemes = []*Theme{Classic, Simple, Dark}
+
+// 組み込みのテーマと、assets/themes/*.json のテーマ
+func LoadThemes(assetsDir string) ([]*Theme, error) {
+	themes := append([]*Theme(nil), builtinThemes...)
+	files, err := filepath.Glob(filepath.Join(assetsDir, "themes", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range files {
+		t, err := LoadThemeFile(name)
+		if err != nil {
+			return nil, err
+		}
+		themes = append(themes, t)
+	}
+	return themes, nil
+}
+
+// テーマの定義ファイルを読み込む（省略した項目は標準のテーマと同じ）
+func LoadThemeFile(name string) (*Theme, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	t := *Classic
+	t.Name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	t.Title = ""
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if t.Shape != ShapePentagon && t.Shape != ShapeCircle {
+		return nil, fmt.Errorf("%w: %s: 駒の形 %s", ErrInvalidTheme, name, t.Shape)
+	}
+	if t.Title == "" {
+		t.Title = t.Name
+	}
+	return &t, nil
+}
+
+// テーマで使う画像とフォント（なければnil）
+type themeAssets struct {
+	pieces    map[piece.Type]*ebiten.Image // 先手の向きの駒の画像
+	board     *ebiten.Image
+	font      font.Face
+	largeFont font.Face
+}
+
+// 駒の画像のファイル名（SFENの駒文字、成駒は + を付ける）
+var pieceImageNames = map[piece.Type]string{
+	piece.Pawn:       "P",
+	piece.Lance:      "L",
+	piece.Knight:     "N",
+	piece.Silver:     "S",
+	piece.Gold:       "G",
+	piece.Bishop:     "B",
+	piece.Rook:       "R",
+	piece.King:       "K",
+	piece.PromPawn:   "+P",
+	piece.PromLance:  "+L",
+	piece.PromKnight: "+N",
+	piece.PromSilver: "+S",
+	piece.PromBishop: "+B",
+	piece.PromRook:   "+R",
+	piece.Giraffe:    "J",
+	piece.Elephant:   "E",
+}
+
+// テーマの画像とフォントを読み込む
+// 駒の画像は assets/pieces/<PieceSet>/<駒文字>.png で、ない駒は文字で描く
+func loadThemeAssets(t *Theme, assetsDir string) (*themeAssets, error) {
+	a := &themeAssets{pieces: make(map[piece.Type]*ebiten.Image)}
+	if t.PieceSet != "" {
+		dir := filepath.Join(assetsDir, "pieces", t.PieceSet)
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		for pt, name := range pieceImageNames {
+			img, _, err := ebitenutil.NewImageFromFile(filepath.Join(dir, name+".png"))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			a.pieces[pt] = img
+		}
+	}
+	if t.BoardImage != "" {
+		img, _, err := ebitenutil.NewImageFromFile(filepath.Join(assetsDir, t.BoardImage))
+		if err != nil {
+			return nil, err
+		}
+		a.board = img
+	}
+	if t.Font != "" {
+		var err error
+		a.font, a.largeFont, err = loadFontFaces(filepath.Join(assetsDir, t.Font))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// フォントファイルから通常サイズと大きいサイズのフォントを作る
+func loadFontFaces(name string) (font.Face, font.Face, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	tt, err := opentype.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	normal, err := opentype.NewFace(tt, &opentype.FaceOptions{Size: 20, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, nil, err
+	}
+	large, err := opentype.NewFace(tt, &opentype.FaceOptions{Size: 36, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, nil, err
+	}
+	return normal, large, nil
+}
+
+// アセットのディレクトリを設定し、そこにあるテーマと効果音を使えるようにする
+func (g *Game) SetAssets(dir string) error {
+	themes, err := LoadThemes(dir)
+	if err != nil {
+		return err
+	}
+	g.assetsDir = dir
+	g.themes = themes
+
+	// 効果音（読み込めなくても対局はできる）
+	if g.sounds, err = loadSounds(dir); err != nil {
+		log.Println("効果音を読み込めません:", err)
+	}
+	return nil
+}
+
+// テーマを名前で選ぶ（選んだテーマは設定に記録する）
+func (g *Game) SetTheme(name string) error {
+	for _, t := range g.themes {
+		if t.Name == name {
+			return g.applyTheme(t)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidTheme, name)
+}
+
+// テーマの画像とフォントを読み込んで表示に使う
+func (g *Game) applyTheme(t *Theme) error {
+	a, err := loadThemeAssets(t, g.assetsDir)
+	if err != nil {
+		return err
+	}
+	g.theme, g.themeAssets = t, a
+	g.font, g.largeFont = g.baseFont, g.baseLargeFont
+	if a.font != nil {
+		g.font, g.largeFont = a.font, a.largeFont
+	}
+	g.settings.Theme = t.Name
+	return nil
+}
+
+// 設定を反映する（テーマが見つからなければ標準のテーマのまま）
+func (g *Game) ApplySettings(s Settings) error {
+	g.settings = s
+	if s.Theme == "" {
+		return nil
+	}
+	return g.SetTheme(s.Theme)
+}
+
+// 設定を保存する
+func (g *Game) SaveSettings() error {
+	return g.settings.Save()
+}
+
+// テーマの切り替え（T キー、選んだテーマは設定に保存する）
+func (g *Game) handleThemeInput() {
+	if !inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		return
+	}
+	i := 0
+	for j, t := range g.themes {
+		if t == g.theme {
+			i = j
+		}
+	}
+	for n := 1; n <= len(g.themes); n++ {
+		t := g.themes[(i+n)%len(g.themes)]
+		if err := g.applyTheme(t); err != nil {
+			g.state.Message = "テーマを読み込めません"
+			continue
+		}
+		g.state.Message = "テーマ：" + t.Title
+		if err := g.SaveSettings(); err != nil {
+			g.state.Message = "設定を保存できません"
+		}
+		return
+	}
+}
+
+// 駒の大きさの比率（玉が最も大きく、歩が最も小さい）
+var pieceScales = map[piece.Type]float32{
+	piece.King:   1.00,
+	piece.Rook:   0.97,
+	piece.Bishop: 0.97,
+	piece.Gold:   0.94,
+	piece.Silver: 0.94,
+	piece.Knight: 0.91,
+	piece.Lance:  0.88,
+	piece.Pawn:   0.86,
+}
+
+// 駒の大きさの比率（成駒は元の駒と同じ、表にない駒は金と同じ）
+func pieceScale(t piece.Type) float32 {
+	if s, ok := pieceScales[t.Unpromote()]; ok {
+		return s
+	}
+	return 0.94
+}
+
+// 塗りつぶしに使う白い画像
+var (
+	whiteImage    = ebiten.NewImage(3, 3)
+	whiteSubImage = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+)
+
+func init() {
+	whiteImage.Fill(color.White)
+}
+
+// 五角形の駒の地を描く（upright が false なら先端を下に向ける）
+// size は駒の高さ
+func drawPentagon(screen *ebiten.Image, cx, cy, size float32, upright bool, fill, edge color.Color) {
+	h, w := size, size*0.86
+	dir := float32(1)
+	if !upright {
+		dir = -1
+	}
+	points := [][2]float32{
+		{0, -h / 2},
+		{w * 0.42, -h/2 + h*0.22},
+		{w / 2, h / 2},
+		{-w / 2, h / 2},
+		{-w * 0.42, -h/2 + h*0.22},
+	}
+	var path vector.Path
+	for i, p := range points {
+		x, y := cx+p[0]*dir, cy+p[1]*dir
+		if i == 0 {
+			path.MoveTo(x, y)
+		} else {
+			path.LineTo(x, y)
+		}
+	}
+	path.Close()
+
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	drawVertices(screen, vs, is, fill)
+	vs, is = path.AppendVerticesAndIndicesForStroke(nil, nil, &vector.StrokeOptions{Width: 1.5})
+	drawVertices(screen, vs, is, edge)
+}
+
+// 頂点を1色で塗る
+func drawVertices(screen *ebiten.Image, vs []ebiten.Vertex, is []uint16, clr color.Color) {
+	r, g, b, a := clr.RGBA()
+	for i := range vs {
+		vs[i].SrcX, vs[i].SrcY = 1, 1
+		vs[i].ColorR = float32(r) / 0xffff
+		vs[i].ColorG = float32(g) / 0xffff
+		vs[i].ColorB = float32(b) / 0xffff
+		vs[i].ColorA = float32(a) / 0xffff
+	}
+	screen.DrawTriangles(vs, is, whiteSubImage, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
+
+// 駒の画像を、高さを size に合わせて描く（upright が false なら180度回す）
+func drawPieceImage(screen, img *ebiten.Image, cx, cy, size float32, upright bool) {
+	bounds := img.Bounds()
+	scale := float64(size) / float64(bounds.Dy())
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(bounds.Dx())/2, -float64(bounds.Dy())/2)
+	op.GeoM.Scale(scale, scale)
+	if !upright {
+		op.GeoM.Rotate(math.Pi)
+	}
+	op.GeoM.Translate(float64(cx), float64(cy))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(img, op)
+}
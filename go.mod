@@ -5,15 +5,16 @@ go 1.21
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.5.9
 	golang.org/x/image v0.12.0
+	golang.org/x/text v0.13.0
 )
 
 require (
 	github.com/ebitengine/purego v0.4.0 // indirect
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20221017161538-93cebf72946b // indirect
+	github.com/hajimehoshi/oto/v2 v2.4.1 // indirect
 	github.com/jezek/xgb v1.1.0 // indirect
 	golang.org/x/exp v0.0.0-20190731235908-ec7cb31e5a56 // indirect
 	golang.org/x/mobile v0.0.0-20230301163155-e0f57694e12c // indirect
 	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/sys v0.7.0 // indirect
-	golang.org/x/text v0.13.0 // indirect
 )
@@ -0,0 +1,300 @@
+package kif
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/record"
+)
+
+// 全角数字（筋）
+var fileNumbers = []string{"", "１", "２", "３", "４", "５", "６", "７", "８", "９"}
+
+// 漢数字（段・枚数）
+var kanjiNumbers = []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九",
+	"十", "十一", "十二", "十三", "十四", "十五", "十六", "十七", "十八"}
+
+// KIF形式での駒の名前
+var pieceNames = map[piece.Type]string{
+	piece.Pawn:       "歩",
+	piece.Lance:      "香",
+	piece.Knight:     "桂",
+	piece.Silver:     "銀",
+	piece.Gold:       "金",
+	piece.Bishop:     "角",
+	piece.Rook:       "飛",
+	piece.King:       "玉",
+	piece.PromPawn:   "と",
+	piece.PromLance:  "成香",
+	piece.PromKnight: "成桂",
+	piece.PromSilver: "成銀",
+	piece.PromBishop: "馬",
+	piece.PromRook:   "龍",
+}
+
+// 局面図（BOD）での駒の名前（1文字）
+var bodPieceNames = map[piece.Type]string{
+	piece.Pawn:       "歩",
+	piece.Lance:      "香",
+	piece.Knight:     "桂",
+	piece.Silver:     "銀",
+	piece.Gold:       "金",
+	piece.Bishop:     "角",
+	piece.Rook:       "飛",
+	piece.King:       "玉",
+	piece.PromPawn:   "と",
+	piece.PromLance:  "杏",
+	piece.PromKnight: "圭",
+	piece.PromSilver: "全",
+	piece.PromBishop: "馬",
+	piece.PromRook:   "龍",
+}
+
+// 持ち駒の表記順
+var handOrder = []piece.Type{
+	piece.Rook, piece.Bishop, piece.Gold, piece.Silver,
+	piece.Knight, piece.Lance, piece.Pawn,
+}
+
+// KIF形式の棋譜
+type Record struct {
+	Names     map[piece.Player]string
+	Event     string
+	StartTime time.Time
+	EndTime   time.Time
+	Initial   *board.Board    // 開始局面（nilなら平手）
+	Moves     []board.Move    // 開始局面からの指し手
+	Times     []time.Duration // 各手の消費時間（Moves と同じ長さか空）
+	End       string          // 終局の表記（投了、千日手、詰み、切れ負け など）
+	Winner    piece.Player    // 勝った側（引き分け・不明なら None）
+}
+
+// 指し手をKIF形式（例: ７六歩(77)、同　角成(88)、５五角打）に変換
+// prev は直前の指し手（「同」の判定に使う、なければnil）。指す前の盤面を渡す
+func FormatMove(b *board.Board, m board.Move, prev *board.Move) string {
+	var sb strings.Builder
+	if prev != nil && prev.ToX == m.ToX && prev.ToY == m.ToY {
+		sb.WriteString("同　")
+	} else {
+		file, rank := board.BoardSize-m.ToX, m.ToY+1
+		sb.WriteString(fileNumbers[file] + kanjiNumbers[rank])
+	}
+
+	if m.FromX == -1 && m.FromY == -1 {
+		sb.WriteString(pieceNames[m.Piece] + "打")
+		return sb.String()
+	}
+
+	sb.WriteString(pieceNames[b.GetPiece(m.FromX, m.FromY).Type])
+	if m.Promote {
+		sb.WriteString("成")
+	}
+	fmt.Fprintf(&sb, "(%d%d)", board.BoardSize-m.FromX, m.FromY+1)
+	return sb.String()
+}
+
+// 棋譜をKIF形式で書き出す
+func WriteRecord(w io.Writer, rec *Record) error {
+	initial := rec.Initial
+	if initial == nil {
+		initial = board.New()
+	}
+	tree := record.FromMoves(initial, rec.Moves, rec.Times)
+	return writeKIF(w, rec, tree, len(rec.Times) > 0)
+}
+
+// 変化を含む棋譜をKIF形式で書き出す
+// 対局者などの情報と本譜の終局は rec から、開始局面と指し手は tree から書き出す
+func WriteTree(w io.Writer, rec *Record, tree *record.Tree) error {
+	return writeKIF(w, rec, tree, true)
+}
+
+func writeKIF(w io.Writer, rec *Record, tree *record.Tree, withTimes bool) error {
+	var sb strings.Builder
+	sb.WriteString("# ---- shogi 棋譜ファイル ----\n")
+	if !rec.StartTime.IsZero() {
+		sb.WriteString("開始日時：" + rec.StartTime.Format("2006/01/02 15:04:05") + "\n")
+	}
+	if !rec.EndTime.IsZero() {
+		sb.WriteString("終了日時：" + rec.EndTime.Format("2006/01/02 15:04:05") + "\n")
+	}
+	if rec.Event != "" {
+		sb.WriteString("棋戦：" + rec.Event + "\n")
+	}
+
+	if tree.Initial.SFEN(1) == board.StartSFEN {
+		sb.WriteString("手合割：平手\n")
+	} else {
+		sb.WriteString(FormatBoard(tree.Initial))
+	}
+
+	sb.WriteString("先手：" + rec.Names[piece.Sente] + "\n")
+	sb.WriteString("後手：" + rec.Names[piece.Gote] + "\n")
+	sb.WriteString("手数----指手---------消費時間--\n")
+	writeComment(&sb, tree.Root.Comment)
+
+	if len(tree.Root.Children) > 0 {
+		writeLine(&sb, tree, tree.Root.Children[0], withTimes, rec)
+	} else {
+		writeEnd(&sb, rec, 0)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// first から本譜をたどって書き出し、途中で分かれる変化を後ろの手から順に書き出す
+// rec が nil でなければ手順の後に終局を書き出す（本譜の場合）
+func writeLine(sb *strings.Builder, tree *record.Tree, first *record.Node, withTimes bool, rec *Record) {
+	line := append([]*record.Node{first}, first.MainLine()...)
+	b := tree.Board(first.Parent)
+	total := elapsedTotals(tree, first.Parent)
+	ply := first.Ply()
+
+	for i, n := range line {
+		var prev *board.Move
+		if !n.Parent.IsRoot() {
+			prev = &n.Parent.Move
+		}
+		mover := b.CurrentPlayer
+		s := fmt.Sprintf("%4d %s", ply+i, FormatMove(b, n.Move, prev))
+		if withTimes {
+			total[mover] += n.Time
+			s = padRight(s, 20) + formatTime(n.Time, total[mover])
+		}
+		if n.Index() < len(n.Parent.Children)-1 {
+			// 変化のある手の印
+			s += "+"
+		}
+		sb.WriteString(s + "\n")
+		writeComment(sb, n.Comment)
+		b.MakeMove(n.Move)
+	}
+	if rec != nil {
+		writeEnd(sb, rec, ply+len(line)-1)
+	}
+
+	for i := len(line) - 1; i >= 0; i-- {
+		n := line[i]
+		if n.Index()+1 < len(n.Parent.Children) {
+			fmt.Fprintf(sb, "\n変化：%d手\n", ply+i)
+			writeLine(sb, tree, n.Parent.Children[n.Index()+1], withTimes, nil)
+		}
+	}
+}
+
+// 終局の表記（例: 投了、まで64手で後手の勝ち）
+func writeEnd(sb *strings.Builder, rec *Record, moves int) {
+	if rec.End == "" {
+		return
+	}
+	fmt.Fprintf(sb, "%4d %s\n", moves+1, rec.End)
+	switch rec.Winner {
+	case piece.Sente:
+		fmt.Fprintf(sb, "まで%d手で先手の勝ち\n", moves)
+	case piece.Gote:
+		fmt.Fprintf(sb, "まで%d手で後手の勝ち\n", moves)
+	default:
+		fmt.Fprintf(sb, "まで%d手で%s\n", moves, rec.End)
+	}
+}
+
+// コメント（各行の先頭に * を付ける）
+func writeComment(sb *strings.Builder, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		sb.WriteString("*" + line + "\n")
+	}
+}
+
+// 節までの先手・後手の消費時間の合計
+func elapsedTotals(tree *record.Tree, n *record.Node) map[piece.Player]time.Duration {
+	total := map[piece.Player]time.Duration{}
+	player := tree.Initial.CurrentPlayer
+	if n.Ply()%2 == 1 {
+		player = player.Opposite()
+	}
+	for ; !n.IsRoot(); n = n.Parent {
+		player = player.Opposite()
+		total[player] += n.Time
+	}
+	return total
+}
+
+// 局面図（BOD形式）を書き出す
+func FormatBoard(b *board.Board) string {
+	var sb strings.Builder
+	sb.WriteString("後手の持駒：" + formatHand(b.GoteCaptures) + "\n")
+	sb.WriteString("  ９ ８ ７ ６ ５ ４ ３ ２ １\n")
+	sb.WriteString("+---------------------------+\n")
+	for y := 0; y < board.BoardSize; y++ {
+		sb.WriteString("|")
+		for x := 0; x < board.BoardSize; x++ {
+			p := b.Grid[y][x]
+			switch {
+			case p.Type == piece.Empty:
+				sb.WriteString(" ・")
+			case p.Player == piece.Gote:
+				sb.WriteString("v" + bodPieceNames[p.Type])
+			default:
+				sb.WriteString(" " + bodPieceNames[p.Type])
+			}
+		}
+		sb.WriteString("|" + kanjiNumbers[y+1] + "\n")
+	}
+	sb.WriteString("+---------------------------+\n")
+	sb.WriteString("先手の持駒：" + formatHand(b.SenteCaptures) + "\n")
+	if b.CurrentPlayer == piece.Gote {
+		sb.WriteString("後手番\n")
+	}
+	return sb.String()
+}
+
+// 持ち駒の表記（例: 角　歩三）
+func formatHand(captures map[piece.Type]int) string {
+	var parts []string
+	for _, t := range handOrder {
+		n := captures[t]
+		if n <= 0 {
+			continue
+		}
+		s := pieceNames[t]
+		if n > 1 {
+			s += kanjiNumbers[n]
+		}
+		parts = append(parts, s)
+	}
+	if len(parts) == 0 {
+		return "なし"
+	}
+	return strings.Join(parts, "　")
+}
+
+// 消費時間の表記（例: ( 0:03/00:01:25)）
+func formatTime(spent, total time.Duration) string {
+	s := int(spent / time.Second)
+	t := int(total / time.Second)
+	return fmt.Sprintf("(%2d:%02d/%02d:%02d:%02d)", s/60, s%60, t/3600, t/60%60, t%60)
+}
+
+// 表示幅（全角を2とする）が n になるよう空白を足す
+func padRight(s string, n int) string {
+	width := 0
+	for _, r := range s {
+		if r < 0x80 {
+			width++
+		} else {
+			width += 2
+		}
+	}
+	if width < n {
+		s += strings.Repeat(" ", n-width)
+	}
+	return s
+}
@@ -0,0 +1,334 @@
+package kif
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/record"
+)
+
+func TestFormatMove(t *testing.T) {
+	tests := []struct {
+		sfen string
+		move board.Move
+		prev *board.Move
+		want string
+	}{
+		{board.StartSFEN, board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, nil, "７六歩(77)"},
+		{"lnsgkgsnl/1r5b1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL b - 3",
+			board.Move{FromX: 1, FromY: 7, ToX: 7, ToY: 1, Promote: true}, nil, "２二角成(88)"},
+		{"lnsgkgs1l/1r5n1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL b - 5",
+			board.Move{FromX: 1, FromY: 7, ToX: 7, ToY: 1, Promote: true},
+			&board.Move{FromX: 7, FromY: 0, ToX: 7, ToY: 1}, "同　角成(88)"},
+		{"4k4/9/9/9/9/9/9/9/4K4 b B 1", board.Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Bishop}, nil, "５五角打"},
+		{"4k4/9/9/9/4+R4/9/9/9/4K4 b - 1", board.Move{FromX: 4, FromY: 4, ToX: 4, ToY: 1}, nil, "５二龍(55)"},
+	}
+	for _, tt := range tests {
+		b, _, err := board.ParseSFEN(tt.sfen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatMove(b, tt.move, tt.prev); got != tt.want {
+			t.Errorf("FormatMove(%+v) = %q, want %q", tt.move, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	rec := &Record{
+		Names: map[piece.Player]string{piece.Sente: "先手さん", piece.Gote: "後手さん"},
+		Event: "テスト",
+		Moves: []board.Move{
+			{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, // ７六歩
+			{FromX: 6, FromY: 2, ToX: 6, ToY: 3}, // ３四歩
+		},
+		Times:  []time.Duration{3 * time.Second, 65 * time.Second},
+		End:    "投了",
+		Winner: piece.Gote,
+	}
+	var sb strings.Builder
+	if err := WriteRecord(&sb, rec); err != nil {
+		t.Fatal(err)
+	}
+	want := `# ---- shogi 棋譜ファイル ----
+棋戦：テスト
+手合割：平手
+先手：先手さん
+後手：後手さん
+手数----指手---------消費時間--
+   1 ７六歩(77)     ( 0:03/00:00:03)
+   2 ３四歩(33)     ( 1:05/00:01:05)
+   3 投了
+まで2手で後手の勝ち
+`
+	if got := sb.String(); got != want {
+		t.Errorf("WriteRecord =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// 平手以外の開始局面は局面図で書き出し、消費時間がなければ書かない
+func TestWriteRecordFromPosition(t *testing.T) {
+	initial, _, err := board.ParseSFEN("4k4/9/9/9/9/9/9/9/4K4 w 2Pb 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &Record{
+		Initial: initial,
+		Moves:   []board.Move{{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Bishop}},
+		End:     "中断",
+	}
+	var sb strings.Builder
+	if err := WriteRecord(&sb, rec); err != nil {
+		t.Fatal(err)
+	}
+	got := sb.String()
+	for _, want := range []string{
+		"後手の持駒：角\n",
+		"先手の持駒：歩二\n",
+		"| ・ ・ ・ ・v玉 ・ ・ ・ ・|一\n",
+		"後手番\n",
+		"   1 ５五角打\n   2 中断\nまで1手で中断\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteRecord に %q がありません:\n%s", want, got)
+		}
+	}
+
+	// 書き出した棋譜を読み込むと同じ局面と指し手になる
+	back, err := ReadRecord(strings.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Initial.SFEN(1) != initial.SFEN(1) || len(back.Moves) != 1 || back.Moves[0] != rec.Moves[0] {
+		t.Errorf("読み込んだ棋譜 = %s %+v", back.Initial.SFEN(1), back.Moves)
+	}
+}
+
+// 変化（入れ子の変化と1手目の変化を含む）を書き出して読み込むと同じ木になる
+func TestTreeRoundTrip(t *testing.T) {
+	var (
+		p76 = board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5} // ７六歩
+		p26 = board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5} // ２六歩
+		p66 = board.Move{FromX: 3, FromY: 6, ToX: 3, ToY: 5} // ６六歩
+		p25 = board.Move{FromX: 7, FromY: 5, ToX: 7, ToY: 4} // ２五歩
+		p34 = board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3} // ３四歩
+		p84 = board.Move{FromX: 1, FromY: 2, ToX: 1, ToY: 3} // ８四歩
+	)
+	tree := record.NewTree(board.New())
+	tree.Root.Comment = "開始局面"
+	add := func(from *record.Node, moves ...board.Move) *record.Node {
+		if err := tree.GoTo(from); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range moves {
+			n, err := tree.Add(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.Time = time.Duration(n.Ply()) * time.Second
+		}
+		return tree.Current
+	}
+	// 本譜 ７六歩 ３四歩 ２六歩
+	main := add(tree.Root, p76, p34, p26)
+	main.Parent.Parent.Comment = "本譜\n2行目"
+	// 3手目の変化 ６六歩
+	add(main.Parent, p66)
+	// 1手目の変化 ２六歩 ８四歩 ２五歩、その中の2手目の変化 ３四歩
+	v := add(tree.Root, p26, p84, p25)
+	v.Comment = "変化"
+	add(v.Parent.Parent, p34)
+
+	rec := &Record{Names: map[piece.Player]string{}, End: "投了", Winner: piece.Sente}
+	var first strings.Builder
+	if err := WriteTree(&first, rec, tree); err != nil {
+		t.Fatal(err)
+	}
+	back, backTree, err := ReadTree(strings.NewReader(first.String()))
+	if err != nil {
+		t.Fatalf("%v\n%s", err, first.String())
+	}
+	if back.End != rec.End || back.Winner != rec.Winner {
+		t.Errorf("終局 = %s %v, want %s %v", back.End, back.Winner, rec.End, rec.Winner)
+	}
+	compareNodes(t, backTree.Root, tree.Root)
+
+	// 読み込んだ木を書き出すと同じ棋譜になる
+	var second strings.Builder
+	if err := WriteTree(&second, back, backTree); err != nil {
+		t.Fatal(err)
+	}
+	if second.String() != first.String() {
+		t.Errorf("書き出し直した棋譜 =\n%s\nwant\n%s", second.String(), first.String())
+	}
+}
+
+// 2つの木の節が子まで同じか
+func compareNodes(t *testing.T, got, want *record.Node) {
+	t.Helper()
+	if got.Move != want.Move || got.Comment != want.Comment || got.Time != want.Time {
+		t.Errorf("%d手目: %+v %q %v, want %+v %q %v",
+			want.Ply(), got.Move, got.Comment, got.Time, want.Move, want.Comment, want.Time)
+	}
+	if len(got.Children) != len(want.Children) {
+		t.Errorf("%d手目 %+v の子 %d, want %d", want.Ply(), want.Move, len(got.Children), len(want.Children))
+		return
+	}
+	for i := range want.Children {
+		compareNodes(t, got.Children[i], want.Children[i])
+	}
+}
+
+// 入れ子の変化と1手目の変化を含むKIF（書き出しの形式どおり）
+const variationKIF = `# ---- shogi 棋譜ファイル ----
+手合割：平手
+先手：先手
+後手：後手
+手数----指手---------消費時間--
+   1 ７六歩(77)     ( 0:01/00:00:01)+
+   2 ３四歩(33)     ( 0:02/00:00:02)
+   3 ２六歩(27)     ( 0:03/00:00:04)+
+   4 ８四歩(83)     ( 0:04/00:00:06)
+   5 投了
+まで4手で先手の勝ち
+
+変化：3手
+   3 ６六歩(67)     ( 0:05/00:00:06)
+   4 ８四歩(83)     ( 0:06/00:00:08)
+
+変化：1手
+   1 ２六歩(27)     ( 0:07/00:00:07)
+   2 ８四歩(83)     ( 0:08/00:00:08)+
+   3 ２五歩(26)     ( 0:09/00:00:16)+
+
+変化：3手
+   3 ７六歩(77)     ( 0:10/00:00:17)
+
+変化：2手
+   2 ３四歩(33)     ( 0:11/00:00:11)
+`
+
+// KIF → 木 → KIF で同じ棋譜になり、変化の並べ替え・本譜への昇格・削除が書き出しに反映される
+func TestVariationKIF(t *testing.T) {
+	// 本譜と変化の最後の節を手順の表記でたどる
+	find := func(tree *record.Tree, path ...string) *record.Node {
+		t.Helper()
+		n := tree.Root
+		b := tree.Board(n)
+	next:
+		for _, s := range path {
+			for _, c := range n.Children {
+				var prev *board.Move
+				if !n.IsRoot() {
+					prev = &n.Move
+				}
+				if FormatMove(b, c.Move, prev) == s {
+					b.MakeMove(c.Move)
+					n = c
+					continue next
+				}
+			}
+			t.Fatalf("%q の手順がありません", path)
+		}
+		return n
+	}
+
+	tests := []struct {
+		name string
+		edit func(tree *record.Tree) error
+		want string // 書き出した棋譜（空なら元の棋譜）
+	}{
+		{"そのまま", func(tree *record.Tree) error { return nil }, ""},
+		{"1手目の変化を上げる", func(tree *record.Tree) error {
+			return tree.Promote(find(tree, "２六歩(27)"))
+		}, `   1 ２六歩(27)     ( 0:07/00:00:07)+
+   2 ８四歩(83)     ( 0:08/00:00:08)+
+   3 ２五歩(26)     ( 0:09/00:00:16)+
+   4 投了
+まで3手で後手の勝ち
+
+変化：3手
+   3 ７六歩(77)     ( 0:10/00:00:17)
+
+変化：2手
+   2 ３四歩(33)     ( 0:11/00:00:11)
+
+変化：1手
+   1 ７六歩(77)     ( 0:01/00:00:01)
+   2 ３四歩(33)     ( 0:02/00:00:02)
+   3 ２六歩(27)     ( 0:03/00:00:04)+
+   4 ８四歩(83)     ( 0:04/00:00:06)
+
+変化：3手
+   3 ６六歩(67)     ( 0:05/00:00:06)
+   4 ８四歩(83)     ( 0:06/00:00:08)
+`},
+		{"入れ子の変化を本譜にする", func(tree *record.Tree) error {
+			return tree.PromoteToMainLine(find(tree, "２六歩(27)", "３四歩(33)"))
+		}, `   1 ２六歩(27)     ( 0:07/00:00:07)+
+   2 ３四歩(33)     ( 0:11/00:00:11)+
+   3 投了
+まで2手で先手の勝ち
+
+変化：2手
+   2 ８四歩(83)     ( 0:08/00:00:08)
+   3 ２五歩(26)     ( 0:09/00:00:16)+
+
+変化：3手
+   3 ７六歩(77)     ( 0:10/00:00:17)
+
+変化：1手
+   1 ７六歩(77)     ( 0:01/00:00:01)
+   2 ３四歩(33)     ( 0:02/00:00:02)
+   3 ２六歩(27)     ( 0:03/00:00:04)+
+   4 ８四歩(83)     ( 0:04/00:00:06)
+
+変化：3手
+   3 ６六歩(67)     ( 0:05/00:00:06)
+   4 ８四歩(83)     ( 0:06/00:00:08)
+`},
+		{"1手目の変化を消す", func(tree *record.Tree) error {
+			return tree.Delete(find(tree, "２六歩(27)"))
+		}, `   1 ７六歩(77)     ( 0:01/00:00:01)
+   2 ３四歩(33)     ( 0:02/00:00:02)
+   3 ２六歩(27)     ( 0:03/00:00:04)+
+   4 ８四歩(83)     ( 0:04/00:00:06)
+   5 投了
+まで4手で先手の勝ち
+
+変化：3手
+   3 ６六歩(67)     ( 0:05/00:00:06)
+   4 ８四歩(83)     ( 0:06/00:00:08)
+`},
+	}
+	header, _, _ := strings.Cut(variationKIF, "   1 ")
+	for _, tt := range tests {
+		rec, tree, err := ReadTree(strings.NewReader(variationKIF))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tt.edit(tree); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		// 本譜が変わったら終局の表記は本譜の手数に合わせる
+		if n := len(tree.MainLineMoves()); n%2 == 0 {
+			rec.Winner = piece.Sente
+		} else {
+			rec.Winner = piece.Gote
+		}
+		var sb strings.Builder
+		if err := WriteTree(&sb, rec, tree); err != nil {
+			t.Fatal(err)
+		}
+		want := variationKIF
+		if tt.want != "" {
+			want = header + tt.want
+		}
+		if got := sb.String(); got != want {
+			t.Errorf("%s:\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package kif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/record"
+)
+
+var (
+	ErrInvalidMove      = errors.New("kif: 不正な指し手です")
+	ErrInvalidPosition  = errors.New("kif: 不正な局面です")
+	ErrUnknownHandicap  = errors.New("kif: 対応していない手合割です")
+	ErrInvalidVariation = errors.New("kif: 変化の手数が不正です")
+)
+
+// 駒落ちの開始局面（上手が後手として先に指す）
+var handicaps = map[string]string{
+	"平手":   board.StartSFEN,
+	"香落ち":  "lnsgkgsn1/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"右香落ち": "1nsgkgsnl/1r5b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"角落ち":  "lnsgkgsnl/1r7/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"飛車落ち": "lnsgkgsnl/7b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"飛香落ち": "lnsgkgsn1/7b1/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"二枚落ち": "lnsgkgsnl/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"四枚落ち": "1nsgkgsn1/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"六枚落ち": "2sgkgs2/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"八枚落ち": "3gkg3/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+	"十枚落ち": "4k4/9/ppppppppp/9/9/9/PPPPPPPPP/1B5R1/LNSGKGSNL w - 1",
+}
+
+// 終局の表記と、手番側から見た勝敗（1: 手番側の勝ち、-1: 負け、0: 勝敗なし）
+var endings = map[string]int{
+	"投了":   -1,
+	"詰み":   -1,
+	"切れ負け": -1,
+	"時間切れ": -1,
+	"反則負け": -1,
+	"反則勝ち": 1,
+	"入玉勝ち": 1,
+	"中断":   0,
+	"千日手":  0,
+	"持将棋":  0,
+	"不詰":   0,
+	"封じ手":  0,
+}
+
+// KIF形式の棋譜を読み込む（Shift_JIS と UTF-8 に対応）
+// 変化（分岐）は読み飛ばし、本譜だけを返す
+func ReadRecord(r io.Reader) (*Record, error) {
+	rec, _, err := ReadTree(r)
+	return rec, err
+}
+
+// 変化を含むKIF形式の棋譜を読み込む（Shift_JIS と UTF-8 に対応）
+// rec には対局者などの情報と本譜が、tree には変化を含む全ての手とコメントが入る
+func ReadTree(r io.Reader) (*Record, *record.Tree, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		if data, err = japanese.ShiftJIS.NewDecoder().Bytes(data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rec := &Record{Names: map[piece.Player]string{}, Winner: piece.None}
+	var bod []string
+	var comments []string // 開始局面が決まる前のコメント
+	var tree *record.Tree
+	var cur *record.Node // 最後に読んだ手
+	var b *board.Board   // cur の局面
+	inVariation := false
+	hasTimes := false
+
+	// 開始局面が決まった時点で木を作る
+	start := func() error {
+		if tree != nil {
+			return nil
+		}
+		initial, err := initialBoard(rec, bod)
+		if err != nil {
+			return err
+		}
+		rec.Initial = initial
+		tree = record.NewTree(initial)
+		tree.Root.Comment = strings.Join(comments, "\n")
+		cur = tree.Root
+		b = initial.Clone()
+		return nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "&"):
+			// しおりは読み飛ばす
+			continue
+		case strings.HasPrefix(trimmed, "*"):
+			if tree == nil {
+				comments = append(comments, trimmed[1:])
+			} else if cur.Comment == "" {
+				cur.Comment = trimmed[1:]
+			} else {
+				cur.Comment += "\n" + trimmed[1:]
+			}
+			continue
+		case strings.HasPrefix(trimmed, "変化："):
+			if err := start(); err != nil {
+				return nil, nil, err
+			}
+			// 分岐する手の1手前まで戻る
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(trimmed, "変化："), "手"))
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %s", ErrInvalidVariation, trimmed)
+			}
+			for !cur.IsRoot() && cur.Ply() >= n {
+				cur = cur.Parent
+			}
+			if cur.Ply() != n-1 {
+				return nil, nil, fmt.Errorf("%w: %s", ErrInvalidVariation, trimmed)
+			}
+			b = tree.Board(cur)
+			inVariation = true
+			continue
+		case strings.HasPrefix(trimmed, "まで"):
+			continue
+		case strings.HasPrefix(trimmed, "手数----"):
+			if err := start(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if n, rest, ok := splitMoveNumber(trimmed); ok {
+			if err := start(); err != nil {
+				return nil, nil, err
+			}
+			if n != cur.Ply()+1 {
+				return nil, nil, fmt.Errorf("%w: %s", ErrInvalidMove, trimmed)
+			}
+
+			text, spent, timed := splitTime(rest)
+			if win, ok := endingOf(text); ok {
+				if !inVariation {
+					rec.End = text
+					switch win {
+					case 1:
+						rec.Winner = b.CurrentPlayer
+					case -1:
+						rec.Winner = b.CurrentPlayer.Opposite()
+					}
+				}
+				continue
+			}
+
+			var prev *board.Move
+			if !cur.IsRoot() {
+				prev = &cur.Move
+			}
+			m, err := ParseMove(b, text, prev)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.MakeMove(m)
+			cur = cur.AddChild(m)
+			cur.Time = spent
+			hasTimes = hasTimes || timed
+			if !inVariation {
+				rec.Moves = append(rec.Moves, m)
+				rec.Times = append(rec.Times, spent)
+			}
+			continue
+		}
+
+		if tree == nil {
+			if isBODLine(trimmed) {
+				bod = append(bod, line)
+				continue
+			}
+			if err := readHeader(rec, trimmed); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := start(); err != nil {
+		return nil, nil, err
+	}
+	if !hasTimes {
+		rec.Times = nil
+	}
+	return rec, tree, nil
+}
+
+// ヘッダー（項目名：値）を読む
+func readHeader(rec *Record, line string) error {
+	key, value, ok := strings.Cut(line, "：")
+	if !ok {
+		key, value, ok = strings.Cut(line, ":")
+	}
+	if !ok {
+		return nil
+	}
+	value = strings.TrimSpace(value)
+	switch key {
+	case "開始日時":
+		rec.StartTime = parseDateTime(value)
+	case "終了日時":
+		rec.EndTime = parseDateTime(value)
+	case "棋戦":
+		rec.Event = value
+	case "先手", "下手":
+		rec.Names[piece.Sente] = value
+	case "後手", "上手":
+		rec.Names[piece.Gote] = value
+	case "手合割":
+		sfen, ok := handicaps[value]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownHandicap, value)
+		}
+		b, _, err := board.ParseSFEN(sfen)
+		if err != nil {
+			return err
+		}
+		rec.Initial = b
+	}
+	return nil
+}
+
+// 日時（2006/01/02 15:04:05 など）を解析
+func parseDateTime(s string) time.Time {
+	for _, layout := range []string{"2006/01/02 15:04:05", "2006/01/02 15:04", "2006/01/02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// 開始局面（局面図があればそれ、なければ手合割、どちらもなければ平手）
+func initialBoard(rec *Record, bod []string) (*board.Board, error) {
+	if len(bod) > 0 {
+		return ParseBoard(bod)
+	}
+	if rec.Initial != nil {
+		return rec.Initial.Clone(), nil
+	}
+	return board.New(), nil
+}
+
+// 局面図（BOD形式）の行か
+func isBODLine(line string) bool {
+	return strings.HasPrefix(line, "|") || strings.HasPrefix(line, "+-") ||
+		strings.HasPrefix(line, "９ ８") || strings.Contains(line, "の持駒：") ||
+		line == "先手番" || line == "後手番" || line == "下手番" || line == "上手番"
+}
+
+// 局面図（BOD形式）を読み込む
+func ParseBoard(lines []string) (*board.Board, error) {
+	b := board.NewEmpty()
+	y := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "|"):
+			if y >= board.BoardSize {
+				return nil, ErrInvalidPosition
+			}
+			if err := parseBoardRow(b, y, line); err != nil {
+				return nil, err
+			}
+			y++
+		case strings.HasPrefix(line, "先手の持駒：") || strings.HasPrefix(line, "下手の持駒："):
+			if err := parseHand(b.SenteCaptures, line); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "後手の持駒：") || strings.HasPrefix(line, "上手の持駒："):
+			if err := parseHand(b.GoteCaptures, line); err != nil {
+				return nil, err
+			}
+		case line == "後手番" || line == "上手番":
+			b.CurrentPlayer = piece.Gote
+		}
+	}
+	if y != board.BoardSize {
+		return nil, ErrInvalidPosition
+	}
+	return b, nil
+}
+
+// 局面図の1段（例: |v香v桂 ・ ・v玉 ・ ・v桂v香|一）
+func parseBoardRow(b *board.Board, y int, line string) error {
+	runes := []rune(line)[1:]
+	for x := 0; x < board.BoardSize; x++ {
+		if len(runes) < 2 {
+			return ErrInvalidPosition
+		}
+		owner, name := runes[0], string(runes[1])
+		runes = runes[2:]
+		if name == "・" {
+			continue
+		}
+		t, ok := parseBODPiece(name)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidPosition, line)
+		}
+		player := piece.Sente
+		if owner == 'v' {
+			player = piece.Gote
+		}
+		b.Grid[y][x] = piece.Piece{Type: t, Player: player}
+	}
+	return nil
+}
+
+// 局面図の駒の名前（1文字）
+func parseBODPiece(name string) (piece.Type, bool) {
+	for t, n := range bodPieceNames {
+		if n == name {
+			return t, true
+		}
+	}
+	switch name {
+	case "王":
+		return piece.King, true
+	case "竜":
+		return piece.PromRook, true
+	}
+	return piece.Empty, false
+}
+
+// 持ち駒（例: 先手の持駒：角　歩三）
+func parseHand(captures map[piece.Type]int, line string) error {
+	_, value, _ := strings.Cut(line, "：")
+	value = strings.TrimSpace(value)
+	if value == "なし" || value == "" {
+		return nil
+	}
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == '　' || r == ' ' }) {
+		runes := []rune(item)
+		t, ok := parseBODPiece(string(runes[0]))
+		if !ok || t.IsPromoted() || t == piece.King {
+			return fmt.Errorf("%w: %s", ErrInvalidPosition, line)
+		}
+		n := 1
+		if len(runes) > 1 {
+			if n, ok = parseKanjiNumber(string(runes[1:])); !ok {
+				return fmt.Errorf("%w: %s", ErrInvalidPosition, line)
+			}
+		}
+		captures[t] += n
+	}
+	return nil
+}
+
+// 漢数字（一〜十八）を数値に変換
+func parseKanjiNumber(s string) (int, bool) {
+	for n, k := range kanjiNumbers {
+		if n > 0 && k == s {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// 指し手の行を手数と残りに分ける
+func splitMoveNumber(line string) (int, string, bool) {
+	i := strings.IndexFunc(line, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(line[:i])
+	if err != nil {
+		return 0, "", false
+	}
+	return n, strings.TrimSpace(line[i:]), true
+}
+
+// 指し手と消費時間（例: ( 0:03/00:00:03)）を分ける
+func splitTime(s string) (string, time.Duration, bool) {
+	// 変化のある手の印（末尾の +）を除く
+	s = strings.TrimSuffix(strings.TrimSpace(s), "+")
+	i := strings.LastIndex(s, "(")
+	if i < 0 || !strings.Contains(s[i:], "/") {
+		return strings.TrimSpace(s), 0, false
+	}
+	spent := strings.TrimSpace(strings.Trim(s[i:], "()"))
+	spent, _, _ = strings.Cut(spent, "/")
+	min, sec, ok := strings.Cut(strings.TrimSpace(spent), ":")
+	if !ok {
+		return strings.TrimSpace(s[:i]), 0, false
+	}
+	m, err1 := strconv.Atoi(strings.TrimSpace(min))
+	sc, err2 := strconv.Atoi(strings.TrimSpace(sec))
+	if err1 != nil || err2 != nil {
+		return strings.TrimSpace(s[:i]), 0, false
+	}
+	return strings.TrimSpace(s[:i]), time.Duration(m)*time.Minute + time.Duration(sc)*time.Second, true
+}
+
+// 終局の表記か
+func endingOf(text string) (int, bool) {
+	for word, win := range endings {
+		if strings.HasPrefix(text, word) {
+			return win, true
+		}
+	}
+	return 0, false
+}
+
+// KIF形式の指し手（例: ７六歩(77)、同　角成(88)、５五角打）を解析
+// prev は直前の指し手（「同」の解決に使う、なければnil）。指す前の盤面を渡す
+func ParseMove(b *board.Board, s string, prev *board.Move) (board.Move, error) {
+	invalid := fmt.Errorf("%w: %s", ErrInvalidMove, s)
+	var m board.Move
+	rest := s
+
+	// 移動先
+	if after, ok := strings.CutPrefix(rest, "同"); ok {
+		if prev == nil {
+			return board.Move{}, invalid
+		}
+		m.ToX, m.ToY = prev.ToX, prev.ToY
+		rest = strings.TrimLeft(after, "　 ")
+	} else {
+		runes := []rune(rest)
+		if len(runes) < 2 {
+			return board.Move{}, invalid
+		}
+		file := parseFileNumber(runes[0])
+		rank, ok := parseKanjiNumber(string(runes[1]))
+		if file < 1 || !ok || rank > board.BoardSize {
+			return board.Move{}, invalid
+		}
+		m.ToX, m.ToY = board.BoardSize-file, rank-1
+		rest = string(runes[2:])
+	}
+
+	// 駒の種類（2文字の名前を優先）
+	var t piece.Type
+	found := false
+	for _, n := range []int{2, 1} {
+		runes := []rune(rest)
+		if len(runes) < n {
+			continue
+		}
+		if t, found = parseMovePiece(string(runes[:n])); found {
+			rest = string(runes[n:])
+			break
+		}
+	}
+	if !found {
+		return board.Move{}, invalid
+	}
+
+	// 成・不成・打
+	switch {
+	case strings.HasPrefix(rest, "不成"):
+		rest = strings.TrimPrefix(rest, "不成")
+	case strings.HasPrefix(rest, "成"):
+		m.Promote = true
+		rest = strings.TrimPrefix(rest, "成")
+	case strings.HasPrefix(rest, "打"):
+		m.FromX, m.FromY = -1, -1
+		m.Piece = t
+		if err := b.CheckMove(m); err != nil {
+			return board.Move{}, fmt.Errorf("%w: %w", invalid, err)
+		}
+		return m, nil
+	}
+
+	// 移動元（例: (77)）
+	rest = strings.TrimSpace(rest)
+	if len(rest) != 4 || rest[0] != '(' || rest[3] != ')' ||
+		rest[1] < '1' || rest[1] > '9' || rest[2] < '1' || rest[2] > '9' {
+		return board.Move{}, invalid
+	}
+	m.FromX, m.FromY = board.BoardSize-int(rest[1]-'0'), int(rest[2]-'1')
+	p := b.GetPiece(m.FromX, m.FromY)
+	if p.Type != t || p.Player != b.CurrentPlayer {
+		return board.Move{}, invalid
+	}
+	if err := b.CheckMove(m); err != nil {
+		return board.Move{}, fmt.Errorf("%w: %w", invalid, err)
+	}
+	return m, nil
+}
+
+// 筋の数字（全角・半角）
+func parseFileNumber(r rune) int {
+	switch {
+	case r >= '１' && r <= '９':
+		return int(r-'１') + 1
+	case r >= '1' && r <= '9':
+		return int(r - '0')
+	}
+	return 0
+}
+
+// 指し手に使われる駒の名前
+func parseMovePiece(name string) (piece.Type, bool) {
+	for t, n := range pieceNames {
+		if n == name {
+			return t, true
+		}
+	}
+	switch name {
+	case "王":
+		return piece.King, true
+	case "竜":
+		return piece.PromRook, true
+	case "全":
+		return piece.PromSilver, true
+	case "圭":
+		return piece.PromKnight, true
+	case "杏":
+		return piece.PromLance, true
+	}
+	return piece.Empty, false
+}
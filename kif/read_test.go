@@ -0,0 +1,25 @@
+package kif
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"shogi/board"
+)
+
+func TestReadRecordRejectsIllegalMove(t *testing.T) {
+	// ５手目の７七玉は後手の角の利きに入る（自玉が取られる手）
+	kifu := `手合割：平手
+手数----指手---------消費時間--
+   1 ７六歩(77)
+   2 ３四歩(33)
+   3 ６八玉(59)
+   4 １四歩(13)
+   5 ７七玉(68)
+`
+	_, err := ReadRecord(strings.NewReader(kifu))
+	if !errors.Is(err, ErrInvalidMove) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrLeavesKingInCheck)", err)
+	}
+}
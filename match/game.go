@@ -0,0 +1,199 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"shogi/board"
+	"shogi/csa"
+	"shogi/piece"
+)
+
+// 対局の条件
+type Config struct {
+	Total       time.Duration // 持ち時間
+	Byoyomi     time.Duration // 秒読み
+	Increment   time.Duration // 1手ごとの加算時間
+	TimeMargin  time.Duration // 時間切れ判定の猶予（通信・起動の遅れの分）
+	MaxMoves    int           // この手数で引き分け（0なら無制限）
+	ResignScore int           // 評価値がこの値以下の手が続いたら投了とみなす（0なら判定しない）
+	ResignMoves int           // ResignScore 以下が何手続いたら投了とみなすか（1未満なら1手）
+}
+
+// 対局結果
+type Outcome int
+
+const (
+	Draw Outcome = iota
+	SenteWin
+	GoteWin
+)
+
+// 勝った側の結果
+func winOf(player piece.Player) Outcome {
+	if player == piece.Sente {
+		return SenteWin
+	}
+	return GoteWin
+}
+
+// 終局理由
+const (
+	ReasonResign         = "resign"
+	ReasonCheckmate      = "checkmate"
+	ReasonTimeUp         = "time up"
+	ReasonIllegalMove    = "illegal move"
+	ReasonSennichite     = "sennichite"
+	ReasonPerpetualCheck = "perpetual check"
+	ReasonDeclaration    = "declaration"
+	ReasonMaxMoves       = "max moves"
+	ReasonAdjudication   = "adjudication"
+	ReasonError          = "error"
+)
+
+// 1局の結果
+type GameResult struct {
+	Names     map[piece.Player]string
+	Start     *board.Board
+	Moves     []board.Move
+	Times     []time.Duration
+	Outcome   Outcome
+	Reason    string
+	StartTime time.Time
+	EndTime   time.Time
+	Err       error // ReasonError の場合のエラー
+}
+
+// 1局指す。start と opening は開始局面と、そこから指し手として記録する序盤の手順
+// 序盤の手順に指せない手があれば、対局せずに ReasonError の結果を返す
+func PlayGame(ctx context.Context, cfg Config, sente, gote Player, start *board.Board, opening []board.Move) *GameResult {
+	players := map[piece.Player]Player{piece.Sente: sente, piece.Gote: gote}
+	res := &GameResult{
+		Names:     map[piece.Player]string{piece.Sente: sente.Name(), piece.Gote: gote.Name()},
+		Start:     start,
+		StartTime: time.Now(),
+	}
+
+	b := replay(start, nil)
+	history := board.NewHistory(b)
+	for i, m := range opening {
+		// 序盤の手順が開始局面に合わなければ対局しない（対局者には知らせない）
+		if err := b.ApplyMove(m); err != nil {
+			res.Err = fmt.Errorf("%w: 序盤の%d手目: %w", ErrInvalidMove, i+1, err)
+			res.Outcome, res.Reason, res.EndTime = Draw, ReasonError, time.Now()
+			return res
+		}
+		history.Push(b)
+		res.Moves = append(res.Moves, m)
+		res.Times = append(res.Times, 0)
+	}
+
+	finish := func(outcome Outcome, reason string) *GameResult {
+		res.Outcome = outcome
+		res.Reason = reason
+		res.EndTime = time.Now()
+		for player, p := range players {
+			switch {
+			case outcome == Draw:
+				p.GameOver("draw")
+			case outcome == winOf(player):
+				p.GameOver("win")
+			default:
+				p.GameOver("lose")
+			}
+		}
+		return res
+	}
+
+	for player, p := range players {
+		if err := p.NewGame(ctx); err != nil {
+			res.Err = err
+			return finish(winOf(player.Opposite()), ReasonError)
+		}
+	}
+
+	clock := Clock{Sente: cfg.Total, Gote: cfg.Total, Byoyomi: cfg.Byoyomi, Increment: cfg.Increment}
+	badScores := map[piece.Player]int{}
+	for {
+		turn := b.CurrentPlayer
+		if len(b.LegalMoves()) == 0 {
+			return finish(winOf(turn.Opposite()), ReasonCheckmate)
+		}
+
+		remaining := clock.Sente
+		if turn == piece.Gote {
+			remaining = clock.Gote
+		}
+		limit := remaining + cfg.Byoyomi + cfg.Increment
+		moveCtx, cancel := context.WithTimeout(ctx, limit+cfg.TimeMargin)
+		begin := time.Now()
+		d, err := players[turn].Play(moveCtx, start, res.Moves, clock)
+		elapsed := time.Since(begin)
+		cancel()
+
+		if ctx.Err() != nil {
+			res.Err = ctx.Err()
+			return finish(Draw, ReasonError)
+		}
+		if err != nil {
+			res.Err = err
+			return finish(winOf(turn.Opposite()), ReasonError)
+		}
+		if elapsed > limit+cfg.TimeMargin {
+			return finish(winOf(turn.Opposite()), ReasonTimeUp)
+		}
+
+		switch {
+		case d.Resign:
+			return finish(winOf(turn.Opposite()), ReasonResign)
+		case d.DeclareWin:
+			if csa.CanDeclareWin(b) {
+				return finish(winOf(turn), ReasonDeclaration)
+			}
+			return finish(winOf(turn.Opposite()), ReasonIllegalMove)
+		case !b.IsLegalMove(d.Move):
+			return finish(winOf(turn.Opposite()), ReasonIllegalMove)
+		}
+
+		// 持ち時間の更新
+		remaining -= elapsed.Truncate(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		remaining += cfg.Increment
+		if turn == piece.Sente {
+			clock.Sente = remaining
+		} else {
+			clock.Gote = remaining
+		}
+
+		b.MakeMove(d.Move)
+		history.Push(b)
+		res.Moves = append(res.Moves, d.Move)
+		res.Times = append(res.Times, elapsed)
+
+		switch rep, checker := history.Repetition(b); rep {
+		case board.RepetitionPerpetualCheck:
+			return finish(winOf(checker.Opposite()), ReasonPerpetualCheck)
+		case board.RepetitionDraw:
+			return finish(Draw, ReasonSennichite)
+		}
+
+		// 評価値による投了の判定
+		if cfg.ResignScore > 0 && d.HasScore {
+			if d.Score <= -cfg.ResignScore {
+				badScores[turn]++
+			} else {
+				badScores[turn] = 0
+			}
+			if badScores[turn] >= max(cfg.ResignMoves, 1) {
+				return finish(winOf(turn.Opposite()), ReasonAdjudication)
+			}
+		}
+
+		if cfg.MaxMoves > 0 && len(res.Moves) >= cfg.MaxMoves {
+			return finish(Draw, ReasonMaxMoves)
+		}
+	}
+}
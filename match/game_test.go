@@ -0,0 +1,57 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"shogi/board"
+)
+
+// 最初の合法手を指し、決まった評価値を返す対局者
+type scoredPlayer struct {
+	score int
+}
+
+func (p *scoredPlayer) Name() string                      { return "scored" }
+func (p *scoredPlayer) NewGame(ctx context.Context) error { return nil }
+func (p *scoredPlayer) GameOver(result string)            {}
+func (p *scoredPlayer) Close() error                      { return nil }
+
+func (p *scoredPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	b := replay(start, moves)
+	return Decision{Move: b.LegalMoves()[0], Score: p.score, HasScore: true}, nil
+}
+
+func TestResignAdjudication(t *testing.T) {
+	tests := []struct {
+		resignMoves int
+		moves       int // 終局までの手数
+	}{
+		// 1未満は1手とみなす（先手の評価値0では投了にならない）
+		{0, 2},
+		{1, 2},
+		{3, 6},
+	}
+	for _, tt := range tests {
+		cfg := Config{Total: time.Minute, ResignScore: 500, ResignMoves: tt.resignMoves}
+		res := PlayGame(context.Background(), cfg, &scoredPlayer{score: 0}, &scoredPlayer{score: -1000}, board.New(), nil)
+		if res.Outcome != SenteWin || res.Reason != ReasonAdjudication || len(res.Moves) != tt.moves {
+			t.Errorf("ResignMoves %d: %v %s %d手, want 先手の勝ち %s %d手",
+				tt.resignMoves, res.Outcome, res.Reason, len(res.Moves), ReasonAdjudication, tt.moves)
+		}
+	}
+}
+
+func TestPlayGameRejectsIllegalOpening(t *testing.T) {
+	opening := []board.Move{
+		{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, // ７六歩
+		{FromX: 2, FromY: 5, ToX: 2, ToY: 4}, // 後手の番に先手の歩を動かす
+	}
+	cfg := Config{Total: time.Minute}
+	res := PlayGame(context.Background(), cfg, &scoredPlayer{}, &scoredPlayer{}, board.New(), opening)
+	if res.Reason != ReasonError || !errors.Is(res.Err, ErrInvalidMove) || !errors.Is(res.Err, board.ErrNoPiece) {
+		t.Fatalf("%s %v, want %s (ErrInvalidMove)", res.Reason, res.Err, ReasonError)
+	}
+}
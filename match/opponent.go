@@ -0,0 +1,176 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"shogi/board"
+	"shogi/csa"
+	"shogi/piece"
+)
+
+var (
+	ErrNotYourTurn  = errors.New("match: 手番ではありません")
+	ErrInvalidMove  = errors.New("match: 不正な指し手です")
+	ErrOpponentDone = errors.New("match: エンジンとの対局は終わっています")
+)
+
+// GUIで人と対局するエンジン（平手の初期局面から）
+// 人の指し手を Send で受け取り、エンジンの指し手を加えた指し手リストを Updates に送る
+// どちらの指し手も開始局面から並べた盤面で調べ、エンジンが指せない手を返したらエンジンの負けにする
+type Opponent struct {
+	player  Player
+	human   piece.Player
+	clock   Clock
+	start   *board.Board // 開始局面（エンジンにもこの局面を渡す）
+	updates chan []board.Move
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	board  *board.Board // start から moves を指した局面
+	moves  []board.Move
+	done   bool   // エンジンが投了・入玉宣言したか、エラーや反則で指せなくなった
+	result string // 終わったときの結果の表示（例: 後手の投了）
+}
+
+// エンジンを起動して対局を始める（人が後手ならエンジンが初手を考え始める）
+// clock はエンジンの1手ごとの持ち時間に使う
+func NewOpponent(f PlayerFactory, human piece.Player, clock Clock) (*Opponent, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p, err := f(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := p.NewGame(ctx); err != nil {
+		p.Close()
+		cancel()
+		return nil, err
+	}
+
+	start := board.New()
+	o := &Opponent{
+		player:  p,
+		human:   human,
+		clock:   clock,
+		start:   start,
+		updates: make(chan []board.Move, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		board:   start.Clone(),
+	}
+	if human != piece.Sente {
+		go o.think(nil)
+	}
+	return o, nil
+}
+
+// 人の手番
+func (o *Opponent) Side() piece.Player {
+	return o.human
+}
+
+// エンジンの名前
+func (o *Opponent) Name() string {
+	return o.player.Name()
+}
+
+// 人の指し手を受け取り、エンジンに次の手を考えさせる
+func (o *Opponent) Send(m board.Move) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done {
+		return ErrOpponentDone
+	}
+	if o.board.CurrentPlayer != o.human {
+		return ErrNotYourTurn
+	}
+	if err := o.board.ApplyMove(m); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMove, err)
+	}
+	o.moves = append(o.moves, m)
+	go o.think(append([]board.Move(nil), o.moves...))
+	return nil
+}
+
+// 確定した指し手リストの更新
+func (o *Opponent) Updates() <-chan []board.Move {
+	return o.updates
+}
+
+// 対局が終わっていれば結果の表示（続いていれば空）
+func (o *Opponent) Result() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.result
+}
+
+// エンジンの指し手を決めて、指し手リストの更新を送る
+func (o *Opponent) think(moves []board.Move) {
+	d, err := o.player.Play(o.ctx, o.start, moves, o.clock)
+
+	o.mu.Lock()
+	engine := o.human.Opposite()
+	switch {
+	case err != nil:
+		if o.ctx.Err() != nil {
+			// Close で止めた
+			o.done = true
+			break
+		}
+		log.Println("エンジンが指せません:", err)
+		o.finishLocked(sideName(o.human) + "の勝ち（エンジンが指せなくなりました）")
+	case d.Resign:
+		o.finishLocked(sideName(engine) + "の投了")
+	case d.DeclareWin:
+		if csa.CanDeclareWin(o.board) {
+			o.finishLocked(sideName(engine) + "の入玉宣言勝ち")
+		} else {
+			o.finishLocked(sideName(o.human) + "の勝ち（エンジンの入玉宣言が条件を満たしていません）")
+		}
+	default:
+		if err := o.board.ApplyMove(d.Move); err != nil {
+			log.Println("エンジンが不正な手を指しました:", err)
+			o.finishLocked(sideName(o.human) + "の勝ち（エンジンの反則）")
+			break
+		}
+		o.moves = append(o.moves, d.Move)
+	}
+	update := append([]board.Move(nil), o.moves...)
+	done := o.done
+	o.mu.Unlock()
+	if done {
+		return
+	}
+
+	// 読まれていない古い更新は捨てて、最新の指し手リストだけを残す
+	select {
+	case <-o.updates:
+	default:
+	}
+	o.updates <- update
+}
+
+// エンジンとの対局を終わりにする（ロックを保持して呼ぶこと）
+func (o *Opponent) finishLocked(result string) {
+	o.done = true
+	o.result = result
+}
+
+// 手番の名前
+func sideName(p piece.Player) string {
+	if p == piece.Gote {
+		return "後手"
+	}
+	return "先手"
+}
+
+// エンジンを終了する
+func (o *Opponent) Close() error {
+	o.cancel()
+	return o.player.Close()
+}
@@ -0,0 +1,108 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 決まった手を返す対局者
+type fixedPlayer struct {
+	decision Decision
+}
+
+func (p *fixedPlayer) Name() string                      { return "fixed" }
+func (p *fixedPlayer) NewGame(ctx context.Context) error { return nil }
+func (p *fixedPlayer) GameOver(result string)            {}
+func (p *fixedPlayer) Close() error                      { return nil }
+
+func (p *fixedPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	return p.decision, nil
+}
+
+// 終局の表示を待つ
+func waitResult(t *testing.T, o *Opponent) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r := o.Result(); r != "" {
+			return r
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("エンジンとの対局が終わりませんでした")
+	return ""
+}
+
+func TestOpponentResult(t *testing.T) {
+	p76 := board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5} // ７六歩
+	tests := []struct {
+		decision Decision
+		want     string
+	}{
+		{Decision{Resign: true}, "後手の投了"},
+		{Decision{DeclareWin: true}, "先手の勝ち"},
+		// 後手の番に先手の歩を動かす
+		{Decision{Move: board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5}}, "先手の勝ち（エンジンの反則）"},
+		// 盤の外への手
+		{Decision{Move: board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 9}}, "先手の勝ち（エンジンの反則）"},
+	}
+	for _, tt := range tests {
+		f := func(ctx context.Context) (Player, error) { return &fixedPlayer{decision: tt.decision}, nil }
+		o, err := NewOpponent(f, piece.Sente, Clock{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Send(p76); err != nil {
+			t.Fatal(err)
+		}
+		if got := waitResult(t, o); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%+v: Result() = %q, want %q", tt.decision, got, tt.want)
+		}
+		// 不正な手は GUI に送らない
+		select {
+		case moves := <-o.Updates():
+			t.Errorf("%+v: 終局後に指し手リスト %+v が届きました", tt.decision, moves)
+		default:
+		}
+		if err := o.Send(board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5}); !errors.Is(err, ErrOpponentDone) {
+			t.Errorf("%+v: 終局後の Send = %v, want ErrOpponentDone", tt.decision, err)
+		}
+		o.Close()
+	}
+}
+
+func TestOpponentSend(t *testing.T) {
+	p34 := board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3} // ３四歩
+	f := func(ctx context.Context) (Player, error) { return &fixedPlayer{decision: Decision{Move: p34}}, nil }
+	o, err := NewOpponent(f, piece.Sente, Clock{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	// 指せない手は受け付けない
+	if err := o.Send(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 4}); !errors.Is(err, ErrInvalidMove) {
+		t.Fatalf("err = %v, want ErrInvalidMove", err)
+	}
+	if err := o.Send(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}); err != nil {
+		t.Fatal(err)
+	}
+	// エンジンの指し手を加えた指し手リストが届く
+	select {
+	case moves := <-o.Updates():
+		if len(moves) != 2 || moves[1] != p34 {
+			t.Fatalf("moves = %+v", moves)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("エンジンの指し手が届きません")
+	}
+	if o.Result() != "" {
+		t.Errorf("Result() = %q, want 空", o.Result())
+	}
+}
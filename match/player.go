@@ -0,0 +1,229 @@
+package match
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"shogi/board"
+	"shogi/book"
+	"shogi/engine"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+// 持ち時間の状態
+type Clock struct {
+	Sente     time.Duration // 先手の残り時間
+	Gote      time.Duration // 後手の残り時間
+	Byoyomi   time.Duration
+	Increment time.Duration
+}
+
+// 手番側が使える時間の目安
+func (c Clock) budget(b *board.Board) time.Duration {
+	remaining := c.Sente
+	if b.CurrentPlayer == piece.Gote {
+		remaining = c.Gote
+	}
+	// 残り時間の1/40と秒読み・加算時間を使う
+	d := remaining/40 + c.Byoyomi + c.Increment
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	return d
+}
+
+// 指し手の決定
+type Decision struct {
+	Move       board.Move
+	Resign     bool // 投了
+	DeclareWin bool // 入玉宣言
+	Score      int  // 手番側から見た評価値
+	HasScore   bool
+}
+
+// 対局者（USIエンジンや内蔵のプレイヤー）
+type Player interface {
+	Name() string
+	NewGame(ctx context.Context) error
+	// start からの指し手 moves の局面で次の手を決める
+	Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error)
+	// 対局の結果を通知（win / lose / draw）
+	GameOver(result string)
+	Close() error
+}
+
+// 対局者を作る関数（並列に対局するため、対局スレッドごとに作る）
+type PlayerFactory func(ctx context.Context) (Player, error)
+
+// 対局者の指定から PlayerFactory を作る
+//
+//	builtin:random       合法手からランダムに選ぶ
+//	builtin:search[:N]   内蔵の探索（最大深さN、既定は3）
+//	それ以外              USIエンジンの実行ファイル（空白区切りで引数を渡せる）
+func NewPlayerFactory(spec string) PlayerFactory {
+	switch {
+	case spec == "builtin:random":
+		return func(ctx context.Context) (Player, error) {
+			return &RandomPlayer{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+		}
+	case strings.HasPrefix(spec, "builtin:search"):
+		depth := 3
+		if s, ok := strings.CutPrefix(spec, "builtin:search:"); ok {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 {
+				depth = n
+			}
+		}
+		return func(ctx context.Context) (Player, error) {
+			return &SearchPlayer{MaxDepth: depth}, nil
+		}
+	default:
+		fields := strings.Fields(spec)
+		return func(ctx context.Context) (Player, error) {
+			e, err := usi.Start(ctx, fields[0], fields[1:]...)
+			if err != nil {
+				return nil, err
+			}
+			return &USIPlayer{Engine: e}, nil
+		}
+	}
+}
+
+// USIエンジンの対局者
+type USIPlayer struct {
+	Engine *usi.Engine
+}
+
+func (p *USIPlayer) Name() string {
+	return p.Engine.Name
+}
+
+func (p *USIPlayer) NewGame(ctx context.Context) error {
+	if err := p.Engine.IsReady(ctx); err != nil {
+		return err
+	}
+	return p.Engine.NewGame()
+}
+
+func (p *USIPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	res, err := p.Engine.Go(ctx, start, moves, usi.Limits{
+		SenteTime: clock.Sente,
+		GoteTime:  clock.Gote,
+		Byoyomi:   clock.Byoyomi,
+		SenteInc:  clock.Increment,
+		GoteInc:   clock.Increment,
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+
+	d := Decision{Score: res.Score, HasScore: res.HasScore}
+	switch res.BestMove {
+	case "resign":
+		d.Resign = true
+	case "win":
+		d.DeclareWin = true
+	default:
+		m, err := usi.ParseMove(res.BestMove)
+		if err != nil {
+			return Decision{}, err
+		}
+		d.Move = m
+	}
+	return d, nil
+}
+
+func (p *USIPlayer) GameOver(result string) {
+	p.Engine.GameOver(result)
+}
+
+func (p *USIPlayer) Close() error {
+	return p.Engine.Quit()
+}
+
+// 合法手からランダムに指す対局者
+type RandomPlayer struct {
+	rand *rand.Rand
+}
+
+func (p *RandomPlayer) Name() string                      { return "random" }
+func (p *RandomPlayer) NewGame(ctx context.Context) error { return nil }
+func (p *RandomPlayer) GameOver(result string)            {}
+func (p *RandomPlayer) Close() error                      { return nil }
+
+func (p *RandomPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	b := replay(start, moves)
+	legal := b.LegalMoves()
+	if len(legal) == 0 {
+		return Decision{Resign: true}, nil
+	}
+	return Decision{Move: legal[p.rand.Intn(len(legal))]}, nil
+}
+
+// 内蔵の探索で指す対局者
+type SearchPlayer struct {
+	MaxDepth int
+}
+
+func (p *SearchPlayer) Name() string                      { return "search" }
+func (p *SearchPlayer) NewGame(ctx context.Context) error { return nil }
+func (p *SearchPlayer) GameOver(result string)            {}
+func (p *SearchPlayer) Close() error                      { return nil }
+
+func (p *SearchPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	b := replay(start, moves)
+	ctx, cancel := context.WithTimeout(ctx, clock.budget(b))
+	defer cancel()
+
+	info := engine.Search(ctx, b, p.MaxDepth, nil)
+	m, ok := info.BestMove()
+	if !ok {
+		// 1手も読めなかった場合は最初の合法手
+		legal := b.LegalMoves()
+		if len(legal) == 0 {
+			return Decision{Resign: true}, nil
+		}
+		return Decision{Move: legal[0]}, nil
+	}
+	return Decision{Move: m, Score: info.Score, HasScore: true}, nil
+}
+
+// 定跡にある局面では定跡手を指し、それ以外は元の対局者に任せる
+type BookPlayer struct {
+	Player
+	Book *book.Book
+	rand *rand.Rand
+}
+
+// 定跡を使う PlayerFactory を作る
+func WithBook(f PlayerFactory, bk *book.Book) PlayerFactory {
+	return func(ctx context.Context) (Player, error) {
+		p, err := f(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &BookPlayer{Player: p, Book: bk, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+	}
+}
+
+func (p *BookPlayer) Play(ctx context.Context, start *board.Board, moves []board.Move, clock Clock) (Decision, error) {
+	if m, ok := p.Book.Pick(replay(start, moves), p.rand); ok {
+		return Decision{Move: m}, nil
+	}
+	return p.Player.Play(ctx, start, moves, clock)
+}
+
+// 開始局面から指し手を進めた盤面
+func replay(start *board.Board, moves []board.Move) *board.Board {
+	b, _, err := board.ParseSFEN(start.SFEN(1))
+	if err != nil {
+		panic(err)
+	}
+	for _, m := range moves {
+		b.MakeMove(m)
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// 対局者A（1番目に指定したエンジン）から見た勝敗
+type Stats struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// 対局数
+func (s Stats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// 勝率（引き分けは0.5勝）
+func (s Stats) Score() float64 {
+	n := s.Games()
+	if n == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + 0.5*float64(s.Draws)) / float64(n)
+}
+
+// 1局あたりの得点の分散
+func (s Stats) variance() float64 {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0
+	}
+	m := s.Score()
+	return (float64(s.Wins)*(1-m)*(1-m) +
+		float64(s.Draws)*(0.5-m)*(0.5-m) +
+		float64(s.Losses)*m*m) / n
+}
+
+// 勝率をレーティング差に変換
+func scoreToElo(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/score-1)
+}
+
+// レーティング差を期待勝率に変換
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// AのBに対するレーティング差
+func (s Stats) Elo() float64 {
+	return scoreToElo(s.Score())
+}
+
+// レーティング差の95%信頼区間の幅（±）
+func (s Stats) EloError() float64 {
+	n := float64(s.Games())
+	if n == 0 || s.Wins == s.Games() || s.Losses == s.Games() {
+		// 全勝・全敗ではレーティング差が定まらない
+		return math.Inf(1)
+	}
+	stderr := math.Sqrt(s.variance() / n)
+	lo := scoreToElo(s.Score() - 1.959964*stderr)
+	hi := scoreToElo(s.Score() + 1.959964*stderr)
+	return (hi - lo) / 2
+}
+
+// 逐次確率比検定（SPRT）の設定
+// H0: レーティング差が Elo0、H1: レーティング差が Elo1
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// SPRTの判定結果
+type SPRTResult int
+
+const (
+	SPRTContinue SPRTResult = iota // 続行
+	SPRTAcceptH0                   // H0を採択（強くなっていない）
+	SPRTAcceptH1                   // H1を採択（強くなった）
+)
+
+func (r SPRTResult) String() string {
+	switch r {
+	case SPRTAcceptH0:
+		return "H0採択"
+	case SPRTAcceptH1:
+		return "H1採択"
+	default:
+		return "続行"
+	}
+}
+
+// 対数尤度比の判定の下限と上限
+func (t SPRT) Bounds() (lower, upper float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// 対数尤度比（得点の正規近似による）
+func (t SPRT) LLR(s Stats) float64 {
+	n := float64(s.Games())
+	v := s.variance()
+	if n == 0 || v == 0 {
+		return 0
+	}
+	s0, s1 := eloToScore(t.Elo0), eloToScore(t.Elo1)
+	return n * (s1 - s0) * (2*s.Score() - s0 - s1) / (2 * v)
+}
+
+// 現在の成績で判定
+func (t SPRT) Test(s Stats) SPRTResult {
+	llr := t.LLR(s)
+	lower, upper := t.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTAcceptH1
+	case llr <= lower:
+		return SPRTAcceptH0
+	default:
+		return SPRTContinue
+	}
+}
+
+// 成績の表示
+func (s Stats) String() string {
+	return fmt.Sprintf("%d局 %d勝 %d分 %d敗 勝率 %.3f Elo %+.1f ±%.1f",
+		s.Games(), s.Wins, s.Draws, s.Losses, s.Score(), s.Elo(), s.EloError())
+}
@@ -0,0 +1,68 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStatsElo(t *testing.T) {
+	tests := []struct {
+		stats    Stats
+		score    float64
+		elo      float64
+		eloError float64
+	}{
+		{Stats{Wins: 60, Draws: 20, Losses: 20}, 0.7, 147.19, 66.01},
+		{Stats{Wins: 30, Draws: 40, Losses: 30}, 0.5, 0, 53.16},
+		{Stats{Wins: 10, Draws: 0, Losses: 30}, 0.25, -190.85, 135.58},
+		{Stats{Wins: 5}, 1, math.Inf(1), math.Inf(1)},
+		{Stats{Losses: 5}, 0, math.Inf(-1), math.Inf(1)},
+	}
+	for _, tt := range tests {
+		if got := tt.stats.Score(); !near(got, tt.score) {
+			t.Errorf("%+v: Score() = %v, want %v", tt.stats, got, tt.score)
+		}
+		if got := tt.stats.Elo(); !near(got, tt.elo) {
+			t.Errorf("%+v: Elo() = %v, want %v", tt.stats, got, tt.elo)
+		}
+		if got := tt.stats.EloError(); !near(got, tt.eloError) {
+			t.Errorf("%+v: EloError() = %v, want %v", tt.stats, got, tt.eloError)
+		}
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	sprt := SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	if lower, upper := sprt.Bounds(); !near(lower, -2.94) || !near(upper, 2.94) {
+		t.Errorf("Bounds() = %v, %v, want -2.94, 2.94", lower, upper)
+	}
+
+	tests := []struct {
+		stats Stats
+		llr   float64
+		want  SPRTResult
+	}{
+		{Stats{Wins: 600, Draws: 200, Losses: 400}, 6.53, SPRTAcceptH1},
+		{Stats{Wins: 400, Draws: 200, Losses: 600}, -7.76, SPRTAcceptH0},
+		{Stats{Wins: 520, Draws: 200, Losses: 480}, 0.79, SPRTContinue},
+		{Stats{}, 0, SPRTContinue},
+		// 全局引き分けでは分散が0なので判定しない
+		{Stats{Draws: 100}, 0, SPRTContinue},
+	}
+	for _, tt := range tests {
+		if got := sprt.LLR(tt.stats); !near(got, tt.llr) {
+			t.Errorf("%+v: LLR() = %v, want %v", tt.stats, got, tt.llr)
+		}
+		if got := sprt.Test(tt.stats); got != tt.want {
+			t.Errorf("%+v: Test() = %v, want %v", tt.stats, got, tt.want)
+		}
+	}
+}
+
+// 小数第2位まで一致するか（無限大は符号まで一致するか）
+func near(got, want float64) bool {
+	if math.IsInf(want, 0) {
+		return got == want
+	}
+	return math.Abs(got-want) < 0.01
+}
@@ -0,0 +1,309 @@
+package match
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"shogi/board"
+	"shogi/csa"
+	"shogi/kif"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+// 開始局面（局面と、そこから記録する序盤の手順）
+type Opening struct {
+	Start *board.Board
+	Moves []board.Move
+}
+
+// 開始局面の一覧を読み込む
+// 1行に1局面で、SFEN（例: lnsgkgsnl/... b - 1）か USI の position 形式
+// （例: startpos moves 7g7f 3c3d）。空行と # で始まる行は無視する
+func ReadOpenings(r io.Reader) ([]Opening, error) {
+	var openings []Opening
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var o Opening
+		var err error
+		if strings.HasPrefix(line, "startpos") || strings.HasPrefix(line, "sfen ") {
+			o.Start, o.Moves, err = usi.ParsePosition(line)
+		} else {
+			o.Start, _, err = board.ParseSFEN(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%d行目: %w", lineNo, err)
+		}
+		openings = append(openings, o)
+	}
+	return openings, s.Err()
+}
+
+// 2者間の連続対局
+type Tournament struct {
+	Config      Config
+	PlayerA     PlayerFactory
+	PlayerB     PlayerFactory
+	Games       int       // 対局数
+	Concurrency int       // 同時に指す対局数
+	Openings    []Opening // 開始局面（空なら平手）。各局面を先後入れ替えて2局ずつ使う
+	OutDir      string    // 棋譜（KIF・CSA）の保存先（空なら保存しない）
+	SPRT        *SPRT     // 指定すれば判定がついた時点で打ち切る
+
+	// 1局終わるごとに呼ばれる（nil可）。n は0から始まる対局番号
+	OnGame func(n int, res *GameResult, aIsSente bool, stats Stats)
+}
+
+// 対局の割り当て
+type job struct {
+	n        int
+	aIsSente bool
+	opening  Opening
+}
+
+// 対局の結果
+type jobResult struct {
+	job
+	res *GameResult
+}
+
+// 全対局を指し終えるか、SPRTの判定がつくまで対局する
+func (t *Tournament) Run(ctx context.Context) (Stats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if t.OutDir != "" {
+		if err := os.MkdirAll(t.OutDir, 0o755); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	jobs := make(chan job)
+	results := make(chan jobResult)
+	concurrency := t.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := t.worker(ctx, jobs, results); err != nil {
+				errs <- err
+				cancel()
+			}
+		}()
+	}
+
+	// 対局の割り当て
+	go func() {
+		defer close(jobs)
+		for n := 0; n < t.Games; n++ {
+			j := job{n: n, aIsSente: n%2 == 0, opening: Opening{Start: board.New()}}
+			if len(t.Openings) > 0 {
+				j.opening = t.Openings[(n/2)%len(t.Openings)]
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var stats Stats
+	for r := range results {
+		if r.res.Reason == ReasonError && ctx.Err() != nil {
+			// 打ち切りで中断した対局は数えない
+			continue
+		}
+		stats.add(r.res.Outcome, r.aIsSente)
+		if err := t.save(r.n, r.res); err != nil {
+			cancel()
+			return stats, err
+		}
+		if t.OnGame != nil {
+			t.OnGame(r.n, r.res, r.aIsSente, stats)
+		}
+		if t.SPRT != nil && t.SPRT.Test(stats) != SPRTContinue {
+			cancel()
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return stats, err
+	default:
+		return stats, nil
+	}
+}
+
+// 対局スレッド。対局者を作って割り当てられた対局を順に指す
+func (t *Tournament) worker(ctx context.Context, jobs <-chan job, results chan<- jobResult) error {
+	a, err := t.PlayerA(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	b, err := t.PlayerB(ctx)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	for j := range jobs {
+		sente, gote := a, b
+		if !j.aIsSente {
+			sente, gote = b, a
+		}
+		res := PlayGame(ctx, t.Config, sente, gote, j.opening.Start, j.opening.Moves)
+		select {
+		case results <- jobResult{job: j, res: res}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// 成績に1局分を加える
+func (s *Stats) add(outcome Outcome, aIsSente bool) {
+	switch {
+	case outcome == Draw:
+		s.Draws++
+	case (outcome == SenteWin) == aIsSente:
+		s.Wins++
+	default:
+		s.Losses++
+	}
+}
+
+// 棋譜をKIFとCSAで保存
+func (t *Tournament) save(n int, res *GameResult) error {
+	if t.OutDir == "" {
+		return nil
+	}
+	base := filepath.Join(t.OutDir, fmt.Sprintf("game-%04d", n+1))
+
+	winner := piece.None
+	switch res.Outcome {
+	case SenteWin:
+		winner = piece.Sente
+	case GoteWin:
+		winner = piece.Gote
+	}
+
+	k := &kif.Record{
+		Names:     res.Names,
+		Event:     fmt.Sprintf("shogi-match 第%d局", n+1),
+		StartTime: res.StartTime,
+		EndTime:   res.EndTime,
+		Initial:   res.Start,
+		Moves:     res.Moves,
+		Times:     res.Times,
+		End:       kifEnd(res.Reason),
+		Winner:    winner,
+	}
+	if err := writeFile(base+".kif", func(w io.Writer) error { return kif.WriteRecord(w, k) }); err != nil {
+		return err
+	}
+
+	c := &csa.Record{
+		Names:     res.Names,
+		Event:     k.Event,
+		StartTime: res.StartTime,
+		EndTime:   res.EndTime,
+		Initial:   res.Start,
+		Moves:     res.Moves,
+		Times:     res.Times,
+		End:       csaEnd(res.Reason),
+		Comment:   fmt.Sprintf("result:%s:%s", outcomeString(res.Outcome), res.Reason),
+	}
+	return writeFile(base+".csa", func(w io.Writer) error { return csa.WriteRecord(w, c) })
+}
+
+// ファイルに書き出す
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// 終局理由のKIF形式の表記
+func kifEnd(reason string) string {
+	switch reason {
+	case ReasonResign, ReasonAdjudication:
+		return "投了"
+	case ReasonCheckmate:
+		return "詰み"
+	case ReasonTimeUp:
+		return "切れ負け"
+	case ReasonIllegalMove:
+		return "反則負け"
+	case ReasonSennichite, ReasonPerpetualCheck:
+		return "千日手"
+	case ReasonDeclaration:
+		return "入玉勝ち"
+	case ReasonMaxMoves:
+		return "持将棋"
+	default:
+		return "中断"
+	}
+}
+
+// 終局理由のCSA形式の表記
+func csaEnd(reason string) string {
+	switch reason {
+	case ReasonResign, ReasonAdjudication:
+		return "%TORYO"
+	case ReasonCheckmate:
+		return "%TSUMI"
+	case ReasonTimeUp:
+		return "%TIME_UP"
+	case ReasonIllegalMove:
+		return "%ILLEGAL_MOVE"
+	case ReasonSennichite, ReasonPerpetualCheck:
+		return "%SENNICHITE"
+	case ReasonDeclaration:
+		return "%KACHI"
+	case ReasonMaxMoves:
+		return "%HIKIWAKE"
+	default:
+		return "%CHUDAN"
+	}
+}
+
+func outcomeString(o Outcome) string {
+	switch o {
+	case SenteWin:
+		return "sente_win"
+	case GoteWin:
+		return "gote_win"
+	default:
+		return "draw"
+	}
+}
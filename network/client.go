@@ -0,0 +1,275 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 再接続を試みる間隔
+var ReconnectInterval = time.Second
+
+// 対局に参加する側
+type Client struct {
+	*session
+	addr     string
+	interval time.Duration // 再接続の間隔
+	conn     net.Conn
+	closed   bool
+	done     chan struct{}
+}
+
+// サーバーに接続して対局に参加する
+func Join(addr string) (*Client, error) {
+	c := &Client{
+		session:  newSession(piece.None),
+		addr:     addr,
+		interval: ReconnectInterval,
+		done:     make(chan struct{}),
+	}
+
+	conn, r, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	go c.run(conn, r)
+	return c, nil
+}
+
+// 接続して HELLO を送り、手番と棋譜の同期を受け取る
+func (c *Client) connect() (net.Conn, *bufio.Reader, error) {
+	conn, err := net.Dial("tcp", c.addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	known := len(c.moves)
+	c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(conn, "%s %d\n", cmdHello, known); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	if err := c.readSync(conn, r); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		conn.Close()
+		return nil, nil, ErrClosed
+	}
+	c.conn = conn
+	return conn, r, nil
+}
+
+// SIDE と SYNC を読み取り、サーバーの棋譜に合わせる
+func (c *Client) readSync(conn net.Conn, r *bufio.Reader) error {
+	cmd, args, err := readMessage(r)
+	if err != nil {
+		return err
+	}
+	if cmd != cmdSide || len(args) != 1 {
+		return errBadMessage
+	}
+	side, err := parseSide(args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd, args, err = readMessage(r)
+	if err != nil {
+		return err
+	}
+	if cmd != cmdSync {
+		return errBadMessage
+	}
+	moves, err := readSync(r, args)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.side = side
+
+	// 切断中に指した手がサーバーに届いていなければ送り直す
+	var pending []board.Move
+	if len(c.moves) > len(moves) && isPrefix(moves, c.moves) {
+		pending = c.moves[len(moves):]
+	}
+
+	if err := c.resetLocked(moves); err != nil {
+		return err
+	}
+	for _, m := range pending {
+		if c.applyLocked(m, c.side) != nil {
+			break
+		}
+		fmt.Fprintln(conn, formatMove(m))
+	}
+	c.notifyLocked()
+	return nil
+}
+
+// 受信ループ。切断されたら再接続する
+func (c *Client) run(conn net.Conn, r *bufio.Reader) {
+	for {
+		c.receive(conn, r)
+		conn.Close()
+
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(c.interval):
+			}
+
+			var err error
+			conn, r, err = c.connect()
+			if err == nil {
+				break
+			}
+			if err == ErrClosed {
+				return
+			}
+			log.Println("network: 再接続に失敗しました:", err)
+		}
+	}
+}
+
+// 接続が切れるまでサーバーからのメッセージを処理
+func (c *Client) receive(conn net.Conn, r *bufio.Reader) {
+	for {
+		cmd, args, err := readMessage(r)
+		if err != nil {
+			return
+		}
+
+		switch cmd {
+		case cmdMove:
+			m, err := parseMove(args)
+			if err == nil {
+				err = c.receiveMove(m)
+			}
+			if err != nil {
+				// 盤面が食い違ったので同期し直す
+				log.Println("network: サーバーの指し手を受け付けられません:", err)
+				if _, err := fmt.Fprintf(conn, "%s %d\n", cmdHello, len(c.Moves())); err != nil {
+					return
+				}
+			}
+		case cmdSide:
+			// HELLO への応答として SIDE, SYNC が続く
+			if err := c.readSyncAfterSide(r, args); err != nil {
+				return
+			}
+		case cmdError:
+			log.Println("network: サーバーが指し手を拒否しました:", args)
+		}
+	}
+}
+
+// 受信ループ中に届いた SIDE 以降の同期を処理
+func (c *Client) readSyncAfterSide(r *bufio.Reader, args []string) error {
+	if len(args) != 1 {
+		return errBadMessage
+	}
+	side, err := parseSide(args[0])
+	if err != nil {
+		return err
+	}
+	cmd, args, err := readMessage(r)
+	if err != nil {
+		return err
+	}
+	if cmd != cmdSync {
+		return errBadMessage
+	}
+	moves, err := readSync(r, args)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.side = side
+	if err := c.resetLocked(moves); err != nil {
+		return err
+	}
+	c.notifyLocked()
+	return nil
+}
+
+// サーバーの指し手を検証して適用
+func (c *Client) receiveMove(m board.Move) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.applyLocked(m, c.side.Opposite()); err != nil {
+		return err
+	}
+	c.notifyLocked()
+	return nil
+}
+
+// ローカル側の指し手を適用してサーバーに送信
+// 切断中の指し手は再接続時に送り直される
+func (c *Client) Send(m board.Move) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if err := c.applyLocked(m, c.side); err != nil {
+		return err
+	}
+	c.notifyLocked()
+
+	if c.conn != nil {
+		if _, err := fmt.Fprintln(c.conn, formatMove(m)); err != nil {
+			log.Println("network: 送信に失敗しました:", err)
+		}
+	}
+	return nil
+}
+
+// 接続を切断し、再接続も止める
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// a が b の先頭部分と一致するか
+func isPrefix(a, b []board.Move) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 指定した手数の指し手リストが届くまで待つ
+func waitMoves(t *testing.T, ch <-chan []board.Move, n int) []board.Move {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case moves := <-ch:
+			if len(moves) == n {
+				return moves
+			}
+		case <-timeout:
+			t.Fatalf("%d手の同期を待ちきれませんでした", n)
+		}
+	}
+}
+
+func TestLoopbackGame(t *testing.T) {
+	server, err := Host("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := Join(server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if client.Side() != piece.Gote {
+		t.Fatalf("client side = %v, want Gote", client.Side())
+	}
+
+	// ７六歩
+	if err := server.Send(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}); err != nil {
+		t.Fatal(err)
+	}
+	waitMoves(t, client.Updates(), 1)
+
+	// 後手番でないのに先手が指すと拒否される
+	if err := server.Send(board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5}); err != ErrNotYourTurn {
+		t.Fatalf("err = %v, want ErrNotYourTurn", err)
+	}
+
+	// 不正な手はクライアント側で拒否される
+	// 拒否の理由も返る
+	if err := client.Send(board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 4}); !errors.Is(err, ErrInvalidMove) ||
+		!errors.Is(err, board.ErrIllegalDestination) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrIllegalDestination)", err)
+	}
+
+	// ３四歩
+	if err := client.Send(board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3}); err != nil {
+		t.Fatal(err)
+	}
+	waitMoves(t, server.Updates(), 2)
+}
+
+func TestReconnectResumesFromServer(t *testing.T) {
+	old := ReconnectInterval
+	ReconnectInterval = 10 * time.Millisecond
+	defer func() { ReconnectInterval = old }()
+
+	server, err := Host("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := Join(server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := server.Send(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}); err != nil {
+		t.Fatal(err)
+	}
+	waitMoves(t, client.Updates(), 1)
+
+	// 接続を切り、切断中にクライアントが指す
+	client.mu.Lock()
+	client.conn.Close()
+	client.mu.Unlock()
+	if err := client.Send(board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 再接続後にサーバーへ届き、その後の手も同期される
+	waitMoves(t, server.Updates(), 2)
+	if err := server.Send(board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5}); err != nil {
+		t.Fatal(err)
+	}
+	moves := waitMoves(t, client.Updates(), 3)
+	if want := server.Moves(); !isPrefix(want, moves) || len(want) != len(moves) {
+		t.Fatalf("client moves = %v, want %v", moves, want)
+	}
+}
+
+func TestSessionRejectsIllegalMove(t *testing.T) {
+	// ５手目の７七玉は後手の角の利きに入る（自玉が取られる手）
+	moves := []board.Move{
+		{FromX: 2, FromY: 6, ToX: 2, ToY: 5}, // ７六歩
+		{FromX: 6, FromY: 2, ToX: 6, ToY: 3}, // ３四歩
+		{FromX: 4, FromY: 8, ToX: 3, ToY: 7}, // ６八玉
+		{FromX: 8, FromY: 2, ToX: 8, ToY: 3}, // １四歩
+		{FromX: 3, FromY: 7, ToX: 2, ToY: 6}, // ７七玉
+	}
+
+	s := newSession(piece.Sente)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for i, m := range moves {
+		if err = s.applyLocked(m, s.board.CurrentPlayer); err != nil && i < len(moves)-1 {
+			t.Fatalf("%d手目: %v", i+1, err)
+		}
+	}
+	if !errors.Is(err, ErrInvalidMove) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want ErrInvalidMove (ErrLeavesKingInCheck)", err)
+	}
+
+	// 相手から届いた指し手リストでも同じ
+	if err := s.resetLocked(moves); !errors.Is(err, errSyncMismatch) || !errors.Is(err, board.ErrLeavesKingInCheck) {
+		t.Fatalf("err = %v, want errSyncMismatch (ErrLeavesKingInCheck)", err)
+	}
+}
+
+// HELLO を送っていない接続からの指し手は適用しない
+func TestServerRejectsMoveWithoutHello(t *testing.T) {
+	server, err := Host("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// 後手の手番にしてから、後手の３四歩を送る
+	if err := server.Send(board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(conn, formatMove(board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3}))
+
+	cmd, _, err := readMessage(bufio.NewReader(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != cmdError {
+		t.Errorf("応答 = %s, want %s", cmd, cmdError)
+	}
+	if moves := server.Moves(); len(moves) != 1 {
+		t.Errorf("server moves = %v, want 1手", moves)
+	}
+}
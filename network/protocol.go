@@ -0,0 +1,206 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 行ベースの通信プロトコル
+//
+//	HELLO <手数>                   クライアント→サーバー 接続（再接続）時の挨拶
+//	SIDE <sente|gote>              サーバー→クライアント クライアント側の手番
+//	SYNC <手数>                    サーバー→クライアント 続けて手数分の MOVE 行を送る
+//	MOVE <fx> <fy> <tx> <ty> <駒> <成>  指し手（座標は board.Move と同じ）
+//	ERROR <理由>                   指し手を拒否した
+const (
+	cmdHello = "HELLO"
+	cmdSide  = "SIDE"
+	cmdSync  = "SYNC"
+	cmdMove  = "MOVE"
+	cmdError = "ERROR"
+)
+
+var (
+	ErrNotYourTurn  = errors.New("network: 手番ではありません")
+	ErrInvalidMove  = errors.New("network: 不正な指し手です")
+	ErrClosed       = errors.New("network: 接続は閉じられています")
+	errBadMessage   = errors.New("network: 不正なメッセージです")
+	errUnknownSide  = errors.New("network: 不明な手番です")
+	errSyncMismatch = errors.New("network: 棋譜の同期に失敗しました")
+	errNotSynced    = errors.New("network: HELLO を送っていない接続からの指し手です")
+)
+
+// 指し手をプロトコルの行に変換
+func formatMove(m board.Move) string {
+	promote := 0
+	if m.Promote {
+		promote = 1
+	}
+	return fmt.Sprintf("%s %d %d %d %d %d %d",
+		cmdMove, m.FromX, m.FromY, m.ToX, m.ToY, int(m.Piece), promote)
+}
+
+// MOVE 行の引数から指し手を復元
+func parseMove(args []string) (board.Move, error) {
+	if len(args) != 6 {
+		return board.Move{}, errBadMessage
+	}
+	var v [6]int
+	for i, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			return board.Move{}, errBadMessage
+		}
+		v[i] = n
+	}
+	return board.Move{
+		FromX:   v[0],
+		FromY:   v[1],
+		ToX:     v[2],
+		ToY:     v[3],
+		Piece:   piece.Type(v[4]),
+		Promote: v[5] == 1,
+	}, nil
+}
+
+func formatSide(p piece.Player) string {
+	if p == piece.Gote {
+		return "gote"
+	}
+	return "sente"
+}
+
+func parseSide(s string) (piece.Player, error) {
+	switch s {
+	case "sente":
+		return piece.Sente, nil
+	case "gote":
+		return piece.Gote, nil
+	}
+	return piece.None, errUnknownSide
+}
+
+// 1行を読み取り、コマンドと引数に分割
+func readMessage(r *bufio.Reader) (string, []string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", nil, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil, errBadMessage
+	}
+	return fields[0], fields[1:], nil
+}
+
+// SYNC に続く指し手を読み取る
+func readSync(r *bufio.Reader, args []string) ([]board.Move, error) {
+	if len(args) != 1 {
+		return nil, errBadMessage
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return nil, errBadMessage
+	}
+	moves := make([]board.Move, 0, n)
+	for i := 0; i < n; i++ {
+		cmd, args, err := readMessage(r)
+		if err != nil {
+			return nil, err
+		}
+		if cmd != cmdMove {
+			return nil, errBadMessage
+		}
+		m, err := parseMove(args)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// 対局の状態（サーバー・クライアント共通）
+type session struct {
+	mu      sync.Mutex
+	side    piece.Player // ローカル側の手番
+	board   *board.Board
+	moves   []board.Move
+	updates chan []board.Move
+}
+
+func newSession(side piece.Player) *session {
+	return &session{
+		side:    side,
+		board:   board.New(),
+		updates: make(chan []board.Move, 1),
+	}
+}
+
+// ローカル側の手番
+func (s *session) Side() piece.Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.side
+}
+
+// 棋譜が更新されるたびに最新の指し手リストを受け取るチャネル
+func (s *session) Updates() <-chan []board.Move {
+	return s.updates
+}
+
+// 対局が終わっていれば結果の表示（投了などは伝え合わないので常に空）
+func (s *session) Result() string {
+	return ""
+}
+
+// 現在の指し手リストのコピーを取得
+func (s *session) Moves() []board.Move {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]board.Move(nil), s.moves...)
+}
+
+// 指し手を検証して適用（呼び出し側でロックを保持すること）
+func (s *session) applyLocked(m board.Move, player piece.Player) error {
+	if s.board.CurrentPlayer != player {
+		return ErrNotYourTurn
+	}
+	if err := s.board.CheckMove(m); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMove, err)
+	}
+	s.board.MakeMove(m)
+	s.moves = append(s.moves, m)
+	return nil
+}
+
+// 指し手リストで盤面を置き換える（呼び出し側でロックを保持すること）
+func (s *session) resetLocked(moves []board.Move) error {
+	b := board.New()
+	for _, m := range moves {
+		if err := b.CheckMove(m); err != nil {
+			return fmt.Errorf("%w: %w", errSyncMismatch, err)
+		}
+		b.MakeMove(m)
+	}
+	s.board = b
+	s.moves = append([]board.Move(nil), moves...)
+	return nil
+}
+
+// 最新の指し手リストを通知（古い通知は捨てる）
+func (s *session) notifyLocked() {
+	moves := append([]board.Move(nil), s.moves...)
+	select {
+	case <-s.updates:
+	default:
+	}
+	s.updates <- moves
+}
@@ -0,0 +1,171 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 対局を主催する側（先手）。棋譜の正本を持つ
+type Server struct {
+	*session
+	listener net.Listener
+	conn     net.Conn // 現在接続中のクライアント
+	closed   bool
+}
+
+// 指定アドレスで対局を待ち受ける
+func Host(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		session:  newSession(piece.Sente),
+		listener: l,
+	}
+	go s.acceptLoop()
+	return s, nil
+}
+
+// 待ち受けているアドレス
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// 接続の受付ループ。再接続してきたクライアントは HELLO の時点で前の接続と置き換える
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.serve(conn)
+	}
+}
+
+// クライアントとの通信処理
+func (s *Server) serve(conn net.Conn) {
+	defer s.dropConn(conn)
+
+	r := bufio.NewReader(conn)
+	for {
+		cmd, args, err := readMessage(r)
+		if err != nil {
+			return
+		}
+
+		switch cmd {
+		case cmdHello:
+			if err := s.sendSync(conn); err != nil {
+				return
+			}
+		case cmdMove:
+			m, err := parseMove(args)
+			if err != nil {
+				fmt.Fprintf(conn, "%s %v\n", cmdError, err)
+				continue
+			}
+			if err := s.receiveMove(conn, m); errors.Is(err, errNotSynced) {
+				// 置き換えられた古い接続や HELLO 前の接続の指し手は、棋譜を送り直さずに拒否する
+				fmt.Fprintf(conn, "%s %v\n", cmdError, err)
+			} else if err != nil {
+				// 拒否した場合は正本の棋譜を送り直す
+				fmt.Fprintf(conn, "%s %v\n", cmdError, err)
+				if err := s.sendSync(conn); err != nil {
+					return
+				}
+			}
+		default:
+			fmt.Fprintf(conn, "%s %v\n", cmdError, errBadMessage)
+		}
+	}
+}
+
+// 手番と正本の棋譜をクライアントに送り、以降の指し手の送信先にする
+func (s *Server) sendSync(conn net.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.conn != nil && s.conn != conn {
+		s.conn.Close()
+	}
+	s.conn = conn
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "%s %s\n", cmdSide, formatSide(s.side.Opposite()))
+	fmt.Fprintf(w, "%s %s\n", cmdSync, strconv.Itoa(len(s.moves)))
+	for _, m := range s.moves {
+		fmt.Fprintln(w, formatMove(m))
+	}
+	return w.Flush()
+}
+
+// クライアントの指し手を検証して適用（HELLO を送って同期した接続からの指し手だけ受け付ける）
+func (s *Server) receiveMove(conn net.Conn, m board.Move) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if conn != s.conn {
+		return errNotSynced
+	}
+	if err := s.applyLocked(m, s.side.Opposite()); err != nil {
+		return err
+	}
+	s.notifyLocked()
+	return nil
+}
+
+// 切断された接続を片付ける
+func (s *Server) dropConn(conn net.Conn) {
+	conn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == conn {
+		s.conn = nil
+	}
+}
+
+// ローカル側の指し手を適用してクライアントに送信
+// クライアントが切断中でも棋譜には記録され、再接続時に同期される
+func (s *Server) Send(m board.Move) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if err := s.applyLocked(m, s.side); err != nil {
+		return err
+	}
+	s.notifyLocked()
+
+	if s.conn != nil {
+		if _, err := fmt.Fprintln(s.conn, formatMove(m)); err != nil {
+			log.Println("network: 送信に失敗しました:", err)
+		}
+	}
+	return nil
+}
+
+// 対局を終了
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mu.Unlock()
+	return s.listener.Close()
+}
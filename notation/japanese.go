@@ -0,0 +1,326 @@
+package notation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+// 全角数字（筋）
+var fileNumbers = []string{"", "１", "２", "３", "４", "５", "６", "７", "８", "９"}
+
+// 漢数字（段）
+var rankNumbers = []string{"", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+
+// 駒の名前
+var pieceNames = map[piece.Type]string{
+	piece.Pawn:       "歩",
+	piece.Lance:      "香",
+	piece.Knight:     "桂",
+	piece.Silver:     "銀",
+	piece.Gold:       "金",
+	piece.Bishop:     "角",
+	piece.Rook:       "飛",
+	piece.King:       "玉",
+	piece.PromPawn:   "と",
+	piece.PromLance:  "成香",
+	piece.PromKnight: "成桂",
+	piece.PromSilver: "成銀",
+	piece.PromBishop: "馬",
+	piece.PromRook:   "龍",
+}
+
+// 駒の名前の別表記
+var pieceAliases = map[string]piece.Type{
+	"王": piece.King,
+	"竜": piece.PromRook,
+	"全": piece.PromSilver,
+	"圭": piece.PromKnight,
+	"杏": piece.PromLance,
+}
+
+// 指し手を日本式の表記（例: ▲７六歩、△同　銀、▲５二金右、▲５五角打）に変換
+// prev は直前の指し手（「同」の判定に使う、なければnil）。指す前の盤面を渡す
+func FormatJapanese(b *board.Board, m board.Move, prev *board.Move) string {
+	var sb strings.Builder
+	if b.CurrentPlayer == piece.Gote {
+		sb.WriteString("△")
+	} else {
+		sb.WriteString("▲")
+	}
+
+	t := movedType(b, m)
+	name := pieceNames[t]
+	if prev != nil && prev.ToX == m.ToX && prev.ToY == m.ToY {
+		sb.WriteString("同")
+		if utf8.RuneCountInString(name) == 1 {
+			sb.WriteString("　")
+		}
+	} else {
+		sb.WriteString(fileNumbers[board.BoardSize-m.ToX] + rankNumbers[m.ToY+1])
+	}
+	sb.WriteString(name)
+	sb.WriteString(relativeWords(b, m, t))
+
+	switch {
+	case m.Promote:
+		sb.WriteString("成")
+	case couldPromote(b, m):
+		sb.WriteString("不成")
+	}
+	return sb.String()
+}
+
+// 前への移動量と、指す側から見た右寄りの度合い
+func forwardAndRight(player piece.Player, m board.Move) (forward, right int) {
+	if player == piece.Gote {
+		return m.ToY - m.FromY, board.BoardSize - 1 - m.FromX
+	}
+	return m.FromY - m.ToY, m.FromX
+}
+
+// 動作を表す語（上・引・寄）
+func motionWord(player piece.Player, m board.Move) string {
+	forward, _ := forwardAndRight(player, m)
+	switch {
+	case forward > 0:
+		return "上"
+	case forward < 0:
+		return "引"
+	}
+	return "寄"
+}
+
+// 同じ種類の駒が同じマスに動ける場合の区別（右・左・直・上・引・寄・打）
+func relativeWords(b *board.Board, m board.Move, t piece.Type) string {
+	var others []board.Move
+	for _, c := range sameDestination(b, t, m.ToX, m.ToY) {
+		if c.FromX != m.FromX || c.FromY != m.FromY {
+			others = append(others, c)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+
+	// 盤上の駒も動ける場合の駒打ち
+	if isDrop(m) {
+		return "打"
+	}
+	var onBoard []board.Move
+	for _, c := range others {
+		if !isDrop(c) {
+			onBoard = append(onBoard, c)
+		}
+	}
+	if len(onBoard) == 0 {
+		return ""
+	}
+
+	// 動作で区別できればそれを使う
+	player := b.CurrentPlayer
+	motion := motionWord(player, m)
+	var sameMotion []board.Move
+	for _, c := range onBoard {
+		if motionWord(player, c) == motion {
+			sameMotion = append(sameMotion, c)
+		}
+	}
+	if len(sameMotion) == 0 {
+		return motion
+	}
+
+	// 真っすぐ上がる手は「直」（竜・馬には使わない）
+	forward, right := forwardAndRight(player, m)
+	if m.FromX == m.ToX && forward > 0 && t != piece.PromRook && t != piece.PromBishop {
+		return "直"
+	}
+
+	// 同じ動作の駒の中での位置で区別し、全体で区別できなければ動作も付ける
+	pos := "左"
+	if isRightmost(player, right, sameMotion) {
+		pos = "右"
+	}
+	if pos == "右" && isRightmost(player, right, onBoard) ||
+		pos == "左" && isLeftmost(player, right, onBoard) {
+		return pos
+	}
+	return pos + motion
+}
+
+// 他の候補より右にあるか
+func isRightmost(player piece.Player, right int, others []board.Move) bool {
+	for _, c := range others {
+		if _, r := forwardAndRight(player, c); r >= right {
+			return false
+		}
+	}
+	return true
+}
+
+// 他の候補より左にあるか
+func isLeftmost(player piece.Player, right int, others []board.Move) bool {
+	for _, c := range others {
+		if _, r := forwardAndRight(player, c); r <= right {
+			return false
+		}
+	}
+	return true
+}
+
+// 日本式の表記の指し手を解析（▲△の印は省略できる）
+// prev は直前の指し手（「同」の解決に使う、なければnil）。指す前の盤面を渡す
+func ParseJapanese(b *board.Board, s string, prev *board.Move) (board.Move, error) {
+	invalid := fmt.Errorf("%w: %s", ErrInvalidMove, s)
+	rest := strings.TrimSpace(s)
+	for _, mark := range []string{"▲", "△", "☗", "☖"} {
+		rest = strings.TrimPrefix(rest, mark)
+	}
+
+	// 移動先
+	var toX, toY int
+	if after, ok := strings.CutPrefix(rest, "同"); ok {
+		if prev == nil {
+			return board.Move{}, invalid
+		}
+		toX, toY = prev.ToX, prev.ToY
+		rest = strings.TrimLeft(after, "　 ")
+	} else {
+		runes := []rune(rest)
+		if len(runes) < 2 {
+			return board.Move{}, invalid
+		}
+		file, rank := indexOf(fileNumbers, string(runes[0])), indexOf(rankNumbers, string(runes[1]))
+		if file < 1 || rank < 1 {
+			return board.Move{}, invalid
+		}
+		toX, toY = board.BoardSize-file, rank-1
+		rest = string(runes[2:])
+	}
+
+	// 駒の種類（2文字の名前を優先）
+	t, rest, ok := cutPieceName(rest)
+	if !ok {
+		return board.Move{}, invalid
+	}
+
+	// 成・不成
+	promote := false
+	if r, ok := strings.CutSuffix(rest, "不成"); ok {
+		rest = r
+	} else if r, ok := strings.CutSuffix(rest, "成"); ok {
+		rest, promote = r, true
+	}
+
+	// 区別の語
+	var drop bool
+	var motion, pos string
+	for _, r := range rest {
+		switch r {
+		case '打':
+			drop = true
+		case '上', '行', '入':
+			motion = "上"
+		case '引':
+			motion = "引"
+		case '寄':
+			motion = "寄"
+		case '右', '左', '直':
+			pos = string(r)
+		default:
+			return board.Move{}, invalid
+		}
+	}
+
+	player := b.CurrentPlayer
+	candidates := sameDestination(b, t, toX, toY)
+	var moves []board.Move
+	hasBoardMove := false
+	for _, c := range candidates {
+		if !isDrop(c) {
+			hasBoardMove = true
+		}
+	}
+	for _, c := range candidates {
+		switch {
+		case drop != isDrop(c) && (drop || hasBoardMove):
+			// 「打」がなければ盤上の駒を優先する
+			continue
+		case motion != "" && (isDrop(c) || motionWord(player, c) != motion):
+			continue
+		}
+		moves = append(moves, c)
+	}
+
+	// 位置による区別
+	switch pos {
+	case "直":
+		var straight []board.Move
+		for _, c := range moves {
+			if forward, _ := forwardAndRight(player, c); c.FromX == c.ToX && forward > 0 {
+				straight = append(straight, c)
+			}
+		}
+		moves = straight
+	case "右", "左":
+		var picked []board.Move
+		for _, c := range moves {
+			var others []board.Move
+			for _, o := range moves {
+				if o != c {
+					others = append(others, o)
+				}
+			}
+			_, right := forwardAndRight(player, c)
+			if pos == "右" && isRightmost(player, right, others) ||
+				pos == "左" && isLeftmost(player, right, others) {
+				picked = append(picked, c)
+			}
+		}
+		moves = picked
+	}
+
+	m, err := single(moves, s)
+	if err != nil {
+		return board.Move{}, err
+	}
+	// 成らなければ指せない手を「成」なしで書いた場合も不正とする
+	m.Promote = promote
+	if !b.IsLegalMove(m) {
+		return board.Move{}, invalid
+	}
+	return m, nil
+}
+
+// 先頭の駒の名前を切り出す
+func cutPieceName(s string) (piece.Type, string, bool) {
+	runes := []rune(s)
+	for _, n := range []int{2, 1} {
+		if len(runes) < n {
+			continue
+		}
+		name := string(runes[:n])
+		for t, pn := range pieceNames {
+			if pn == name {
+				return t, string(runes[n:]), true
+			}
+		}
+		if t, ok := pieceAliases[name]; ok {
+			return t, string(runes[n:]), true
+		}
+	}
+	return piece.Empty, "", false
+}
+
+// 表の中の位置（なければ-1）
+func indexOf(table []string, s string) int {
+	for i, v := range table {
+		if i > 0 && v == s {
+			return i
+		}
+	}
+	return -1
+}
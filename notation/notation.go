@@ -0,0 +1,92 @@
+package notation
+
+import (
+	"errors"
+	"fmt"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+var (
+	ErrInvalidMove   = errors.New("notation: 不正な指し手です")
+	ErrAmbiguousMove = errors.New("notation: 指し手が特定できません")
+)
+
+// 指し手をUSI形式（例: 7g7f+、S*5e）に変換
+func FormatUSI(m board.Move) string {
+	return usi.FormatMove(m)
+}
+
+// USI形式の指し手を解析し、局面で指せる手か確かめる
+func ParseUSI(b *board.Board, s string) (board.Move, error) {
+	m, err := usi.ParseMove(s)
+	if err != nil || !b.IsLegalMove(m) {
+		return board.Move{}, fmt.Errorf("%w: %s", ErrInvalidMove, s)
+	}
+	return m, nil
+}
+
+// 駒打ちか
+func isDrop(m board.Move) bool {
+	return m.FromX == -1 && m.FromY == -1
+}
+
+// 動かす（打つ）駒の種類
+func movedType(b *board.Board, m board.Move) piece.Type {
+	if isDrop(m) {
+		return m.Piece
+	}
+	return b.GetPiece(m.FromX, m.FromY).Type
+}
+
+// 成らずに指した手が、成ることもできたか
+func couldPromote(b *board.Board, m board.Move) bool {
+	if isDrop(m) || m.Promote {
+		return false
+	}
+	m.Promote = true
+	return b.IsLegalMove(m)
+}
+
+// 同じ種類の駒を同じマスに動かす合法手（成・不成の区別はせず Promote は false）
+// 盤上の駒は移動元ごとに1つ、駒打ちは1つにまとめる
+func sameDestination(b *board.Board, t piece.Type, toX, toY int) []board.Move {
+	var moves []board.Move
+	for y := 0; y < board.BoardSize; y++ {
+		for x := 0; x < board.BoardSize; x++ {
+			p := b.Grid[y][x]
+			if p.Type != t || p.Player != b.CurrentPlayer {
+				continue
+			}
+			m := board.Move{FromX: x, FromY: y, ToX: toX, ToY: toY}
+			promoted := m
+			promoted.Promote = true
+			if b.IsLegalMove(m) || b.IsLegalMove(promoted) {
+				moves = append(moves, m)
+			}
+		}
+	}
+
+	hand := b.SenteCaptures
+	if b.CurrentPlayer == piece.Gote {
+		hand = b.GoteCaptures
+	}
+	drop := board.Move{FromX: -1, FromY: -1, ToX: toX, ToY: toY, Piece: t}
+	if hand[t] > 0 && b.IsLegalMove(drop) {
+		moves = append(moves, drop)
+	}
+	return moves
+}
+
+// 候補を1つに絞る
+func single(moves []board.Move, s string) (board.Move, error) {
+	switch len(moves) {
+	case 0:
+		return board.Move{}, fmt.Errorf("%w: %s", ErrInvalidMove, s)
+	case 1:
+		return moves[0], nil
+	}
+	return board.Move{}, fmt.Errorf("%w: %s", ErrAmbiguousMove, s)
+}
@@ -0,0 +1,141 @@
+package notation
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"shogi/board"
+	"shogi/usi"
+)
+
+// 局面を読み込む
+func mustParseSFEN(t *testing.T, sfen string) *board.Board {
+	t.Helper()
+	b, _, err := board.ParseSFEN(sfen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// USIの指し手を読み込む
+func mustParseUSI(t *testing.T, b *board.Board, s string) board.Move {
+	t.Helper()
+	m, err := ParseUSI(b, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		sfen     string
+		prev     string // 直前の指し手（USI、なければ空）
+		move     string // USI
+		japanese string
+		western  string
+	}{
+		{board.StartSFEN, "", "7g7f", "▲７六歩", "P-7f"},
+		{board.StartSFEN, "", "2h5h", "▲５八飛", "R-5h"},
+		{"lnsgkgsnl/1r5b1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL b - 3", "3c3d", "8h2b+", "▲２二角成", "Bx2b+"},
+		{"lnsgkgsnl/1r5+B1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/7R1/LNSGKGSNL w B 4", "8h2b+", "3a2b", "△同　銀", "Sx2b"},
+		{"lnsgkgsnl/1r5b1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL b - 3", "", "8h2b", "▲２二角不成", "Bx2b="},
+		// 左右
+		{"4k4/9/9/9/9/9/9/9/3GKG3 b - 1", "", "6i5h", "▲５八金左", "G6i-5h"},
+		{"4k4/9/9/9/9/9/9/9/3GKG3 b - 1", "", "4i5h", "▲５八金右", "G4i-5h"},
+		// 上・寄・引
+		{"4k4/9/9/9/9/9/9/6G2/4KG3 b - 1", "", "4i4h", "▲４八金上", "G4i-4h"},
+		{"4k4/9/9/9/9/9/9/6G2/4KG3 b - 1", "", "3h4h", "▲４八金寄", "G3h-4h"},
+		{"4k4/9/9/9/9/9/9/5G3/4K1G2 b - 1", "", "3i4i", "▲４九金寄", "G3i-4i"},
+		{"4k4/9/9/9/9/9/9/5G3/4K1G2 b - 1", "", "4h4i", "▲４九金引", "G4h-4i"},
+		// 直
+		{"4k4/9/9/9/9/9/9/9/K2GGG3 b - 1", "", "5i5h", "▲５八金直", "G5i-5h"},
+		{"4k4/9/9/9/9/9/9/9/K2GGG3 b - 1", "", "4i5h", "▲５八金右", "G4i-5h"},
+		// 打
+		{"4k4/9/9/9/9/9/9/9/4K1G2 b G 1", "", "G*3h", "▲３八金打", "G*3h"},
+		{"4k4/9/9/9/9/9/9/9/4K4 b G 1", "", "G*3h", "▲３八金", "G*3h"},
+		// 後手の左右
+		{"3gkg3/9/9/9/9/9/9/9/4K4 w - 1", "", "6a5b", "△５二金右", "G6a-5b"},
+		// 竜は「直」を使わない
+		{"k8/9/9/9/9/9/9/9/4+R+R2K b - 1", "", "5i5h", "▲５八龍左", "+R5i-5h"},
+		{"k8/9/9/9/9/9/9/9/4+R+R2K b - 1", "", "4i5h", "▲５八龍右", "+R4i-5h"},
+	}
+	for _, tt := range tests {
+		b := mustParseSFEN(t, tt.sfen)
+		var prev *board.Move
+		if tt.prev != "" {
+			// 直前の手は移動先しか使わないので局面と照合しない
+			p, err := usi.ParseMove(tt.prev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prev = &p
+		}
+		m := mustParseUSI(t, b, tt.move)
+		if got := FormatJapanese(b, m, prev); got != tt.japanese {
+			t.Errorf("FormatJapanese(%s) = %s, want %s", tt.move, got, tt.japanese)
+		}
+		if got := FormatWestern(b, m); got != tt.western {
+			t.Errorf("FormatWestern(%s) = %s, want %s", tt.move, got, tt.western)
+		}
+		if got, err := ParseJapanese(b, tt.japanese, prev); err != nil || got != m {
+			t.Errorf("ParseJapanese(%s) = %v, %v, want %v", tt.japanese, got, err, m)
+		}
+		if got, err := ParseWestern(b, tt.western); err != nil || got != m {
+			t.Errorf("ParseWestern(%s) = %v, %v, want %v", tt.western, got, err, m)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	b := mustParseSFEN(t, "4k4/9/9/9/9/9/9/9/3GKG3 b - 1")
+	if _, err := ParseJapanese(b, "５八金", nil); !errors.Is(err, ErrAmbiguousMove) {
+		t.Errorf("曖昧な手: %v", err)
+	}
+	if _, err := ParseWestern(b, "G-5h"); !errors.Is(err, ErrAmbiguousMove) {
+		t.Errorf("曖昧な手: %v", err)
+	}
+	if _, err := ParseJapanese(b, "５五金", nil); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("動けない手: %v", err)
+	}
+	if _, err := ParseJapanese(b, "同　金", nil); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("直前の手がない「同」: %v", err)
+	}
+	if _, err := ParseUSI(b, "5i5a"); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("USIの不正な手: %v", err)
+	}
+}
+
+// ランダムな対局のすべての局面で、すべての合法手が3つの表記で元に戻るか
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for game := 0; game < 20; game++ {
+		b := board.New()
+		var prev *board.Move
+		for ply := 0; ply < 150; ply++ {
+			legal := b.LegalMoves()
+			if len(legal) == 0 {
+				break
+			}
+			for _, m := range legal {
+				j := FormatJapanese(b, m, prev)
+				if got, err := ParseJapanese(b, j, prev); err != nil || got != m {
+					t.Fatalf("%s: ParseJapanese(%s) = %v, %v, want %v", b.SFEN(1), j, got, err, m)
+				}
+				w := FormatWestern(b, m)
+				if got, err := ParseWestern(b, w); err != nil || got != m {
+					t.Fatalf("%s: ParseWestern(%s) = %v, %v, want %v", b.SFEN(1), w, got, err, m)
+				}
+				u := FormatUSI(m)
+				if got, err := ParseUSI(b, u); err != nil || got != m {
+					t.Fatalf("%s: ParseUSI(%s) = %v, %v, want %v", b.SFEN(1), u, got, err, m)
+				}
+			}
+			m := legal[r.Intn(len(legal))]
+			b.MakeMove(m)
+			prev = &m
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package notation
+
+import (
+	"fmt"
+	"strings"
+
+	"shogi/board"
+	"shogi/piece"
+	"shogi/usi"
+)
+
+// 西洋式（Hodges式）の駒の文字（成駒は先頭に + を付ける）
+var westernLetters = map[piece.Type]string{
+	piece.Pawn:   "P",
+	piece.Lance:  "L",
+	piece.Knight: "N",
+	piece.Silver: "S",
+	piece.Gold:   "G",
+	piece.Bishop: "B",
+	piece.Rook:   "R",
+	piece.King:   "K",
+}
+
+// 西洋式の駒の表記
+func westernPiece(t piece.Type) string {
+	if t.IsPromoted() {
+		return "+" + westernLetters[t.Unpromote()]
+	}
+	return westernLetters[t]
+}
+
+// 指し手を西洋式（Hodges式、例: P-7f、Sx5e+、S*5e、G6i-5h）に変換
+// 同じ種類の駒が同じマスに動ける場合は移動元を付ける。成りは +、不成は = を付ける
+func FormatWestern(b *board.Board, m board.Move) string {
+	t := movedType(b, m)
+	if isDrop(m) {
+		return westernPiece(t) + "*" + usi.FormatSquare(m.ToX, m.ToY)
+	}
+
+	s := westernPiece(t)
+	for _, c := range sameDestination(b, t, m.ToX, m.ToY) {
+		if !isDrop(c) && (c.FromX != m.FromX || c.FromY != m.FromY) {
+			s += usi.FormatSquare(m.FromX, m.FromY)
+			break
+		}
+	}
+	if b.GetPiece(m.ToX, m.ToY).Type != piece.Empty {
+		s += "x"
+	} else {
+		s += "-"
+	}
+	s += usi.FormatSquare(m.ToX, m.ToY)
+
+	switch {
+	case m.Promote:
+		s += "+"
+	case couldPromote(b, m):
+		s += "="
+	}
+	return s
+}
+
+// 西洋式の指し手を解析（指す前の盤面を渡す）
+func ParseWestern(b *board.Board, s string) (board.Move, error) {
+	invalid := fmt.Errorf("%w: %s", ErrInvalidMove, s)
+	rest := strings.TrimSpace(s)
+
+	// 駒の種類
+	promoted := false
+	if r, ok := strings.CutPrefix(rest, "+"); ok {
+		rest, promoted = r, true
+	}
+	if rest == "" {
+		return board.Move{}, invalid
+	}
+	t := piece.Empty
+	for pt, l := range westernLetters {
+		if l == rest[:1] {
+			t = pt
+		}
+	}
+	if t == piece.Empty || promoted && !t.CanPromote() {
+		return board.Move{}, invalid
+	}
+	if promoted {
+		t = t.Promote()
+	}
+	rest = rest[1:]
+
+	// 成（+）・不成（=）
+	promote := false
+	if r, ok := strings.CutSuffix(rest, "+"); ok {
+		rest, promote = r, true
+	} else {
+		rest = strings.TrimSuffix(rest, "=")
+	}
+
+	// 移動元（省略可）・区切り・移動先
+	i := strings.IndexAny(rest, "-x*")
+	if i != 0 && i != 2 {
+		return board.Move{}, invalid
+	}
+	sep := rest[i]
+	toX, toY, ok := usi.ParseSquare(rest[i+1:])
+	if !ok {
+		return board.Move{}, invalid
+	}
+	fromX, fromY := -1, -1
+	if i == 2 {
+		if fromX, fromY, ok = usi.ParseSquare(rest[:2]); !ok || sep == '*' {
+			return board.Move{}, invalid
+		}
+	}
+	if sep == '*' && promote {
+		return board.Move{}, invalid
+	}
+	capture := b.GetPiece(toX, toY).Type != piece.Empty
+	if sep == 'x' && !capture || sep == '-' && capture {
+		return board.Move{}, invalid
+	}
+
+	var moves []board.Move
+	for _, c := range sameDestination(b, t, toX, toY) {
+		if isDrop(c) != (sep == '*') || i == 2 && (c.FromX != fromX || c.FromY != fromY) {
+			continue
+		}
+		moves = append(moves, c)
+	}
+	m, err := single(moves, s)
+	if err != nil {
+		return board.Move{}, err
+	}
+	m.Promote = promote
+	if !b.IsLegalMove(m) {
+		return board.Move{}, invalid
+	}
+	return m, nil
+}
@@ -0,0 +1,122 @@
+package piece
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+var ErrTooManyTypes = errors.New("piece: 独自の駒の種類が多すぎます")
+
+// 駒の種類の番号の上限（指し手を32ビットに詰めるときに8ビットで表す）
+const MaxType Type = 0xff
+
+// 独自に定義する駒（将棋の種類の定義ファイルから読み込む）
+type Definition struct {
+	Name      string      // 駒の文字
+	Movements []Direction // 先手から見た動き
+	Promoted  *Definition // 成った後の駒（成れなければnil）
+}
+
+// 登録した独自の駒
+type customPiece struct {
+	name       string
+	movements  []Direction
+	promoted   Type // 成った後の駒（成れなければ Empty）
+	unpromoted Type // 成る前の駒（成駒でなければ Empty）
+}
+
+// 独自の駒の情報（添字は種類の番号 - Elephant - 1）
+// 対局中の探索などと並行して登録されることがあるのでロックする
+var (
+	customMu sync.RWMutex
+	customs  []customPiece
+)
+
+// 独自の駒を登録して駒の種類を返す（成った後の駒もあわせて登録する）
+// 同じ定義の駒がすでにあればその種類を返すので、同じ定義ファイルを何度読み込んでも種類は増えない
+// 種類の番号が MaxType を超えるなら何も登録せずに ErrTooManyTypes を返す
+func Register(defs ...Definition) ([]Type, error) {
+	customMu.Lock()
+	defer customMu.Unlock()
+
+	// 新しく必要な番号の数を先に数える
+	next := Elephant + 1 + Type(len(customs))
+	var added []Definition
+	for _, d := range defs {
+		if findCustomLocked(d) == Empty && !slices.ContainsFunc(added, d.equal) {
+			added = append(added, d)
+			next++
+			if d.Promoted != nil {
+				next++
+			}
+		}
+	}
+	if next-1 > MaxType {
+		return nil, ErrTooManyTypes
+	}
+
+	types := make([]Type, len(defs))
+	for i, d := range defs {
+		if t := findCustomLocked(d); t != Empty {
+			types[i] = t
+			continue
+		}
+		t := Elephant + 1 + Type(len(customs))
+		customs = append(customs, customPiece{name: d.Name, movements: d.Movements})
+		if d.Promoted != nil {
+			pt := t + 1
+			customs[len(customs)-1].promoted = pt
+			customs = append(customs, customPiece{name: d.Promoted.Name, movements: d.Promoted.Movements, unpromoted: t})
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+// 同じ定義の成る前の駒を探す（なければ Empty、ロックを保持して呼ぶこと）
+func findCustomLocked(d Definition) Type {
+	for i, c := range customs {
+		if c.unpromoted != Empty || c.name != d.Name || !slices.Equal(c.movements, d.Movements) {
+			continue
+		}
+		t := Elephant + 1 + Type(i)
+		switch {
+		case d.Promoted == nil && c.promoted == Empty:
+			return t
+		case d.Promoted != nil && c.promoted != Empty:
+			p := customs[c.promoted-Elephant-1]
+			if p.name == d.Promoted.Name && slices.Equal(p.movements, d.Promoted.Movements) {
+				return t
+			}
+		}
+	}
+	return Empty
+}
+
+// 定義が同じか
+func (d Definition) equal(o Definition) bool {
+	if d.Name != o.Name || !slices.Equal(d.Movements, o.Movements) || (d.Promoted == nil) != (o.Promoted == nil) {
+		return false
+	}
+	return d.Promoted == nil || d.Promoted.equal(*o.Promoted)
+}
+
+// 登録した独自の駒の情報
+func custom(t Type) (customPiece, bool) {
+	if !t.IsCustom() {
+		return customPiece{}, false
+	}
+	customMu.RLock()
+	defer customMu.RUnlock()
+	i := int(t - Elephant - 1)
+	if i >= len(customs) {
+		return customPiece{}, false
+	}
+	return customs[i], true
+}
+
+// 独自に定義した駒か
+func (t Type) IsCustom() bool {
+	return t > Elephant
+}
@@ -19,6 +19,8 @@ const (
 	PromSilver      // 成銀
 	PromBishop      // 馬
 	PromRook        // 龍
+	Giraffe         // キリン（どうぶつしょうぎ）
+	Elephant        // ゾウ（どうぶつしょうぎ）
 )
 
 // プレイヤー
@@ -100,6 +102,18 @@ var movements = map[Type][]Direction{
 		{0, 1, false, false},
 		{1, 1, false, false},
 	},
+	Giraffe: { // 縦横に1マス
+		{0, -1, false, false},
+		{-1, 0, false, false},
+		{1, 0, false, false},
+		{0, 1, false, false},
+	},
+	Elephant: { // 斜めに1マス
+		{-1, -1, false, false},
+		{1, -1, false, false},
+		{-1, 1, false, false},
+		{1, 1, false, false},
+	},
 }
 
 // 成駒の移動方向
@@ -130,15 +144,73 @@ func (t Type) CanPromote() bool {
 	case Pawn, Lance, Knight, Silver, Bishop, Rook:
 		return true
 	default:
-		return false
+		c, ok := custom(t)
+		return ok && c.promoted != Empty
 	}
 }
 
+// 成った後の駒の種類を取得（成れない駒はそのまま）
+func (t Type) Promote() Type {
+	switch t {
+	case Pawn:
+		return PromPawn
+	case Lance:
+		return PromLance
+	case Knight:
+		return PromKnight
+	case Silver:
+		return PromSilver
+	case Bishop:
+		return PromBishop
+	case Rook:
+		return PromRook
+	default:
+		if c, ok := custom(t); ok && c.promoted != Empty {
+			return c.promoted
+		}
+		return t
+	}
+}
+
+// 成る前の駒の種類を取得（成駒でなければそのまま）
+func (t Type) Unpromote() Type {
+	switch t {
+	case PromPawn:
+		return Pawn
+	case PromLance:
+		return Lance
+	case PromKnight:
+		return Knight
+	case PromSilver:
+		return Silver
+	case PromBishop:
+		return Bishop
+	case PromRook:
+		return Rook
+	default:
+		if c, ok := custom(t); ok && c.unpromoted != Empty {
+			return c.unpromoted
+		}
+		return t
+	}
+}
+
+// 成駒かどうかを判定
+func (t Type) IsPromoted() bool {
+	if t >= PromPawn && t <= PromRook {
+		return true
+	}
+	c, ok := custom(t)
+	return ok && c.unpromoted != Empty
+}
+
 // 駒の移動可能な方向を取得（後手の場合は方向を反転）
 func (p Piece) GetMovements() []Direction {
 	// 成り駒の場合は専用の動きを使用
 	var dirs []Direction
-	if p.Type >= PromPawn && p.Type <= PromRook {
+	if c, ok := custom(p.Type); ok {
+		dirs = c.movements
+	} else if p.Type.IsPromoted() {
 		dirs = promotedMovements[p.Type]
 	} else {
 		dirs = movements[p.Type]
@@ -149,11 +221,11 @@ func (p Piece) GetMovements() []Direction {
 	}
 
 	if p.Player == Gote {
-		// 後手の場合は方向を反転
+		// 後手の場合は方向を反転（左右が非対称な駒もあるので180度回す）
 		reversed := make([]Direction, len(dirs))
 		for i, dir := range dirs {
 			reversed[i] = Direction{
-				DX:       dir.DX,
+				DX:       -dir.DX,
 				DY:       -dir.DY,
 				Repeat:   dir.Repeat,
 				PromOnly: dir.PromOnly,
@@ -200,7 +272,14 @@ func (p Piece) String() string {
 		return "馬"
 	case PromRook:
 		return "龍"
+	case Giraffe:
+		return "麒"
+	case Elephant:
+		return "象"
 	default:
+		if c, ok := custom(p.Type); ok {
+			return c.name
+		}
 		return "？"
 	}
 }
@@ -0,0 +1,261 @@
+package record
+
+import (
+	"errors"
+	"time"
+
+	"shogi/board"
+)
+
+var (
+	ErrIllegalMove = errors.New("record: 指せない手です")
+	ErrRootNode    = errors.New("record: 開始局面は削除できません")
+	ErrNotInTree   = errors.New("record: 木に含まれない節です")
+)
+
+// 変化（分岐）を含む棋譜の木
+type Tree struct {
+	Initial *board.Board // 開始局面
+	Root    *Node        // 開始局面を表す根（Move は使わない）
+	Current *Node        // 現在の位置
+}
+
+// 木の節（1手）
+type Node struct {
+	Move     board.Move
+	Comment  string
+	Time     time.Duration // 消費時間
+	Parent   *Node
+	Children []*Node // 先頭が本譜、2番目以降が変化
+}
+
+// 開始局面だけの木を作る
+func NewTree(initial *board.Board) *Tree {
+	root := &Node{}
+	return &Tree{Initial: initial.Clone(), Root: root, Current: root}
+}
+
+// 変化のない棋譜から木を作る（times は空でもよい）
+func FromMoves(initial *board.Board, moves []board.Move, times []time.Duration) *Tree {
+	t := NewTree(initial)
+	n := t.Root
+	for i, m := range moves {
+		n = n.AddChild(m)
+		if i < len(times) {
+			n.Time = times[i]
+		}
+	}
+	return t
+}
+
+// 開始局面か
+func (n *Node) IsRoot() bool {
+	return n.Parent == nil
+}
+
+// 手数（根は0）
+func (n *Node) Ply() int {
+	ply := 0
+	for ; n.Parent != nil; n = n.Parent {
+		ply++
+	}
+	return ply
+}
+
+// 根からこの節までの指し手
+func (n *Node) Moves() []board.Move {
+	moves := make([]board.Move, n.Ply())
+	for i := len(moves) - 1; n.Parent != nil; i, n = i-1, n.Parent {
+		moves[i] = n.Move
+	}
+	return moves
+}
+
+// 指し手 m の子（なければnil）
+func (n *Node) Child(m board.Move) *Node {
+	for _, c := range n.Children {
+		if c.Move == m {
+			return c
+		}
+	}
+	return nil
+}
+
+// 指し手 m の子を追加する。同じ手の子があればそれを返す
+// 最初の子は本譜、以降は変化になる。合法かどうかは調べない
+func (n *Node) AddChild(m board.Move) *Node {
+	if c := n.Child(m); c != nil {
+		return c
+	}
+	c := &Node{Move: m, Parent: n}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// 兄弟の中での順番（0なら本譜、根は0）
+func (n *Node) Index() int {
+	if n.Parent == nil {
+		return 0
+	}
+	for i, c := range n.Parent.Children {
+		if c == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// 根から本譜だけをたどって来られる節か
+func (n *Node) IsMainLine() bool {
+	for ; n.Parent != nil; n = n.Parent {
+		if n.Index() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// この節から本譜をたどった節の並び（この節は含まない）
+func (n *Node) MainLine() []*Node {
+	var line []*Node
+	for len(n.Children) > 0 {
+		n = n.Children[0]
+		line = append(line, n)
+	}
+	return line
+}
+
+// 節の局面
+func (t *Tree) Board(n *Node) *board.Board {
+	b := t.Initial.Clone()
+	for _, m := range n.Moves() {
+		b.MakeMove(m)
+	}
+	return b
+}
+
+// 本譜の指し手
+func (t *Tree) MainLineMoves() []board.Move {
+	var moves []board.Move
+	for _, n := range t.Root.MainLine() {
+		moves = append(moves, n.Move)
+	}
+	return moves
+}
+
+// 現在の位置で手を指し、その手に移る
+// 既にある手ならその節に移り、新しい手なら変化として加える
+func (t *Tree) Add(m board.Move) (*Node, error) {
+	if t.Current.Child(m) == nil && !t.Board(t.Current).IsLegalMove(m) {
+		return nil, ErrIllegalMove
+	}
+	t.Current = t.Current.AddChild(m)
+	return t.Current, nil
+}
+
+// 節が木に含まれるか
+func (t *Tree) contains(n *Node) bool {
+	for ; n.Parent != nil; n = n.Parent {
+	}
+	return n == t.Root
+}
+
+// 節に移る
+func (t *Tree) GoTo(n *Node) error {
+	if !t.contains(n) {
+		return ErrNotInTree
+	}
+	t.Current = n
+	return nil
+}
+
+// 本譜（最初の子）に1手進む
+func (t *Tree) Forward() bool {
+	if len(t.Current.Children) == 0 {
+		return false
+	}
+	t.Current = t.Current.Children[0]
+	return true
+}
+
+// 1手戻る
+func (t *Tree) Back() bool {
+	if t.Current.Parent == nil {
+		return false
+	}
+	t.Current = t.Current.Parent
+	return true
+}
+
+// 同じ手数の次の変化に移る
+func (t *Tree) NextVariation() bool {
+	return t.switchVariation(1)
+}
+
+// 同じ手数の前の変化に移る
+func (t *Tree) PrevVariation() bool {
+	return t.switchVariation(-1)
+}
+
+func (t *Tree) switchVariation(delta int) bool {
+	n := t.Current
+	if n.Parent == nil {
+		return false
+	}
+	i := n.Index() + delta
+	if i < 0 || i >= len(n.Parent.Children) {
+		return false
+	}
+	t.Current = n.Parent.Children[i]
+	return true
+}
+
+// 変化を1つ前（本譜側）に上げる
+func (t *Tree) Promote(n *Node) error {
+	if !t.contains(n) {
+		return ErrNotInTree
+	}
+	if i := n.Index(); i > 0 {
+		siblings := n.Parent.Children
+		siblings[i-1], siblings[i] = siblings[i], siblings[i-1]
+	}
+	return nil
+}
+
+// 節までの手順を本譜にする
+func (t *Tree) PromoteToMainLine(n *Node) error {
+	if !t.contains(n) {
+		return ErrNotInTree
+	}
+	for ; n.Parent != nil; n = n.Parent {
+		i := n.Index()
+		siblings := n.Parent.Children
+		copy(siblings[1:i+1], siblings[:i])
+		siblings[0] = n
+	}
+	return nil
+}
+
+// 節とそれ以降の手を削除する。現在の位置が削除された場合は親に移る
+func (t *Tree) Delete(n *Node) error {
+	if !t.contains(n) {
+		return ErrNotInTree
+	}
+	if n.Parent == nil {
+		return ErrRootNode
+	}
+
+	// 現在の位置が削除される手順の中なら親に移る
+	for c := t.Current; c != nil; c = c.Parent {
+		if c == n {
+			t.Current = n.Parent
+			break
+		}
+	}
+
+	siblings := n.Parent.Children
+	i := n.Index()
+	n.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+	n.Parent = nil
+	return nil
+}
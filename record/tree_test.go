@@ -0,0 +1,194 @@
+package record
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"shogi/board"
+)
+
+// 平手の初期局面から指せる手
+var (
+	p76 = board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5} // ７六歩
+	p26 = board.Move{FromX: 7, FromY: 6, ToX: 7, ToY: 5} // ２六歩
+	p56 = board.Move{FromX: 4, FromY: 6, ToX: 4, ToY: 5} // ５六歩
+	p34 = board.Move{FromX: 6, FromY: 2, ToX: 6, ToY: 3} // ３四歩
+	p84 = board.Move{FromX: 1, FromY: 2, ToX: 1, ToY: 3} // ８四歩
+)
+
+// 木に手順を加える（現在の位置は最後の手）
+func addMoves(t *testing.T, tree *Tree, from *Node, moves ...board.Move) *Node {
+	t.Helper()
+	if err := tree.GoTo(from); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		if _, err := tree.Add(m); err != nil {
+			t.Fatalf("Add(%+v): %v", m, err)
+		}
+	}
+	return tree.Current
+}
+
+// 節の子の指し手
+func childMoves(n *Node) []board.Move {
+	var moves []board.Move
+	for _, c := range n.Children {
+		moves = append(moves, c.Move)
+	}
+	return moves
+}
+
+func TestTreeAdd(t *testing.T) {
+	tree := NewTree(board.New())
+	main := addMoves(t, tree, tree.Root, p76, p34)
+	variation := addMoves(t, tree, main.Parent, p84)
+
+	if main.Ply() != 2 || !slices.Equal(main.Moves(), []board.Move{p76, p34}) || !main.IsMainLine() {
+		t.Errorf("本譜の節: %d手 %+v 本譜 %v", main.Ply(), main.Moves(), main.IsMainLine())
+	}
+	if variation.Index() != 1 || variation.IsMainLine() {
+		t.Errorf("変化の節: 順番 %d 本譜 %v, want 1 false", variation.Index(), variation.IsMainLine())
+	}
+	if got := tree.MainLineMoves(); !slices.Equal(got, []board.Move{p76, p34}) {
+		t.Errorf("MainLineMoves() = %+v", got)
+	}
+
+	// 既にある手はその節に移るだけ
+	if n := addMoves(t, tree, main.Parent, p34); n != main || len(main.Parent.Children) != 2 {
+		t.Errorf("同じ手を加えると節が増えます")
+	}
+
+	// 指せない手は加えず、位置も変わらない
+	if _, err := tree.Add(p76); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("Add(後手番で７六歩) = %v, want ErrIllegalMove", err)
+	}
+	if tree.Current != main {
+		t.Errorf("指せない手で現在の位置が変わりました")
+	}
+	if got := tree.Board(main).SFEN(1); got != "lnsgkgsnl/1r5b1/pppppp1pp/6p2/9/2P6/PP1PPPPPP/1B5R1/LNSGKGSNL b - 1" {
+		t.Errorf("Board() = %s", got)
+	}
+}
+
+func TestTreeNavigation(t *testing.T) {
+	tree := NewTree(board.New())
+	first := addMoves(t, tree, tree.Root, p76, p34)
+	second := addMoves(t, tree, first.Parent, p84)
+
+	if err := tree.GoTo(tree.Root); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Back() || tree.NextVariation() || tree.PrevVariation() {
+		t.Error("開始局面から戻ったり変化に移ったりできます")
+	}
+	if !tree.Forward() || !tree.Forward() || tree.Current != first {
+		t.Fatal("本譜を進めません")
+	}
+	if tree.Forward() {
+		t.Error("最後の手から進めます")
+	}
+	if tree.PrevVariation() {
+		t.Error("本譜から前の変化に移れます")
+	}
+	if !tree.NextVariation() || tree.Current != second {
+		t.Error("次の変化に移れません")
+	}
+	if tree.NextVariation() {
+		t.Error("最後の変化から次の変化に移れます")
+	}
+	if !tree.PrevVariation() || tree.Current != first {
+		t.Error("前の変化に戻れません")
+	}
+	if !tree.Back() || tree.Current != first.Parent {
+		t.Error("1手戻れません")
+	}
+
+	if err := tree.GoTo(&Node{}); !errors.Is(err, ErrNotInTree) {
+		t.Errorf("GoTo(木にない節) = %v, want ErrNotInTree", err)
+	}
+}
+
+func TestTreePromote(t *testing.T) {
+	tree := NewTree(board.New())
+	a := addMoves(t, tree, tree.Root, p76)
+	addMoves(t, tree, tree.Root, p26)
+	c := addMoves(t, tree, tree.Root, p56)
+
+	if err := tree.Promote(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := childMoves(tree.Root); !slices.Equal(got, []board.Move{p76, p56, p26}) {
+		t.Errorf("Promote 後の順番 = %+v", got)
+	}
+	// 本譜はそれ以上上がらない
+	if err := tree.Promote(a); err != nil || tree.Root.Children[0] != a {
+		t.Errorf("本譜の Promote: %v", err)
+	}
+	if err := tree.Promote(&Node{Parent: &Node{}}); !errors.Is(err, ErrNotInTree) {
+		t.Errorf("Promote(木にない節) = %v, want ErrNotInTree", err)
+	}
+}
+
+func TestTreePromoteToMainLine(t *testing.T) {
+	tree := NewTree(board.New())
+	addMoves(t, tree, tree.Root, p76, p34)
+	addMoves(t, tree, tree.Root.Children[0], p84)
+	addMoves(t, tree, tree.Root, p26)
+	deep := addMoves(t, tree, tree.Root, p56, p84)
+
+	if err := tree.PromoteToMainLine(deep); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.MainLineMoves(); !slices.Equal(got, []board.Move{p56, p84}) {
+		t.Errorf("MainLineMoves() = %+v", got)
+	}
+	// 残りの変化の順番は変わらない
+	if got := childMoves(tree.Root); !slices.Equal(got, []board.Move{p56, p76, p26}) {
+		t.Errorf("開始局面の子 = %+v", got)
+	}
+	if got := childMoves(tree.Root.Children[1]); !slices.Equal(got, []board.Move{p34, p84}) {
+		t.Errorf("７六歩の子 = %+v", got)
+	}
+	if !deep.IsMainLine() {
+		t.Error("本譜になっていません")
+	}
+}
+
+func TestTreeDelete(t *testing.T) {
+	tree := NewTree(board.New())
+	main := addMoves(t, tree, tree.Root, p76, p34)
+	variation := addMoves(t, tree, main.Parent, p84)
+
+	if err := tree.Delete(tree.Root); !errors.Is(err, ErrRootNode) {
+		t.Errorf("Delete(開始局面) = %v, want ErrRootNode", err)
+	}
+
+	// 現在の位置と関係ない手を削除しても位置は変わらない
+	if err := tree.Delete(main); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Current != variation || !slices.Equal(childMoves(variation.Parent), []board.Move{p84}) {
+		t.Errorf("Delete(本譜) 後: 現在 %+v 子 %+v", tree.Current.Move, childMoves(variation.Parent))
+	}
+	// 残った変化が本譜になる
+	if !variation.IsMainLine() {
+		t.Error("残った変化が本譜になっていません")
+	}
+	if err := tree.GoTo(main); !errors.Is(err, ErrNotInTree) {
+		t.Errorf("削除した節に移れます: %v", err)
+	}
+
+	// 現在の位置を含む手順を削除すると親に移る
+	first := variation.Parent
+	if err := tree.Delete(first); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Current != tree.Root || len(tree.Root.Children) != 0 {
+		t.Errorf("Delete(現在の位置を含む手順) 後: 現在の手数 %d 子 %d", tree.Current.Ply(), len(tree.Root.Children))
+	}
+	if err := tree.Delete(first); !errors.Is(err, ErrNotInTree) {
+		t.Errorf("2回目の Delete = %v, want ErrNotInTree", err)
+	}
+}
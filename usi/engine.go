@@ -0,0 +1,308 @@
+package usi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shogi/board"
+)
+
+var (
+	ErrEngineExited = errors.New("usi: エンジンが終了しました")
+	ErrNoBestMove   = errors.New("usi: bestmove が返りませんでした")
+)
+
+// stop を送ってから bestmove を待つ時間
+var StopTimeout = 5 * time.Second
+
+// go コマンドの持ち時間（先手・後手の残り時間、秒読み、加算）
+type Limits struct {
+	SenteTime time.Duration
+	GoteTime  time.Duration
+	Byoyomi   time.Duration
+	SenteInc  time.Duration
+	GoteInc   time.Duration
+}
+
+// go コマンドの引数
+func (l Limits) args() string {
+	ms := func(d time.Duration) int64 { return d.Milliseconds() }
+	s := fmt.Sprintf("btime %d wtime %d", ms(l.SenteTime), ms(l.GoteTime))
+	if l.SenteInc > 0 || l.GoteInc > 0 {
+		s += fmt.Sprintf(" binc %d winc %d", ms(l.SenteInc), ms(l.GoteInc))
+	} else {
+		s += fmt.Sprintf(" byoyomi %d", ms(l.Byoyomi))
+	}
+	return s
+}
+
+// 探索の結果
+type Result struct {
+	BestMove   string // USI形式の指し手、または resign / win
+	Ponder     string
+	Score      int  // 最後に届いた評価値（手番側から見たセンチポーン）
+	HasScore   bool // 評価値が届いたか
+	Mate       bool // 評価値が詰みを表すか
+	PV         []string
+	Depth      int
+	Nodes      int64
+	Elapsed    time.Duration
+	InfoString string
+}
+
+// USIエンジンのプロセス
+type Engine struct {
+	Name    string // id name
+	Author  string // id author
+	Options map[string]string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string // 標準出力の各行（終了すると閉じられる）
+	mu    sync.Mutex
+}
+
+// エンジンを起動して usi / usiok のやり取りを行う
+func Start(ctx context.Context, path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		Options: make(map[string]string),
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 64),
+	}
+	go func() {
+		defer close(e.lines)
+		s := bufio.NewScanner(stdout)
+		for s.Scan() {
+			e.lines <- strings.TrimRight(s.Text(), "\r")
+		}
+	}()
+
+	if err := e.send("usi"); err != nil {
+		e.Kill()
+		return nil, err
+	}
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			e.Kill()
+			return nil, err
+		}
+		switch {
+		case line == "usiok":
+			return e, nil
+		case strings.HasPrefix(line, "id name "):
+			e.Name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "id author "):
+			e.Author = strings.TrimPrefix(line, "id author ")
+		case strings.HasPrefix(line, "option name "):
+			name, def := parseOption(line)
+			e.Options[name] = def
+		}
+	}
+}
+
+// option 行から名前と既定値を取り出す
+func parseOption(line string) (string, string) {
+	fields := strings.Fields(line)
+	name, def := "", ""
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "name":
+			name = fields[i+1]
+		case "default":
+			def = fields[i+1]
+		}
+	}
+	return name, def
+}
+
+// オプションを設定
+func (e *Engine) SetOption(name, value string) error {
+	e.Options[name] = value
+	return e.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// isready を送って readyok を待つ
+func (e *Engine) IsReady(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	for {
+		line, err := e.readLine(ctx)
+		if err != nil {
+			return err
+		}
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// 新しい対局の開始を通知
+func (e *Engine) NewGame() error {
+	return e.send("usinewgame")
+}
+
+// 対局の終了を通知（result は win / lose / draw）
+func (e *Engine) GameOver(result string) error {
+	return e.send("gameover " + result)
+}
+
+// 局面を送って探索させ、bestmove を待つ
+// ctx が終わると stop を送り、StopTimeout まで bestmove を待つ
+func (e *Engine) Go(ctx context.Context, start *board.Board, moves []board.Move, limits Limits) (*Result, error) {
+	if err := e.send("position " + FormatPosition(start, moves)); err != nil {
+		return nil, err
+	}
+	if err := e.send("go " + limits.args()); err != nil {
+		return nil, err
+	}
+
+	begin := time.Now()
+	res := &Result{}
+	readCtx := ctx
+	stopped := false
+	for {
+		line, err := e.readLine(readCtx)
+		if err != nil {
+			if stopped || ctx.Err() == nil {
+				return nil, err
+			}
+			// 探索を止めて bestmove を待つ
+			if err := e.send("stop"); err != nil {
+				return nil, err
+			}
+			stopped = true
+			var cancel context.CancelFunc
+			readCtx, cancel = context.WithTimeout(context.Background(), StopTimeout)
+			defer cancel()
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "info":
+			parseInfo(fields[1:], res)
+		case "bestmove":
+			if len(fields) < 2 {
+				return nil, ErrNoBestMove
+			}
+			res.BestMove = fields[1]
+			if len(fields) >= 4 && fields[2] == "ponder" {
+				res.Ponder = fields[3]
+			}
+			res.Elapsed = time.Since(begin)
+			return res, nil
+		}
+	}
+}
+
+// info 行の内容を結果に反映
+func parseInfo(fields []string, res *Result) {
+	for i := 0; i < len(fields); i++ {
+		next := func() string {
+			if i+1 < len(fields) {
+				i++
+				return fields[i]
+			}
+			return ""
+		}
+		switch fields[i] {
+		case "depth":
+			res.Depth, _ = strconv.Atoi(next())
+		case "nodes":
+			res.Nodes, _ = strconv.ParseInt(next(), 10, 64)
+		case "score":
+			kind := next()
+			value := next()
+			switch kind {
+			case "cp":
+				if n, err := strconv.Atoi(value); err == nil {
+					res.Score, res.HasScore, res.Mate = n, true, false
+				}
+			case "mate":
+				res.HasScore, res.Mate = true, true
+				res.Score = MateScore
+				if strings.HasPrefix(value, "-") {
+					res.Score = -MateScore
+				}
+			}
+		case "pv":
+			res.PV = append([]string(nil), fields[i+1:]...)
+			return
+		case "string":
+			res.InfoString = strings.Join(fields[i+1:], " ")
+			return
+		}
+	}
+}
+
+// 詰みを表す評価値
+const MateScore = 100000
+
+// エンジンを終了させる
+func (e *Engine) Quit() error {
+	e.send("quit")
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(StopTimeout):
+		e.Kill()
+		return <-done
+	}
+}
+
+// エンジンのプロセスを強制終了
+func (e *Engine) Kill() {
+	if e.cmd.Process != nil {
+		e.cmd.Process.Kill()
+	}
+}
+
+// 1行送信
+func (e *Engine) send(line string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := io.WriteString(e.stdin, line+"\n")
+	return err
+}
+
+// 1行受信
+func (e *Engine) readLine(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-e.lines:
+		if !ok {
+			return "", ErrEngineExited
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
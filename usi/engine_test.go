@@ -0,0 +1,127 @@
+package usi
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"shogi/board"
+)
+
+// 決まった行を出力するエンジン（送ったコマンドは捨てる）
+func scriptedEngine(lines ...string) *Engine {
+	e := &Engine{Options: make(map[string]string), stdin: nopWriteCloser{}, lines: make(chan string, len(lines))}
+	for _, line := range lines {
+		e.lines <- line
+	}
+	close(e.lines)
+	return e
+}
+
+type nopWriteCloser struct{}
+
+func (nopWriteCloser) Write(p []byte) (int, error) { return len(p), nil }
+func (nopWriteCloser) Close() error                { return nil }
+
+func TestGoBestMove(t *testing.T) {
+	tests := []struct {
+		lines  []string
+		best   string
+		ponder string
+		score  int
+	}{
+		{[]string{"info depth 1 score cp 30 pv 7g7f", "bestmove 7g7f"}, "7g7f", "", 30},
+		{[]string{"", "info score cp -15", "bestmove 2g2f ponder 8c8d"}, "2g2f", "8c8d", -15},
+		{[]string{"info score mate -1", "bestmove resign"}, "resign", "", -MateScore},
+		{[]string{"bestmove win"}, "win", "", 0},
+	}
+	for _, tt := range tests {
+		res, err := scriptedEngine(tt.lines...).Go(context.Background(), board.New(), nil, Limits{})
+		if err != nil {
+			t.Errorf("%q: %v", tt.lines, err)
+			continue
+		}
+		if res.BestMove != tt.best || res.Ponder != tt.ponder || res.Score != tt.score {
+			t.Errorf("%q: bestmove %q ponder %q score %d, want %q %q %d",
+				tt.lines, res.BestMove, res.Ponder, res.Score, tt.best, tt.ponder, tt.score)
+		}
+	}
+
+	if _, err := scriptedEngine("bestmove").Go(context.Background(), board.New(), nil, Limits{}); !errors.Is(err, ErrNoBestMove) {
+		t.Errorf("空の bestmove: err = %v, want ErrNoBestMove", err)
+	}
+	if _, err := scriptedEngine("info depth 1").Go(context.Background(), board.New(), nil, Limits{}); !errors.Is(err, ErrEngineExited) {
+		t.Errorf("bestmove の前に終了: err = %v, want ErrEngineExited", err)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  Result
+	}{
+		{
+			[]string{"info depth 12 seldepth 20 nodes 123456 score cp 85 pv 7g7f 3c3d 2g2f"},
+			Result{Depth: 12, Nodes: 123456, Score: 85, HasScore: true, PV: []string{"7g7f", "3c3d", "2g2f"}},
+		},
+		{
+			[]string{"info score cp -40 lowerbound depth 3"},
+			Result{Depth: 3, Score: -40, HasScore: true},
+		},
+		{
+			[]string{"info depth 5 score mate 7 pv 2b3c+"},
+			Result{Depth: 5, Score: MateScore, HasScore: true, Mate: true, PV: []string{"2b3c+"}},
+		},
+		{
+			[]string{"info score mate -3"},
+			Result{Score: -MateScore, HasScore: true, Mate: true},
+		},
+		// 詰みの後に評価値が届けば上書きする
+		{
+			[]string{"info score mate +5", "info depth 9 score cp 300"},
+			Result{Depth: 9, Score: 300, HasScore: true},
+		},
+		// 読めない評価値は無視する
+		{
+			[]string{"info score cp 120", "info score cp abc"},
+			Result{Score: 120, HasScore: true},
+		},
+		{
+			[]string{"info string book move 7g7f"},
+			Result{InfoString: "book move 7g7f"},
+		},
+		{
+			[]string{"info depth"},
+			Result{},
+		},
+	}
+	for _, tt := range tests {
+		var got Result
+		for _, line := range tt.lines {
+			parseInfo(strings.Fields(line)[1:], &got)
+		}
+		if got.Depth != tt.want.Depth || got.Nodes != tt.want.Nodes || got.Score != tt.want.Score ||
+			got.HasScore != tt.want.HasScore || got.Mate != tt.want.Mate ||
+			!slices.Equal(got.PV, tt.want.PV) || got.InfoString != tt.want.InfoString {
+			t.Errorf("%q: %+v, want %+v", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestParseOption(t *testing.T) {
+	tests := []struct {
+		line               string
+		name, defaultValue string
+	}{
+		{"option name USI_Hash type spin default 256 min 1 max 1024", "USI_Hash", "256"},
+		{"option name USI_Ponder type check default false", "USI_Ponder", "false"},
+		{"option name BookFile type filename", "BookFile", ""},
+	}
+	for _, tt := range tests {
+		if name, def := parseOption(tt.line); name != tt.name || def != tt.defaultValue {
+			t.Errorf("parseOption(%q) = %q, %q, want %q, %q", tt.line, name, def, tt.name, tt.defaultValue)
+		}
+	}
+}
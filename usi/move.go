@@ -0,0 +1,172 @@
+package usi
+
+import (
+	"errors"
+	"strings"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+var ErrInvalidMove = errors.New("usi: 不正な指し手です")
+
+// 駒打ちで使う駒の文字
+var dropLetters = map[piece.Type]byte{
+	piece.Pawn:   'P',
+	piece.Lance:  'L',
+	piece.Knight: 'N',
+	piece.Silver: 'S',
+	piece.Gold:   'G',
+	piece.Bishop: 'B',
+	piece.Rook:   'R',
+}
+
+// 盤の座標をUSIのマス表記（例: 7g）に変換
+func FormatSquare(x, y int) string {
+	return board.NewSquare(x, y).USI()
+}
+
+// USIのマス表記を盤の座標に変換
+func ParseSquare(s string) (int, int, bool) {
+	sq, err := board.ParseSquare(s)
+	if err != nil {
+		return -1, -1, false
+	}
+	return sq.X(), sq.Y(), true
+}
+
+// 指し手をUSI形式（例: 7g7f、8h2b+、P*5e）に変換（本将棋の盤の筋で表す）
+func FormatMove(m board.Move) string {
+	return formatMove(m, FormatSquare)
+}
+
+// 盤面 b の幅に合わせて、指し手をUSI形式に変換
+func FormatMoveOn(b *board.Board, m board.Move) string {
+	return formatMove(m, func(x, y int) string { return b.SquareUSI(board.NewSquare(x, y)) })
+}
+
+func formatMove(m board.Move, square func(x, y int) string) string {
+	if m.FromX == -1 && m.FromY == -1 {
+		return string(dropLetters[m.Piece]) + "*" + square(m.ToX, m.ToY)
+	}
+	s := square(m.FromX, m.FromY) + square(m.ToX, m.ToY)
+	if m.Promote {
+		s += "+"
+	}
+	return s
+}
+
+// USI形式の指し手を盤面の指し手に変換（本将棋の盤の筋として読む）
+func ParseMove(s string) (board.Move, error) {
+	return parseMove(s, ParseSquare)
+}
+
+// 盤面 b の幅に合わせて、USI形式の指し手を読む（盤の外のマスはエラー）
+func ParseMoveOn(b *board.Board, s string) (board.Move, error) {
+	return parseMove(s, func(s string) (int, int, bool) {
+		sq, err := b.ParseSquareUSI(s)
+		if err != nil {
+			return -1, -1, false
+		}
+		return sq.X(), sq.Y(), true
+	})
+}
+
+func parseMove(s string, square func(string) (int, int, bool)) (board.Move, error) {
+	if len(s) == 4 && s[1] == '*' {
+		for pt, l := range dropLetters {
+			if l != s[0] {
+				continue
+			}
+			x, y, ok := square(s[2:])
+			if !ok {
+				return board.Move{}, ErrInvalidMove
+			}
+			return board.Move{FromX: -1, FromY: -1, ToX: x, ToY: y, Piece: pt}, nil
+		}
+		return board.Move{}, ErrInvalidMove
+	}
+
+	promote := strings.HasSuffix(s, "+")
+	s = strings.TrimSuffix(s, "+")
+	if len(s) != 4 {
+		return board.Move{}, ErrInvalidMove
+	}
+	fromX, fromY, ok1 := square(s[:2])
+	toX, toY, ok2 := square(s[2:])
+	if !ok1 || !ok2 {
+		return board.Move{}, ErrInvalidMove
+	}
+	return board.Move{FromX: fromX, FromY: fromY, ToX: toX, ToY: toY, Promote: promote}, nil
+}
+
+// position コマンドの引数（例: sfen ... moves 7g7f 3c3d）
+// start が平手の初期局面なら startpos を使う
+func FormatPosition(start *board.Board, moves []board.Move) string {
+	sfen := start.SFEN(1)
+	var sb strings.Builder
+	if sfen == board.StartSFEN {
+		sb.WriteString("startpos")
+	} else {
+		sb.WriteString("sfen " + sfen)
+	}
+	if len(moves) > 0 {
+		sb.WriteString(" moves")
+		for _, m := range moves {
+			sb.WriteString(" " + FormatMove(m))
+		}
+	}
+	return sb.String()
+}
+
+// position コマンドの引数を解析し、開始局面と指し手を返す
+// 指し手は開始局面から順に合法かどうか確認する
+func ParsePosition(s string) (*board.Board, []board.Move, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, nil, ErrInvalidMove
+	}
+
+	var start *board.Board
+	rest := fields[1:]
+	switch fields[0] {
+	case "startpos":
+		start = board.New()
+	case "sfen":
+		end := len(rest)
+		for i, f := range rest {
+			if f == "moves" {
+				end = i
+				break
+			}
+		}
+		b, _, err := board.ParseSFEN(strings.Join(rest[:end], " "))
+		if err != nil {
+			return nil, nil, err
+		}
+		start = b
+		rest = rest[end:]
+	default:
+		return nil, nil, board.ErrInvalidSFEN
+	}
+
+	var moves []board.Move
+	if len(rest) > 0 {
+		if rest[0] != "moves" {
+			return nil, nil, ErrInvalidMove
+		}
+		b, _, _ := board.ParseSFEN(start.SFEN(1))
+		for _, f := range rest[1:] {
+			m, err := ParseMove(f)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !b.IsLegalMove(m) {
+				return nil, nil, ErrInvalidMove
+			}
+			b.MakeMove(m)
+			moves = append(moves, m)
+		}
+	}
+	return start, moves, nil
+}
@@ -0,0 +1,101 @@
+package usi
+
+import (
+	"errors"
+	"testing"
+
+	"shogi/board"
+	"shogi/piece"
+)
+
+func TestParseMove(t *testing.T) {
+	tests := []struct {
+		s    string
+		want board.Move
+	}{
+		{"7g7f", board.Move{FromX: 2, FromY: 6, ToX: 2, ToY: 5}},
+		{"8h2b+", board.Move{FromX: 1, FromY: 7, ToX: 7, ToY: 1, Promote: true}},
+		{"1a1b", board.Move{FromX: 8, FromY: 0, ToX: 8, ToY: 1}},
+		{"P*5e", board.Move{FromX: -1, FromY: -1, ToX: 4, ToY: 4, Piece: piece.Pawn}},
+		{"R*9i", board.Move{FromX: -1, FromY: -1, ToX: 0, ToY: 8, Piece: piece.Rook}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMove(tt.s)
+		if err != nil {
+			t.Errorf("ParseMove(%q): %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMove(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+		if s := FormatMove(got); s != tt.s {
+			t.Errorf("FormatMove(%+v) = %q, want %q", got, s, tt.s)
+		}
+	}
+}
+
+func TestParseMoveErrors(t *testing.T) {
+	for _, s := range []string{"", "7g", "7g7f++", "0a1a", "7j7f", "K*5e", "+*5e", "P*5", "7g7f=", "resign"} {
+		if m, err := ParseMove(s); !errors.Is(err, ErrInvalidMove) {
+			t.Errorf("ParseMove(%q) = %+v, %v, want ErrInvalidMove", s, m, err)
+		}
+	}
+}
+
+// 盤面の幅に合わせて読み書きする（どうぶつしょうぎの盤は3筋）
+func TestParseMoveOn(t *testing.T) {
+	b := board.Dobutsu.New()
+	tests := []struct {
+		s    string
+		want board.Move
+	}{
+		{"2c2b", board.Move{FromX: 1, FromY: 2, ToX: 1, ToY: 1}},
+		{"3d3c", board.Move{FromX: 0, FromY: 3, ToX: 0, ToY: 2}},
+		{"P*1b", board.Move{FromX: -1, FromY: -1, ToX: 2, ToY: 1, Piece: piece.Pawn}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoveOn(b, tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoveOn(%q) = %+v, %v, want %+v", tt.s, got, err, tt.want)
+		}
+		if s := FormatMoveOn(b, tt.want); s != tt.s {
+			t.Errorf("FormatMoveOn(%+v) = %q, want %q", tt.want, s, tt.s)
+		}
+	}
+	for _, s := range []string{"7g7f", "2c2e", "P*4a"} {
+		if _, err := ParseMoveOn(b, s); !errors.Is(err, ErrInvalidMove) {
+			t.Errorf("ParseMoveOn(%q) = %v, want ErrInvalidMove", s, err)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		s     string
+		sfen  string // 開始局面
+		moves int
+	}{
+		{"startpos", board.StartSFEN, 0},
+		{"startpos moves 7g7f 3c3d 8h2b+", board.StartSFEN, 3},
+		{"sfen 4k4/9/9/9/9/9/9/9/4K4 b G 1 moves G*5b", "4k4/9/9/9/9/9/9/9/4K4 b G 1", 1},
+	}
+	for _, tt := range tests {
+		start, moves, err := ParsePosition(tt.s)
+		if err != nil {
+			t.Errorf("ParsePosition(%q): %v", tt.s, err)
+			continue
+		}
+		if got := start.SFEN(1); got != tt.sfen || len(moves) != tt.moves {
+			t.Errorf("ParsePosition(%q) = %q, %d手, want %q, %d手", tt.s, got, len(moves), tt.sfen, tt.moves)
+		}
+		if got := FormatPosition(start, moves); got != tt.s {
+			t.Errorf("FormatPosition = %q, want %q", got, tt.s)
+		}
+	}
+
+	for _, s := range []string{"", "sfen", "position startpos", "startpos moves 7g7e", "startpos moves 5i5h 5a5b 5h5i 5b5a 7g7f 3c3d 8h2b 2c2b"} {
+		if _, _, err := ParsePosition(s); err == nil {
+			t.Errorf("ParsePosition(%q): エラーになりません", s)
+		}
+	}
+}